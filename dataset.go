@@ -3,12 +3,29 @@ package registry
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/libp2p/go-libp2p-crypto"
 	"github.com/qri-io/dataset"
 )
 
+const (
+	// SignatureV1 is the original dataset signature scheme, a signature of
+	// the commit timestamp & structure checksum stored in Commit.Signature
+	SignatureV1 = 1
+	// SignatureV2 signs the canonical registry record: operation, handle,
+	// name, path, profileID, structure checksum and commit timestamp. V2
+	// signatures are stored in Dataset.Signature
+	SignatureV2 = 2
+)
+
+// AcceptLegacySignatures controls whether Verify accepts datasets signed with
+// SignatureV1. V1 signatures don't cover handle, name, path or operation, so
+// those fields can be altered by anyone relaying a V1 registration, and a
+// registration can be replayed to deregister
+var AcceptLegacySignatures = true
+
 // Dataset is a registry's version of a dataset
 type Dataset struct {
 	// Dataset   dataset.Dataset
@@ -21,6 +38,32 @@ type Dataset struct {
 	Handle    string `json:",omitempty"`
 	Name      string `json:",omitempty"`
 	PublicKey string `json:",omitempty"`
+
+	// SignatureVersion is the signature scheme used to sign this record,
+	// zero is treated as SignatureV1
+	SignatureVersion int `json:"signatureVersion,omitempty"`
+	// Signature is a base64 encoded signature of the canonical registry
+	// record, required when SignatureVersion is SignatureV2
+	Signature string `json:"signature,omitempty"`
+	// Operation is the action a SignatureV2 signature authorizes, one of
+	// OpRegister or OpDeregister. empty is treated as OpRegister
+	Operation string `json:"operation,omitempty"`
+
+	// Deprecation is set when the dataset owner has deprecated the dataset
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
+	// DeprecationUpdated is when a deprecation was last set or removed.
+	// deprecation changes must be newer
+	DeprecationUpdated *time.Time `json:"deprecationUpdated,omitempty"`
+	// Moved is when the dataset was last moved. moves must be newer
+	Moved *time.Time `json:"moved,omitempty"`
+	// SignedKey is the handle/name a moved SignatureV2 record was signed
+	// under, used in place of Handle & Name when verifying
+	SignedKey string `json:"signedKey,omitempty"`
+	// Preview is an optional bounded sample of the dataset body & readme
+	Preview *Preview `json:"preview,omitempty"`
+	// Stats reports use of the dataset. Stats are tracked by the registry
+	// and included in responses, they're never stored with a dataset
+	Stats *DatasetStats `json:"stats,omitempty"`
 }
 
 // NewDataset creates a new dataset instance
@@ -80,14 +123,117 @@ func (d *Dataset) Key() string {
 	return fmt.Sprintf("%s/%s", d.Handle, d.Name)
 }
 
-// sigBytes gives the signable bytes from a dataset
+// sigBytes gives the signable bytes from a dataset for SignatureV1
 func (d *Dataset) sigBytes() []byte {
 	return []byte(fmt.Sprintf("%s\n%s", d.Commit.Timestamp.UTC().Format(time.RFC3339), d.Structure.Checksum))
 }
 
-// Verify checks a profile's proof of key ownership
-// Registree's must prove they have control of the private key by signing the desired handle,
-// which is validated with a provided public key. Public key, handle, and date of
+// sigBytesV2 gives the canonical signable bytes of a registry record for
+// SignatureV2. Fields are newline-delimited in a fixed order, so no field may
+// contain a newline
+func (d *Dataset) sigBytesV2() ([]byte, error) {
+	handle, name := d.Handle, d.Name
+	if d.SignedKey != "" {
+		var err error
+		if handle, name, err = SplitDatasetKey(d.SignedKey); err != nil {
+			return nil, err
+		}
+	}
+	fields := []string{
+		fmt.Sprintf("registry.Dataset/v%d", SignatureV2),
+		d.operation(),
+		handle,
+		name,
+		d.Path,
+		d.ProfileID,
+		d.Structure.Checksum,
+		d.Commit.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+	for _, f := range fields {
+		if strings.Contains(f, "\n") {
+			return nil, fmt.Errorf("signed fields cannot contain newlines")
+		}
+	}
+	return []byte(strings.Join(fields, "\n")), nil
+}
+
+// operation gives the signed operation, defaulting to OpRegister
+func (d *Dataset) operation() string {
+	if d.Operation == "" {
+		return OpRegister
+	}
+	return d.Operation
+}
+
+// Sign signs the canonical registry record for registration with privKey
+// using the latest signature scheme, setting PublicKey, ProfileID,
+// SignatureVersion and Signature. Commit and Structure must be set before
+// calling Sign
+func (d *Dataset) Sign(privKey crypto.PrivKey) error {
+	return d.SignOperation(OpRegister, privKey)
+}
+
+// SignOperation is like Sign, authorizing op instead of registration. op must
+// be one of OpRegister or OpDeregister
+func (d *Dataset) SignOperation(op string, privKey crypto.PrivKey) error {
+	if op != OpRegister && op != OpDeregister {
+		return fmt.Errorf("unsupported operation: %s", op)
+	}
+	if d.Commit == nil {
+		return fmt.Errorf("commit is required")
+	}
+	if d.Structure == nil {
+		return fmt.Errorf("structure is required")
+	}
+
+	pubb, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		return err
+	}
+	d.PublicKey = base64.StdEncoding.EncodeToString(pubb)
+	if d.ProfileID, err = profileIDFromPubKeyBytes(pubb); err != nil {
+		return err
+	}
+	d.SignatureVersion = SignatureV2
+	d.Operation = op
+	d.SignedKey = ""
+
+	data, err := d.sigBytesV2()
+	if err != nil {
+		return err
+	}
+	sig, err := privKey.Sign(data)
+	if err != nil {
+		return err
+	}
+	d.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// Verify checks the dataset was signed by the holder of PublicKey, selecting
+// the signature scheme by SignatureVersion. SignatureV1 datasets are only
+// accepted when AcceptLegacySignatures is true
 func (d *Dataset) Verify() error {
-	return verify(d.PublicKey, d.Commit.Signature, d.sigBytes())
+	switch d.SignatureVersion {
+	case 0, SignatureV1:
+		if !AcceptLegacySignatures {
+			return fmt.Errorf("signature version %d is no longer accepted", SignatureV1)
+		}
+		return verify(d.PublicKey, d.Commit.Signature, d.sigBytes())
+	case SignatureV2:
+		id, err := ProfileIDFromPublicKey(d.PublicKey)
+		if err != nil {
+			return err
+		}
+		if d.ProfileID != id {
+			return fmt.Errorf("profileID does not match publickey")
+		}
+		data, err := d.sigBytesV2()
+		if err != nil {
+			return err
+		}
+		return verify(d.PublicKey, d.Signature, data)
+	default:
+		return fmt.Errorf("unsupported signature version: %d", d.SignatureVersion)
+	}
 }
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"encoding/base64"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -92,3 +93,69 @@ func TestDatasetVerify(t *testing.T) {
 		t.Errorf("unexpected error: '%s'", err.Error())
 	}
 }
+
+func TestDatasetSignV2(t *testing.T) {
+	privKey, _, err := crypto.GenerateEd25519Key(rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ds := signedTestDataset(t, "foo", "bar", privKey)
+	if err := ds.Sign(privKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if ds.SignatureVersion != SignatureV2 {
+		t.Errorf("expected signature version %d, got: %d", SignatureV2, ds.SignatureVersion)
+	}
+	if err := ds.Verify(); err != nil {
+		t.Errorf("unexpected error: '%s'", err.Error())
+	}
+
+	cases := []struct {
+		description string
+		tamper      func(d *Dataset)
+		err         string
+	}{
+		{"handle", func(d *Dataset) { d.Handle = "baz" }, "mismatched signature"},
+		{"name", func(d *Dataset) { d.Name = "baz" }, "mismatched signature"},
+		{"path", func(d *Dataset) { d.Path = "/ipfs/QmBaz" }, "mismatched signature"},
+		{"checksum", func(d *Dataset) { d.Structure.Checksum = "QmBaz" }, "mismatched signature"},
+		{"timestamp", func(d *Dataset) { d.Commit.Timestamp = d.Commit.Timestamp.Add(time.Nanosecond) }, "mismatched signature"},
+		{"operation", func(d *Dataset) { d.Operation = OpDeregister }, "mismatched signature"},
+		{"profileID", func(d *Dataset) { d.ProfileID = "QmBaz" }, "profileID does not match publickey"},
+		{"newline", func(d *Dataset) { d.Name = "bar\nbaz" }, "signed fields cannot contain newlines"},
+		{"version", func(d *Dataset) { d.SignatureVersion = 3 }, "unsupported signature version: 3"},
+	}
+
+	for _, c := range cases {
+		d := signedTestDataset(t, "foo", "bar", privKey)
+		if err := d.Sign(privKey); err != nil {
+			t.Fatal(err.Error())
+		}
+		c.tamper(d)
+		err := d.Verify()
+		if err == nil || err.Error() != c.err {
+			t.Errorf("case %s error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+		}
+	}
+}
+
+func TestAcceptLegacySignatures(t *testing.T) {
+	privKey, _, err := crypto.GenerateEd25519Key(rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := signedTestDataset(t, "foo", "bar", privKey)
+
+	defer func() { AcceptLegacySignatures = true }()
+	AcceptLegacySignatures = false
+	if err := ds.Verify(); err == nil {
+		t.Errorf("expected legacy signature to be refused")
+	}
+	if err := ds.Sign(privKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ds.Verify(); err != nil {
+		t.Errorf("unexpected error: '%s'", err.Error())
+	}
+}
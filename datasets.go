@@ -21,6 +21,12 @@ type Datasets interface {
 	Range(iter func(key string, p *Dataset) (brk bool))
 	// SortedRange is like range but with deterministic key ordering
 	SortedRange(iter func(key string, p *Dataset) (brk bool))
+	// LoadByPath fetches a dataset by it's content-addressed path
+	LoadByPath(path string) (value *Dataset, ok bool)
+	// ListByHandle returns all datasets for a given handle, sorted by key
+	ListByHandle(handle string) []*Dataset
+	// ListByProfileID returns all datasets for a given profileID, sorted by key
+	ListByProfileID(profileID string) []*Dataset
 
 	// Store adds an entry, bypassing the register process
 	// store is only exported for administrative use cases.
@@ -32,59 +38,240 @@ type Datasets interface {
 	Delete(key string)
 }
 
-// RegisterDataset adds a dataset to the store if it's valid
-func RegisterDataset(store Datasets, d *Dataset) error {
-	if err := d.Validate(); err != nil {
+// RegisterOptions configures checks performed by RegisterProfile,
+// RegisterDataset, DeregisterDataset & MoveDataset, and the dataset handlers
+// built on them
+type RegisterOptions struct {
+	// Profiles, if set, requires the handle of a dataset to resolve to a
+	// registered profile with a matching public key
+	Profiles Profiles
+	// Organizations, if set, restricts datasets published under an
+	// organization handle to organization members, and prevents profiles
+	// from claiming organization handles
+	Organizations Organizations
+	// Redirects, if set, records the previous location of moved datasets
+	Redirects Redirects
+	// Stats, if set, counts dataset lookups
+	Stats Stats
+	// Events, if set, records committed dataset changes
+	Events EventLog
+	// Lock, if set, is held by UnitOfWork.Apply for the whole of each change,
+	// and by CheckIndex & Reindex. units of work that share a store should
+	// share a lock
+	Lock sync.Locker
+}
+
+// WithProfiles creates a configuration func for passing to
+// RegisterDataset & DeregisterDataset
+func WithProfiles(ps Profiles) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Profiles = ps
+	}
+}
+
+// WithOrganizations creates a configuration func for passing to
+// RegisterProfile, RegisterDataset & DeregisterDataset
+func WithOrganizations(orgs Organizations) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Organizations = orgs
+	}
+}
+
+// WithRedirects creates a configuration func for passing to MoveDataset
+func WithRedirects(rs Redirects) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Redirects = rs
+	}
+}
+
+// WithStats creates a configuration func for passing to dataset handlers
+// that count dataset use
+func WithStats(s Stats) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Stats = s
+	}
+}
+
+// WithEvents creates a configuration func for passing to NewUnitOfWork
+func WithEvents(l EventLog) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Events = l
+	}
+}
+
+// WithLock creates a configuration func for passing to NewUnitOfWork
+func WithLock(l sync.Locker) func(o *RegisterOptions) {
+	return func(o *RegisterOptions) {
+		o.Lock = l
+	}
+}
+
+// RegisterDataset adds a dataset to the store if it's valid. The
+// dataset's ProfileID is set to the profileID of the signing key,
+// and datasets can't replace a dataset owned by another profile.
+// Registrations must have a newer commit than the stored record, and can't
+// recreate a key a dataset has moved away from, so old registrations can't
+// be replayed. Deprecation & move history is kept from the stored record,
+// never taken from d
+func RegisterDataset(store Datasets, d *Dataset, opts ...func(o *RegisterOptions)) error {
+	if err := checkDataset(store, d, OpRegister, opts); err != nil {
 		return err
 	}
-	if err := d.Verify(); err != nil {
+	if err := checkReplay(store, d, opts); err != nil {
 		return err
 	}
+	if d.Preview != nil {
+		d.Preview.Trim()
+	}
+	d.Stats = nil
+	d.Deprecation = nil
+	d.DeprecationUpdated = nil
+	d.Moved = nil
 
-	prev := ""
 	dkey := d.Key()
-	store.Range(func(key string, d *Dataset) bool {
-		if key == dkey {
-			prev = key
-			return true
-		}
-		return false
-	})
-
-	if prev != "" {
-		store.Delete(prev)
+	if prev, ok := store.Load(dkey); ok {
+		d.Deprecation = prev.Deprecation
+		d.DeprecationUpdated = prev.DeprecationUpdated
+		d.Moved = prev.Moved
+		store.Delete(dkey)
 	}
 
 	store.Store(dkey, d)
 	return nil
 }
 
-// DeregisterDataset removes a dataset from a given store if it exists & is valid
-func DeregisterDataset(store Datasets, d *Dataset) error {
-	if err := d.Validate(); err != nil {
+// DeregisterDataset removes a dataset from a given store if it exists & is
+// valid. SignatureV2 datasets must be signed for OpDeregister
+func DeregisterDataset(store Datasets, d *Dataset, opts ...func(o *RegisterOptions)) error {
+	if err := checkDataset(store, d, OpDeregister, opts); err != nil {
 		return err
 	}
-	if err := d.Verify(); err != nil {
+
+	store.Delete(d.Key())
+	return nil
+}
+
+// checkDataset validates & verifies a dataset, confirming the signer is
+// allowed to publish under the dataset's handle and that SignatureV2
+// signatures authorize op. checkDataset sets the ProfileID of d to the
+// profileID of the signing key
+func checkDataset(store Datasets, d *Dataset, op string, opts []func(o *RegisterOptions)) (err error) {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err = d.Validate(); err != nil {
+		return withKind(ErrInvalid, err)
+	}
+	// requests are signed under their own key, SignedKey is only set by moves
+	d.SignedKey = ""
+	if err = d.Verify(); err != nil {
+		return withKind(ErrUnauthorized, err)
+	}
+	if d.SignatureVersion == SignatureV2 && d.operation() != op {
+		return NewError(ErrUnauthorized, "signature authorizes '%s', not '%s'", d.operation(), op)
+	}
+	if d.ProfileID, err = ProfileIDFromPublicKey(d.PublicKey); err != nil {
+		return withKind(ErrInvalid, err)
+	}
+
+	org, err := checkHandle(d.Handle, d.ProfileID, d.PublicKey, o)
+	if err != nil || org {
+		// organization members share ownership of datasets
 		return err
 	}
 
-	store.Delete(d.Key())
+	if prev, ok := store.Load(d.Key()); ok && prev.ProfileID != "" && prev.ProfileID != d.ProfileID {
+		return NewError(ErrUnauthorized, "dataset '%s' is owned by another profile", d.Key())
+	}
+	return nil
+}
+
+// checkReplay rejects registrations that aren't newer than the stored
+// record, and registrations of a key a dataset has moved away from
+func checkReplay(store Datasets, d *Dataset, opts []func(o *RegisterOptions)) error {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dkey := d.Key()
+	if prev, ok := store.Load(dkey); ok && prev.Commit != nil && !d.Commit.Timestamp.After(prev.Commit.Timestamp) {
+		return NewError(ErrConflict, "registration is not newer than dataset '%s'", dkey)
+	}
+	if o.Redirects != nil {
+		if r, ok := o.Redirects.Load(dkey); ok {
+			return NewError(ErrConflict, "dataset '%s' has moved to '%s'", dkey, r.To)
+		}
+	}
+	if moved, ok := store.LoadByPath(d.Path); ok && moved.Key() != dkey && moved.Moved != nil {
+		if moved.SignedKey == dkey || !d.Commit.Timestamp.After(*moved.Moved) {
+			return NewError(ErrConflict, "dataset '%s' has moved to '%s'", dkey, moved.Key())
+		}
+	}
+	return nil
+}
+
+// checkHandle confirms the holder of pubKey may publish datasets under
+// handle, reporting whether handle belongs to an organization
+func checkHandle(handle, profileID, pubKey string, o *RegisterOptions) (org bool, err error) {
+	if o.Organizations != nil {
+		if org, ok := o.Organizations.Load(handle); ok {
+			if !org.IsMember(profileID) {
+				return true, NewError(ErrUnauthorized, "signer is not a member of organization '%s'", handle)
+			}
+			return true, nil
+		}
+	}
+
+	if o.Profiles != nil {
+		pro, ok := o.Profiles.Load(handle)
+		if !ok {
+			return false, NewError(ErrInvalid, "handle '%s' is not registered", handle)
+		}
+		if pro.PublicKey != pubKey {
+			return false, NewError(ErrUnauthorized, "publickey does not match profile '%s'", handle)
+		}
+	}
+	return false, nil
+}
+
+// checkOwner confirms profileID owns a registered dataset, either directly
+// or through organization membership
+func checkOwner(d *Dataset, profileID string, o *RegisterOptions) error {
+	if o.Organizations != nil {
+		if org, ok := o.Organizations.Load(d.Handle); ok {
+			if !org.IsMember(profileID) {
+				return NewError(ErrUnauthorized, "signer is not a member of organization '%s'", d.Handle)
+			}
+			return nil
+		}
+	}
+	if d.ProfileID != profileID {
+		return NewError(ErrUnauthorized, "dataset '%s' is owned by another profile", d.Key())
+	}
 	return nil
 }
 
 // MemDatasets is a map of datasets data safe for concurrent use
-// heavily inspired by sync.Map
+// heavily inspired by sync.Map. MemDatasets keeps secondary indexes
+// of path, handle and profileID to keys
 type MemDatasets struct {
 	sync.RWMutex
-	tips     map[string]string
-	internal map[string]*Dataset
+	internal    map[string]*Dataset
+	byPath      map[string]keySet
+	byHandle    map[string]keySet
+	byProfileID map[string]keySet
 }
 
 // NewMemDatasets allocates a new *MemDatasets map
 func NewMemDatasets() *MemDatasets {
 	return &MemDatasets{
-		tips:     make(map[string]string),
-		internal: make(map[string]*Dataset),
+		internal:    make(map[string]*Dataset),
+		byPath:      make(map[string]keySet),
+		byHandle:    make(map[string]keySet),
+		byProfileID: make(map[string]keySet),
 	}
 }
 
@@ -131,9 +318,46 @@ func (ds *MemDatasets) SortedRange(iter func(key string, p *Dataset) (brk bool))
 	}
 }
 
+// LoadByPath fetches a dataset by it's content-addressed path. When more
+// than one dataset shares a path the first by key is returned
+func (ds *MemDatasets) LoadByPath(path string) (value *Dataset, ok bool) {
+	ds.RLock()
+	defer ds.RUnlock()
+	keys := ds.byPath[path].sorted()
+	if len(keys) == 0 {
+		return nil, false
+	}
+	value, ok = ds.internal[keys[0]]
+	return
+}
+
+// ListByHandle returns all datasets for a given handle, sorted by key
+func (ds *MemDatasets) ListByHandle(handle string) []*Dataset {
+	ds.RLock()
+	defer ds.RUnlock()
+	return ds.list(ds.byHandle[handle])
+}
+
+// ListByProfileID returns all datasets for a given profileID, sorted by key
+func (ds *MemDatasets) ListByProfileID(profileID string) []*Dataset {
+	ds.RLock()
+	defer ds.RUnlock()
+	return ds.list(ds.byProfileID[profileID])
+}
+
+// list fetches datasets for a set of keys. callers must hold the lock
+func (ds *MemDatasets) list(keys keySet) []*Dataset {
+	res := make([]*Dataset, 0, len(keys))
+	for _, key := range keys.sorted() {
+		res = append(res, ds.internal[key])
+	}
+	return res
+}
+
 // Delete removes a record from MemDatasets at key
 func (ds *MemDatasets) Delete(key string) {
 	ds.Lock()
+	ds.unindex(key)
 	delete(ds.internal, key)
 	ds.Unlock()
 }
@@ -141,6 +365,56 @@ func (ds *MemDatasets) Delete(key string) {
 // Store adds an entry
 func (ds *MemDatasets) Store(key string, value *Dataset) {
 	ds.Lock()
+	ds.unindex(key)
 	ds.internal[key] = value
+	keySetAdd(ds.byPath, value.Path, key)
+	keySetAdd(ds.byHandle, value.Handle, key)
+	keySetAdd(ds.byProfileID, value.ProfileID, key)
 	ds.Unlock()
 }
+
+// unindex removes any index entries for the value stored at key. callers
+// must hold the write lock
+func (ds *MemDatasets) unindex(key string) {
+	prev, ok := ds.internal[key]
+	if !ok {
+		return
+	}
+	keySetRemove(ds.byPath, prev.Path, key)
+	keySetRemove(ds.byHandle, prev.Handle, key)
+	keySetRemove(ds.byProfileID, prev.ProfileID, key)
+}
+
+// keySet is a set of store keys, used for one-to-many secondary indexes
+type keySet map[string]struct{}
+
+// sorted returns the keys in the set in lexographical order
+func (ks keySet) sorted() []string {
+	keys := make([]string, 0, len(ks))
+	for key := range ks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keySetAdd adds key to the set at idx[val], ignoring empty index values
+func keySetAdd(idx map[string]keySet, val, key string) {
+	if val == "" {
+		return
+	}
+	if idx[val] == nil {
+		idx[val] = keySet{}
+	}
+	idx[val][key] = struct{}{}
+}
+
+// keySetRemove drops key from the set at idx[val], removing empty sets
+func keySetRemove(idx map[string]keySet, val, key string) {
+	if set, ok := idx[val]; ok {
+		delete(set, key)
+		if len(set) == 0 {
+			delete(idx, val)
+		}
+	}
+}
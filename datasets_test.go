@@ -2,6 +2,7 @@ package registry
 
 import (
 	"encoding/base64"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -97,7 +98,7 @@ func TestRegisterDataset(t *testing.T) {
 			Structure: &dataset.Structure{
 				Checksum: "QmcCcPTqmckdXLBwPQXxfyW2BbFcUT6gqv9oGeWDkrNTyD",
 			},
-		}, "foo", "bar", ""},
+		}, "foo", "bar", "registration is not newer than dataset 'foo/bar'"},
 		// {Datasets{DatasetsID: p.DatasetsID, Handle: p.Handle, Signature: p.Signature, PublicKey: "bad_data"}, "publickey base64 encoding: illegal base64 data at input byte 3"},
 		// {Datasets{DatasetsID: p.DatasetsID, Handle: p.Handle, Signature: p.Signature, PublicKey: base64.StdEncoding.EncodeToString([]byte("bad_data"))}, "invalid publickey: unexpected EOF"},
 		// {Datasets{DatasetsID: p.DatasetsID, Handle: p.Handle, PublicKey: p.PublicKey, Signature: "bad_data"}, "signature base64 encoding: illegal base64 data at input byte 3"},
@@ -198,3 +199,197 @@ func TestDatasetsSortedRange(t *testing.T) {
 		break
 	}
 }
+
+func TestMemDatasetsIndexes(t *testing.T) {
+	dss := NewMemDatasets()
+	dss.Store("a/foo", &Dataset{Handle: "a", Name: "foo", Path: "/ipfs/QmFoo", ProfileID: "QmA"})
+	dss.Store("a/bar", &Dataset{Handle: "a", Name: "bar", Path: "/ipfs/QmBar", ProfileID: "QmA"})
+	dss.Store("b/baz", &Dataset{Handle: "b", Name: "baz", Path: "/ipfs/QmBaz", ProfileID: "QmB"})
+
+	if ds, ok := dss.LoadByPath("/ipfs/QmBar"); !ok || ds.Name != "bar" {
+		t.Errorf("expected path /ipfs/QmBar to load dataset 'bar'")
+	}
+	if got := dss.ListByHandle("a"); len(got) != 2 || got[0].Name != "bar" || got[1].Name != "foo" {
+		t.Errorf("expected handle 'a' to list [bar foo], got %d results", len(got))
+	}
+	if got := dss.ListByProfileID("QmB"); len(got) != 1 || got[0].Name != "baz" {
+		t.Errorf("expected profileID QmB to list [baz], got %d results", len(got))
+	}
+
+	// overwriting a key should drop stale index entries
+	dss.Store("a/foo", &Dataset{Handle: "a", Name: "foo", Path: "/ipfs/QmFoo2", ProfileID: "QmA"})
+	if _, ok := dss.LoadByPath("/ipfs/QmFoo"); ok {
+		t.Errorf("expected stale path to be removed from index")
+	}
+	if _, ok := dss.LoadByPath("/ipfs/QmFoo2"); !ok {
+		t.Errorf("expected updated path to load")
+	}
+
+	dss.Delete("b/baz")
+	if _, ok := dss.LoadByPath("/ipfs/QmBaz"); ok {
+		t.Errorf("expected deleted path to be removed from index")
+	}
+	if got := dss.ListByProfileID("QmB"); len(got) != 0 {
+		t.Errorf("expected deleted dataset to be removed from profileID index")
+	}
+
+	// datasets sharing a path stay loadable when one of them is removed
+	dss.Store("b/fork", &Dataset{Handle: "b", Name: "fork", Path: "/ipfs/QmBar", ProfileID: "QmB"})
+	dss.Delete("a/bar")
+	if ds, ok := dss.LoadByPath("/ipfs/QmBar"); !ok || ds.Name != "fork" {
+		t.Errorf("expected shared path /ipfs/QmBar to load dataset 'fork'")
+	}
+	dss.Delete("b/fork")
+	if _, ok := dss.LoadByPath("/ipfs/QmBar"); ok {
+		t.Errorf("expected path to be removed once no datasets share it")
+	}
+}
+
+func TestRegisterDatasetReplay(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	aliceKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// registration builds a signed registration of alice/cities, committed
+	// secs seconds after the base commit timestamp
+	registration := func(path string, secs int) *Dataset {
+		d := signedTestDataset(t, "alice", "cities", aliceKey)
+		d.Path = path
+		d.Commit.Timestamp = d.Commit.Timestamp.Add(time.Duration(secs) * time.Second)
+		if err := d.Sign(aliceKey); err != nil {
+			t.Fatal(err.Error())
+		}
+		return d
+	}
+
+	dss := NewMemDatasets()
+	if err := RegisterDataset(dss, registration("/ipfs/QmOld", 0)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterDataset(dss, registration("/ipfs/QmNew", 1)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterDataset(dss, registration("/ipfs/QmOld", 0)); ErrorKind(err) != ErrConflict {
+		t.Errorf("expected replaying an older registration to conflict, got: %v", err)
+	}
+	if err := RegisterDataset(dss, registration("/ipfs/QmNew", 1)); ErrorKind(err) != ErrConflict {
+		t.Errorf("expected replaying the current registration to conflict, got: %v", err)
+	}
+	if d, _ := dss.Load("alice/cities"); d.Path != "/ipfs/QmNew" {
+		t.Errorf("expected stored path to be /ipfs/QmNew, got: %s", d.Path)
+	}
+
+	// moved datasets can't be brought back to their previous key, whether
+	// or not a redirect is kept
+	rs := NewMemRedirects()
+	for _, opts := range [][]func(o *RegisterOptions){{WithRedirects(rs)}, nil} {
+		dss := NewMemDatasets()
+		if err := RegisterDataset(dss, registration("/ipfs/QmNew", 1), opts...); err != nil {
+			t.Fatal(err.Error())
+		}
+		mv, err := NewDatasetMove("alice/cities", "alice/towns", aliceKey)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := MoveDataset(dss, mv, opts...); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := RegisterDataset(dss, registration("/ipfs/QmNew", 1), opts...); ErrorKind(err) != ErrConflict {
+			t.Errorf("expected replaying a registration of a moved dataset to conflict, got: %v", err)
+		}
+		if _, ok := dss.Load("alice/cities"); ok {
+			t.Errorf("expected moved dataset to stay at it's new key")
+		}
+	}
+}
+
+func TestRegisterDatasetOwnership(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	aliceKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	bobKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	alice, err := ProfileFromPrivateKey("alice", aliceKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ps := NewMemProfiles()
+	if err := RegisterProfile(ps, alice); err != nil {
+		t.Fatal(err.Error())
+	}
+	dss := NewMemDatasets()
+
+	unregistered := signedTestDataset(t, "carol", "census", bobKey)
+	if err := RegisterDataset(dss, unregistered, WithProfiles(ps)); err == nil || err.Error() != "handle 'carol' is not registered" {
+		t.Errorf("expected unregistered handle error, got: %v", err)
+	}
+
+	forged := signedTestDataset(t, "alice", "census", bobKey)
+	if err := RegisterDataset(dss, forged, WithProfiles(ps)); err == nil || err.Error() != "publickey does not match profile 'alice'" {
+		t.Errorf("expected publickey mismatch error, got: %v", err)
+	}
+
+	ds := signedTestDataset(t, "alice", "census", aliceKey)
+	ds.ProfileID = "client_provided"
+	if err := RegisterDataset(dss, ds, WithProfiles(ps)); err != nil {
+		t.Fatal(err.Error())
+	}
+	stored, ok := dss.Load("alice/census")
+	if !ok {
+		t.Fatal("expected dataset to be stored")
+	}
+	if stored.ProfileID != alice.ProfileID {
+		t.Errorf("expected ProfileID to be set server-side. expected: %s, got: %s", alice.ProfileID, stored.ProfileID)
+	}
+
+	// without profile checks, ownership of existing keys is still enforced
+	if err := RegisterDataset(dss, forged); err == nil || err.Error() != "dataset 'alice/census' is owned by another profile" {
+		t.Errorf("expected ownership error, got: %v", err)
+	}
+	if err := DeregisterDataset(dss, forged); err == nil {
+		t.Errorf("expected deregistering another profile's dataset to error")
+	}
+	if err := DeregisterDataset(dss, ds, WithProfiles(ps)); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestDeregisterDatasetOperation(t *testing.T) {
+	privKey, _, err := crypto.GenerateEd25519Key(rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dss := NewMemDatasets()
+	ds := signedTestDataset(t, "alice", "census", privKey)
+	if err := ds.Sign(privKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterDataset(dss, ds); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	replayed := *ds
+	if err := DeregisterDataset(dss, &replayed); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected a registration signature to be refused for deregistration, got: %v", err)
+	}
+
+	del := signedTestDataset(t, "alice", "census", privKey)
+	if err := del.SignOperation(OpDeregister, privKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterDataset(dss, del); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected a deregistration signature to be refused for registration, got: %v", err)
+	}
+	if err := DeregisterDataset(dss, del); err != nil {
+		t.Error(err.Error())
+	}
+	if dss.Len() != 0 {
+		t.Errorf("expected dataset to be removed")
+	}
+}
@@ -0,0 +1,66 @@
+package registry
+
+import "fmt"
+
+// Error kinds classify why a registry operation failed, so callers can
+// respond appropriately, eg: with an HTTP status code. Use ErrorKind to get
+// the kind of an error
+var (
+	// ErrNotFound is the canonical error for a record that isn't in a store
+	ErrNotFound = fmt.Errorf("not found")
+	// ErrConflict indicates a change conflicts with existing registry state,
+	// eg: a handle that's already taken
+	ErrConflict = fmt.Errorf("conflict")
+	// ErrUnauthorized indicates a change wasn't signed by a key allowed to
+	// make it
+	ErrUnauthorized = fmt.Errorf("unauthorized")
+	// ErrInvalid indicates a malformed or incomplete request
+	ErrInvalid = fmt.Errorf("invalid")
+)
+
+// Error is a registry error of a kind, with a message describing the
+// specific failure
+type Error struct {
+	Kind    error
+	Message string
+}
+
+// NewError creates an error of kind with a formatted message
+func NewError(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap gives the kind of error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorKind gives the kind of err, one of ErrNotFound, ErrConflict,
+// ErrUnauthorized or ErrInvalid. Errors without a kind return nil
+func ErrorKind(err error) error {
+	switch err {
+	case ErrNotFound, ErrConflict, ErrUnauthorized, ErrInvalid:
+		return err
+	}
+	switch e := err.(type) {
+	case *Error:
+		return e.Kind
+	case *QueryError:
+		return ErrInvalid
+	}
+	return nil
+}
+
+// withKind classifies an error, keeping its message. errors that already
+// have a kind are returned as-is
+func withKind(kind, err error) error {
+	if err == nil || ErrorKind(err) != nil {
+		return err
+	}
+	return &Error{Kind: kind, Message: err.Error()}
+}
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestErrorKind(t *testing.T) {
+	ps := NewMemProfiles()
+	src := rand.New(rand.NewSource(0))
+	key0, _, err := crypto.GenerateSecp256k1Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	key1, _, err := crypto.GenerateSecp256k1Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	p0, err := ProfileFromPrivateKey("taken", key0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	p1, err := ProfileFromPrivateKey("taken", key1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterProfile(ps, p0); err != nil {
+		t.Fatal(err.Error())
+	}
+	forged := *p1
+	forged.Signature = p0.Signature
+	mv, err := NewDatasetMove("taken/missing", "taken/moved", key0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, moveErr := MoveDataset(NewMemDatasets(), mv)
+
+	cases := []struct {
+		err  error
+		kind error
+	}{
+		{ErrNotFound, ErrNotFound},
+		{fmt.Errorf("not found"), nil},
+		{nil, nil},
+		{RegisterProfile(ps, &Profile{Handle: "a"}), ErrInvalid},
+		{RegisterProfile(ps, &forged), ErrUnauthorized},
+		{RegisterProfile(ps, p1), ErrConflict},
+		{DeregisterProfile(ps, p1), ErrUnauthorized},
+		{moveErr, ErrNotFound},
+	}
+	for i, c := range cases {
+		if got := ErrorKind(c.err); got != c.kind {
+			t.Errorf("case %d: expected kind %v for error '%v', got: %v", i, c.kind, c.err, got)
+		}
+	}
+
+	if _, ok := ps.Load("taken"); !ok {
+		t.Error("expected deregistering another key's profile to leave it in place")
+	}
+}
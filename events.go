@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType names a kind of change to registry datasets
+type EventType string
+
+const (
+	// EventDatasetRegistered records a dataset being registered or updated
+	EventDatasetRegistered EventType = "dataset:registered"
+	// EventDatasetDeregistered records a dataset being removed
+	EventDatasetDeregistered EventType = "dataset:deregistered"
+	// EventDatasetMoved records a dataset changing handle or name
+	EventDatasetMoved EventType = "dataset:moved"
+	// EventDatasetDeprecated records a dataset being marked deprecated
+	EventDatasetDeprecated EventType = "dataset:deprecated"
+	// EventDatasetUndeprecated records a deprecation being lifted
+	EventDatasetUndeprecated EventType = "dataset:undeprecated"
+	// EventDatasetsStored records datasets written directly to the store by
+	// an administrator
+	EventDatasetsStored EventType = "datasets:stored"
+)
+
+// Event is a single entry in an EventLog, describing the change to one
+// dataset key
+type Event struct {
+	Type EventType `json:"type"`
+	Key  string    `json:"key"`
+	// Dataset is the value stored at Key after the change, nil if the change
+	// removed Key from the store
+	Dataset   *Dataset  `json:"dataset,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventLog is an append-only record of committed registry changes
+type EventLog interface {
+	// Append adds events to the log. Append must add all events or none
+	Append(events ...*Event) error
+}
+
+// MemEventLog is an in-memory EventLog safe for concurrent use
+type MemEventLog struct {
+	sync.RWMutex
+	events []*Event
+}
+
+// NewMemEventLog allocates a new, empty *MemEventLog
+func NewMemEventLog() *MemEventLog {
+	return &MemEventLog{}
+}
+
+// Append adds events to the end of the log
+func (l *MemEventLog) Append(events ...*Event) error {
+	l.Lock()
+	l.events = append(l.events, events...)
+	l.Unlock()
+	return nil
+}
+
+// Len returns the number of events in the log
+func (l *MemEventLog) Len() int {
+	l.RLock()
+	defer l.RUnlock()
+	return len(l.events)
+}
+
+// Range calls an iteration function on each event in the order they were
+// appended until the end of the log is reached or iter returns true
+func (l *MemEventLog) Range(iter func(e *Event) (brk bool)) {
+	l.RLock()
+	defer l.RUnlock()
+	for _, e := range l.events {
+		if iter(e) {
+			break
+		}
+	}
+}
@@ -14,11 +14,18 @@ replace (
 )
 
 require (
+	github.com/ghodss/yaml v1.0.0
+	github.com/ipfs/go-block-format v0.0.2
+	github.com/ipfs/go-cid v0.0.2
 	github.com/ipfs/go-ipld-format v0.0.2
+	github.com/ipfs/go-merkledag v0.0.3
 	github.com/ipfs/interface-go-ipfs-core v0.0.8
+	github.com/lib/pq v1.10.9
 	github.com/libp2p/go-libp2p-crypto v0.0.2
+	github.com/mattn/go-sqlite3 v1.11.0
 	github.com/mr-tron/base58 v1.1.2
 	github.com/multiformats/go-multihash v0.0.5
+	github.com/prometheus/client_golang v0.9.3
 	github.com/qri-io/apiutil v0.1.0
 	github.com/qri-io/dag v0.1.1-0.20190826121154-bee27f6db672
 	github.com/qri-io/dataset v0.1.3-0.20190617151150-bd20b1913ba5
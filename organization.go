@@ -0,0 +1,200 @@
+package registry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-crypto"
+)
+
+const (
+	// RoleOwner members can manage an organization's membership
+	RoleOwner = "owner"
+	// RoleMember members can register & deregister an organization's datasets
+	RoleMember = "member"
+)
+
+// Organization is a shared handle that datasets can be published under.
+// Owners & Members are lists of profileIDs. Owners are implicitly members.
+// Organizations are created by signing the desired handle, the creator becomes
+// the organization's first owner
+type Organization struct {
+	Handle  string
+	Owners  []string
+	Members []string `json:",omitempty"`
+	Created time.Time
+	Updated time.Time
+
+	// Operation, Timestamp, PublicKey & Signature are only used to prove key
+	// ownership when registering & deregistering. Operation is one of
+	// OpRegister or OpDeregister
+	Operation string     `json:",omitempty"`
+	Timestamp *time.Time `json:",omitempty"`
+	PublicKey string     `json:",omitempty"`
+	Signature string     `json:",omitempty"`
+}
+
+// OrganizationFromPrivateKey creates a signed organization registration
+// request for a handle
+func OrganizationFromPrivateKey(handle string, privKey crypto.PrivKey) (*Organization, error) {
+	return NewOrganizationRequest(OpRegister, handle, privKey)
+}
+
+// NewOrganizationRequest creates a signed request to perform op on the
+// organization at handle, op must be one of OpRegister or OpDeregister
+func NewOrganizationRequest(op, handle string, privKey crypto.PrivKey) (*Organization, error) {
+	pubkeybytes, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+	}
+	ts := nowFunc().UTC()
+	o := &Organization{
+		Handle:    handle,
+		Operation: op,
+		Timestamp: &ts,
+		PublicKey: base64.StdEncoding.EncodeToString(pubkeybytes),
+	}
+
+	sigbytes, err := privKey.Sign(o.sigBytes())
+	if err != nil {
+		return nil, fmt.Errorf("error signing %s", err.Error())
+	}
+	o.Signature = base64.StdEncoding.EncodeToString(sigbytes)
+	return o, nil
+}
+
+// Validate is a sanity check that all required values are present
+func (o *Organization) Validate() error {
+	if o.Handle == "" {
+		return fmt.Errorf("handle is required")
+	}
+	if o.Operation != OpRegister && o.Operation != OpDeregister {
+		return fmt.Errorf("operation must be one of '%s' or '%s'", OpRegister, OpDeregister)
+	}
+	if o.Timestamp == nil || o.Timestamp.IsZero() {
+		return fmt.Errorf("timestamp is required")
+	}
+	if o.Signature == "" {
+		return fmt.Errorf("signature is required")
+	}
+	if o.PublicKey == "" {
+		return fmt.Errorf("publickey is required")
+	}
+	return nil
+}
+
+// Verify checks the signer has control of the provided public key by
+// validating a signature of the organization handle, operation & timestamp
+func (o *Organization) Verify() error {
+	return verify(o.PublicKey, o.Signature, o.sigBytes())
+}
+
+// sigBytes gives the signable bytes from an organization request
+func (o *Organization) sigBytes() []byte {
+	var ts string
+	if o.Timestamp != nil {
+		ts = o.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	return []byte(fmt.Sprintf("%s\n%s\n%s", o.Handle, o.Operation, ts))
+}
+
+// IsOwner returns true if profileID is an owner of the organization
+func (o *Organization) IsOwner(profileID string) bool {
+	return contains(o.Owners, profileID)
+}
+
+// IsMember returns true if profileID is an owner or member of the
+// organization
+func (o *Organization) IsMember(profileID string) bool {
+	return contains(o.Owners, profileID) || contains(o.Members, profileID)
+}
+
+// MembershipChange is a signed request to add or remove a profile from an
+// organization. Changes must be signed by an owner of the organization, and
+// are only accepted if Timestamp is after the organization's last update,
+// preventing replay of old requests
+type MembershipChange struct {
+	Org       string
+	ProfileID string
+	Role      string
+	Remove    bool
+	Timestamp time.Time
+	PublicKey string
+	Signature string
+}
+
+// NewMembershipChange creates a membership change signed by privKey
+func NewMembershipChange(org, profileID, role string, remove bool, privKey crypto.PrivKey) (*MembershipChange, error) {
+	pubkeybytes, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+	}
+	mc := &MembershipChange{
+		Org:       org,
+		ProfileID: profileID,
+		Role:      role,
+		Remove:    remove,
+		Timestamp: nowFunc().UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(pubkeybytes),
+	}
+
+	sigbytes, err := privKey.Sign(mc.sigBytes())
+	if err != nil {
+		return nil, fmt.Errorf("error signing %s", err.Error())
+	}
+	mc.Signature = base64.StdEncoding.EncodeToString(sigbytes)
+	return mc, nil
+}
+
+// Validate is a sanity check that all required values are present
+func (mc *MembershipChange) Validate() error {
+	if mc.Org == "" {
+		return fmt.Errorf("org is required")
+	}
+	if mc.ProfileID == "" {
+		return fmt.Errorf("profileID is required")
+	}
+	if mc.Role != RoleOwner && mc.Role != RoleMember {
+		return fmt.Errorf("role must be one of '%s' or '%s'", RoleOwner, RoleMember)
+	}
+	if mc.Timestamp.IsZero() {
+		return fmt.Errorf("timestamp is required")
+	}
+	if mc.Signature == "" {
+		return fmt.Errorf("signature is required")
+	}
+	if mc.PublicKey == "" {
+		return fmt.Errorf("publickey is required")
+	}
+	return nil
+}
+
+// Verify checks the change was signed by the provided public key
+func (mc *MembershipChange) Verify() error {
+	return verify(mc.PublicKey, mc.Signature, mc.sigBytes())
+}
+
+// sigBytes gives the signable bytes from a membership change
+func (mc *MembershipChange) sigBytes() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%t\n%s", mc.Org, mc.ProfileID, mc.Role, mc.Remove, mc.Timestamp.UTC().Format(time.RFC3339Nano)))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(list []string, s string) []string {
+	res := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != s {
+			res = append(res, v)
+		}
+	}
+	return res
+}
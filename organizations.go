@@ -0,0 +1,236 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+)
+
+// Organizations is the interface for working with a set of *Organization's
+// Register, Deregister, UpdateMembership, Load, Len, Range, and SortedRange
+// should be considered safe to hook up to public http endpoints, whereas
+// Delete & Store should only be exposed in administrative contexts
+type Organizations interface {
+	// Len returns the number of records in the set
+	Len() int
+	// Load fetches an organization from the list by handle
+	Load(handle string) (value *Organization, ok bool)
+	// Range calls an iteration fuction on each element in the map until
+	// the end of the list is reached or iter returns true
+	Range(iter func(handle string, o *Organization) (brk bool))
+	// SortedRange is like range but with deterministic key ordering
+	SortedRange(iter func(handle string, o *Organization) (brk bool))
+
+	// Store adds an entry, bypassing the register process
+	// store is only exported for administrative use cases.
+	// most of the time callers should use Register instead
+	Store(handle string, value *Organization)
+	// Delete removes a record from the set at handle
+	// Delete is only exported for administrative use cases.
+	// most of the time callers should use Deregister instead
+	Delete(handle string)
+}
+
+// orgsLock serializes organization changes, so concurrent read-modify-write
+// updates can't overwrite each other
+var orgsLock sync.Mutex
+
+// RegisterOrganization creates an organization if the desired handle isn't
+// taken by another organization or a profile. The signer of o becomes the
+// organization's first owner. Requests must be signed for OpRegister within
+// MaxRequestAge of now
+func RegisterOrganization(orgs Organizations, profiles Profiles, o *Organization) error {
+	if err := checkOrganizationRequest(o, OpRegister); err != nil {
+		return err
+	}
+
+	orgsLock.Lock()
+	defer orgsLock.Unlock()
+	if _, ok := orgs.Load(o.Handle); ok {
+		return NewError(ErrConflict, "handle '%s' is taken", o.Handle)
+	}
+	if profiles != nil {
+		if _, ok := profiles.Load(o.Handle); ok {
+			return NewError(ErrConflict, "handle '%s' is taken", o.Handle)
+		}
+	}
+
+	owner, err := ProfileIDFromPublicKey(o.PublicKey)
+	if err != nil {
+		return withKind(ErrInvalid, err)
+	}
+
+	now := nowFunc()
+	orgs.Store(o.Handle, &Organization{
+		Handle:  o.Handle,
+		Owners:  []string{owner},
+		Created: now,
+		Updated: now,
+	})
+	return nil
+}
+
+// DeregisterOrganization removes an organization. The request must be signed
+// for OpDeregister by an owner of the organization, and is only accepted if
+// its timestamp is after the organization's last update
+func DeregisterOrganization(orgs Organizations, o *Organization) error {
+	if err := checkOrganizationRequest(o, OpDeregister); err != nil {
+		return err
+	}
+
+	orgsLock.Lock()
+	defer orgsLock.Unlock()
+	org, ok := orgs.Load(o.Handle)
+	if !ok {
+		return NewError(ErrNotFound, "organization '%s' not found", o.Handle)
+	}
+	signer, err := ProfileIDFromPublicKey(o.PublicKey)
+	if err != nil {
+		return withKind(ErrInvalid, err)
+	}
+	if !org.IsOwner(signer) {
+		return NewError(ErrUnauthorized, "only organization owners can remove an organization")
+	}
+	if !o.Timestamp.After(org.Updated) {
+		return NewError(ErrConflict, "deregistration is older than the latest organization update")
+	}
+
+	orgs.Delete(o.Handle)
+	return nil
+}
+
+// checkOrganizationRequest validates & verifies a signed organization request
+// for op, rejecting stale timestamps
+func checkOrganizationRequest(o *Organization, op string) error {
+	if err := o.Validate(); err != nil {
+		return withKind(ErrInvalid, err)
+	}
+	if o.Operation != op {
+		return NewError(ErrInvalid, "expected operation '%s', got '%s'", op, o.Operation)
+	}
+	if err := o.Verify(); err != nil {
+		return withKind(ErrUnauthorized, err)
+	}
+	return checkFresh(*o.Timestamp)
+}
+
+// UpdateMembership applies a signed membership change to an organization,
+// returning the updated organization. Changes must be timestamped within
+// MaxRequestAge of now, and after the organization's last update
+func UpdateMembership(orgs Organizations, mc *MembershipChange) (*Organization, error) {
+	if err := mc.Validate(); err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+	if err := mc.Verify(); err != nil {
+		return nil, withKind(ErrUnauthorized, err)
+	}
+	if err := checkFresh(mc.Timestamp); err != nil {
+		return nil, err
+	}
+
+	orgsLock.Lock()
+	defer orgsLock.Unlock()
+	prev, ok := orgs.Load(mc.Org)
+	if !ok {
+		return nil, NewError(ErrNotFound, "organization '%s' not found", mc.Org)
+	}
+	signer, err := ProfileIDFromPublicKey(mc.PublicKey)
+	if err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+	if !prev.IsOwner(signer) {
+		return nil, NewError(ErrUnauthorized, "only organization owners can change membership")
+	}
+	if !mc.Timestamp.After(prev.Updated) {
+		return nil, NewError(ErrConflict, "membership change is older than the latest organization update")
+	}
+
+	// copy before modifying so readers of the stored value aren't affected
+	org := *prev
+	org.Owners = remove(prev.Owners, mc.ProfileID)
+	org.Members = remove(prev.Members, mc.ProfileID)
+	if !mc.Remove {
+		if mc.Role == RoleOwner {
+			org.Owners = append(org.Owners, mc.ProfileID)
+		} else {
+			org.Members = append(org.Members, mc.ProfileID)
+		}
+	}
+	if len(org.Owners) == 0 {
+		return nil, NewError(ErrConflict, "organizations must have at least one owner")
+	}
+
+	org.Updated = mc.Timestamp
+	orgs.Store(org.Handle, &org)
+	return &org, nil
+}
+
+// MemOrganizations is a map of organization data safe for concurrent use
+// heavily inspired by sync.Map
+type MemOrganizations struct {
+	sync.RWMutex
+	internal map[string]*Organization
+}
+
+// NewMemOrganizations allocates a new *MemOrganizations map
+func NewMemOrganizations() *MemOrganizations {
+	return &MemOrganizations{
+		internal: make(map[string]*Organization),
+	}
+}
+
+// Len returns the number of records in the map
+func (mo *MemOrganizations) Len() int {
+	mo.RLock()
+	defer mo.RUnlock()
+	return len(mo.internal)
+}
+
+// Load fetches an organization from the map by handle
+func (mo *MemOrganizations) Load(handle string) (value *Organization, ok bool) {
+	mo.RLock()
+	value, ok = mo.internal[handle]
+	mo.RUnlock()
+	return
+}
+
+// Range calls an iteration fuction on each element in the map until
+// the end of the list is reached or iter returns true
+func (mo *MemOrganizations) Range(iter func(handle string, o *Organization) (brk bool)) {
+	mo.RLock()
+	defer mo.RUnlock()
+	for handle, o := range mo.internal {
+		if iter(handle, o) {
+			break
+		}
+	}
+}
+
+// SortedRange is like range but with deterministic key ordering
+func (mo *MemOrganizations) SortedRange(iter func(handle string, o *Organization) (brk bool)) {
+	mo.RLock()
+	defer mo.RUnlock()
+	handles := make([]string, 0, len(mo.internal))
+	for handle := range mo.internal {
+		handles = append(handles, handle)
+	}
+	sort.Strings(handles)
+	for _, handle := range handles {
+		if iter(handle, mo.internal[handle]) {
+			break
+		}
+	}
+}
+
+// Delete removes a record from MemOrganizations at handle
+func (mo *MemOrganizations) Delete(handle string) {
+	mo.Lock()
+	delete(mo.internal, handle)
+	mo.Unlock()
+}
+
+// Store adds an entry
+func (mo *MemOrganizations) Store(handle string, value *Organization) {
+	mo.Lock()
+	mo.internal[handle] = value
+	mo.Unlock()
+}
@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/dataset"
+)
+
+// signedTestDataset creates a dataset with a commit signed by privKey
+func signedTestDataset(t *testing.T, handle, name string, privKey crypto.PrivKey) *Dataset {
+	d, err := NewDataset(handle, name, &dataset.Dataset{
+		Path:      "/ipfs/Qm" + handle + name,
+		Commit:    &dataset.Commit{Timestamp: time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)},
+		Structure: &dataset.Structure{Checksum: "QmChecksum"},
+	}, privKey.GetPublic())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sig, err := privKey.Sign(d.sigBytes())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	d.Commit.Signature = base64.StdEncoding.EncodeToString(sig)
+	return d
+}
+
+func TestOrganizations(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	ownerKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	memberKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	owner, err := ProfileFromPrivateKey("owner", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	member, err := ProfileFromPrivateKey("member", memberKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ps := NewMemProfiles()
+	orgs := NewMemOrganizations()
+	if err := RegisterProfile(ps, owner); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	taken, err := OrganizationFromPrivateKey("owner", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterOrganization(orgs, ps, taken); err == nil {
+		t.Errorf("expected organization to not be able to claim a profile handle")
+	}
+
+	o, err := OrganizationFromPrivateKey("acme", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterOrganization(orgs, ps, o); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterOrganization(orgs, ps, o); err == nil {
+		t.Errorf("expected registering a taken organization handle to error")
+	}
+
+	squatter, err := ProfileFromPrivateKey("acme", memberKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterProfile(ps, squatter, WithOrganizations(orgs)); err == nil {
+		t.Errorf("expected profile to not be able to claim an organization handle")
+	}
+
+	org, ok := orgs.Load("acme")
+	if !ok {
+		t.Fatal("expected organization to load")
+	}
+	if !org.IsOwner(owner.ProfileID) {
+		t.Errorf("expected creator to be an owner")
+	}
+
+	dss := NewMemDatasets()
+	ds := signedTestDataset(t, "acme", "cities", memberKey)
+	if err := RegisterDataset(dss, ds, WithOrganizations(orgs)); err == nil {
+		t.Errorf("expected non-member to be refused")
+	}
+
+	// members can't change membership
+	mc, err := NewMembershipChange("acme", member.ProfileID, RoleMember, false, memberKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, mc); err == nil {
+		t.Errorf("expected non-owner membership change to error")
+	}
+
+	mc, err = NewMembershipChange("acme", member.ProfileID, RoleMember, false, ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, mc); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, mc); err == nil {
+		t.Errorf("expected replayed membership change to error")
+	}
+
+	staleMC, err := NewMembershipChange("acme", member.ProfileID, RoleOwner, false, ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	staleMC.Timestamp = staleMC.Timestamp.Add(-MaxRequestAge * 2)
+	if err := resign(staleMC, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, staleMC); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected stale membership change to be unauthorized, got: %v", err)
+	}
+
+	if err := RegisterDataset(dss, ds, WithOrganizations(orgs)); err != nil {
+		t.Errorf("expected member to register org dataset: %s", err.Error())
+	}
+	if _, ok := dss.Load("acme/cities"); !ok {
+		t.Errorf("expected org dataset to be stored")
+	}
+
+	mc, err = NewMembershipChange("acme", owner.ProfileID, RoleOwner, true, ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc.Timestamp = mc.Timestamp.Add(time.Second)
+	if err := resign(mc, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, mc); err == nil {
+		t.Errorf("expected removing the last owner to error")
+	}
+
+	mc, err = NewMembershipChange("acme", member.ProfileID, RoleMember, true, ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc.Timestamp = mc.Timestamp.Add(time.Second)
+	if err := resign(mc, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := UpdateMembership(orgs, mc); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := DeregisterDataset(dss, ds, WithOrganizations(orgs)); err == nil {
+		t.Errorf("expected removed member to be refused")
+	}
+
+	// membership changes above are timestamped a second ahead
+	prevNow := nowFunc
+	defer func() { nowFunc = prevNow }()
+	nowFunc = func() time.Time { return prevNow().Add(time.Second * 2) }
+
+	del, err := NewOrganizationRequest(OpDeregister, "acme", memberKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := DeregisterOrganization(orgs, del); err == nil {
+		t.Errorf("expected non-owner deregistration to error")
+	}
+	if err := DeregisterOrganization(orgs, o); ErrorKind(err) != ErrInvalid {
+		t.Errorf("expected replaying a registration to deregister to be invalid, got: %v", err)
+	}
+	o.Operation = OpDeregister
+	if err := DeregisterOrganization(orgs, o); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected altering a signed operation to be unauthorized, got: %v", err)
+	}
+
+	stale, err := NewOrganizationRequest(OpDeregister, "acme", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	*stale.Timestamp = stale.Timestamp.Add(-MaxRequestAge * 2)
+	if err := resignOrg(stale, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := DeregisterOrganization(orgs, stale); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected a stale deregistration to be unauthorized, got: %v", err)
+	}
+
+	del, err = NewOrganizationRequest(OpDeregister, "acme", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := DeregisterOrganization(orgs, del); err != nil {
+		t.Error(err.Error())
+	}
+	if orgs.Len() != 0 {
+		t.Errorf("expected organization to be removed")
+	}
+}
+
+// resign updates the signature of a modified membership change
+func resign(mc *MembershipChange, privKey crypto.PrivKey) error {
+	sig, err := privKey.Sign(mc.sigBytes())
+	if err != nil {
+		return err
+	}
+	mc.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// resignOrg updates the signature of a modified organization request
+func resignOrg(o *Organization, privKey crypto.PrivKey) error {
+	sig, err := privKey.Sign(o.sigBytes())
+	if err != nil {
+		return err
+	}
+	o.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
@@ -1,7 +1,6 @@
 package pinset
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/qri-io/registry"
@@ -17,7 +16,8 @@ type Pinset interface {
 	Pin(req *PinRequest) (chan PinStatus, error)
 	// Unpin removes a pin
 	Unpin(req *PinRequest) error
-	// Status gets the current pin state value for a given PinRequest
+	// Status gets the current pin state value for a given PinRequest,
+	// returning registry.ErrNotFound for paths that aren't pinned
 	Status(req *PinRequest) (PinStatus, error)
 	// Pins lists pins within the range defined by limit & offset in
 	// lexographical order, smallest to largest
@@ -66,7 +66,7 @@ func (m *MemPinset) Pin(req *PinRequest) (chan PinStatus, error) {
 func (m *MemPinset) Status(req *PinRequest) (PinStatus, error) {
 	ps := m.pk.Get(req.Path)
 	if ps == nil {
-		return PinStatus{}, fmt.Errorf("not found")
+		return PinStatus{}, registry.ErrNotFound
 	}
 
 	return *ps, nil
@@ -0,0 +1,98 @@
+package pinset
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ErrClosed is returned by Tracker.Pin once the tracker has been closed
+var ErrClosed = fmt.Errorf("pinset is shutting down")
+
+// Tracker wraps a Pinset, counting in-flight pin jobs so they can be drained
+// before shutdown. A job is in-flight until the status channel returned by
+// the underlying Pin is closed. Callers must read all statuses from Pin
+type Tracker struct {
+	Pinset
+
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	jobs   int
+	closed bool
+}
+
+// NewTracker wraps a pinset with job tracking
+func NewTracker(ps Pinset) *Tracker {
+	return &Tracker{Pinset: ps}
+}
+
+// Pin starts a pin job on the underlying pinset, returning ErrClosed if the
+// tracker has been closed
+func (t *Tracker) Pin(req *PinRequest) (chan PinStatus, error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, ErrClosed
+	}
+	t.jobs++
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	statuses, err := t.Pinset.Pin(req)
+	if err != nil {
+		t.done()
+		return nil, err
+	}
+
+	fwd := make(chan PinStatus)
+	go func() {
+		defer t.done()
+		defer close(fwd)
+		for status := range statuses {
+			fwd <- status
+		}
+	}()
+	return fwd, nil
+}
+
+func (t *Tracker) done() {
+	t.mu.Lock()
+	t.jobs--
+	t.mu.Unlock()
+	t.wg.Done()
+}
+
+// Jobs returns the number of in-flight pin jobs
+func (t *Tracker) Jobs() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.jobs
+}
+
+// Closed is true once Close has been called
+func (t *Tracker) Closed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// Close stops the tracker accepting new pin jobs & waits for in-flight jobs
+// to finish, returning ctx.Err() if ctx is done first
+func (t *Tracker) Close(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for %d pin jobs: %s", t.Jobs(), ctx.Err().Error())
+	}
+}
@@ -0,0 +1,55 @@
+package pinset
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowPinset is a Pinset whose pin jobs run until release is closed
+type slowPinset struct {
+	MemPinset
+	release chan struct{}
+}
+
+func (ps *slowPinset) Pin(req *PinRequest) (chan PinStatus, error) {
+	c := make(chan PinStatus)
+	go func() {
+		<-ps.release
+		c <- PinStatus{Path: req.Path, Pinned: true}
+		close(c)
+	}()
+	return c, nil
+}
+
+func TestTracker(t *testing.T) {
+	ps := &slowPinset{release: make(chan struct{})}
+	tr := NewTracker(ps)
+
+	statuses, err := tr.Pin(&PinRequest{Path: "/ipfs/QmFoo"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if tr.Jobs() != 1 {
+		t.Errorf("expected 1 job in flight, got: %d", tr.Jobs())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := tr.Close(ctx); err == nil {
+		t.Error("expected close to time out while a job is in flight")
+	}
+	if _, err := tr.Pin(&PinRequest{Path: "/ipfs/QmBar"}); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+
+	close(ps.release)
+	for range statuses {
+	}
+	if err := tr.Close(context.Background()); err != nil {
+		t.Errorf("expected in-flight jobs to drain, got: %s", err.Error())
+	}
+	if tr.Jobs() != 0 {
+		t.Errorf("expected no jobs in flight, got: %d", tr.Jobs())
+	}
+}
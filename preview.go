@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+)
+
+var (
+	// PreviewMaxRows is the maximum number of body entries kept in a preview
+	PreviewMaxRows = 100
+	// PreviewMaxBytes is the maximum size of a preview body, encoded as JSON
+	PreviewMaxBytes = 64 * 1024
+	// PreviewMaxReadme is the maximum length of a preview readme excerpt in
+	// bytes
+	PreviewMaxReadme = 2048
+)
+
+// Preview is a bounded sample of a dataset's body & readme, stored with a
+// dataset so clients can show what a dataset looks like without fetching it.
+// Previews are supplied by the registrant and are not covered by the
+// dataset signature
+type Preview struct {
+	// Body is the first entries of the dataset body, either an array of
+	// rows or an object
+	Body interface{} `json:"body,omitempty"`
+	// Readme is an excerpt from the start of the dataset readme
+	Readme string `json:"readme,omitempty"`
+}
+
+// NewPreview creates a preview from a dataset body and a readme, trimmed to
+// preview bounds
+func NewPreview(ds *dataset.Dataset, readme string) (*Preview, error) {
+	p := &Preview{Readme: readme}
+	if ds != nil && ds.Body != nil {
+		// round trip through JSON to normalize body types
+		data, err := json.Marshal(ds.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &p.Body); err != nil {
+			return nil, err
+		}
+	}
+	p.Trim()
+	return p, nil
+}
+
+// Trim shortens a preview to fit within PreviewMaxRows, PreviewMaxBytes
+// and PreviewMaxReadme. Body values that aren't arrays or objects are
+// dropped
+func (p *Preview) Trim() {
+	switch body := p.Body.(type) {
+	case []interface{}:
+		if len(body) > PreviewMaxRows {
+			body = body[:PreviewMaxRows]
+		}
+		p.Body = body[:fitEntries(len(body), func(i int) int {
+			return encodedLen(body[i])
+		})]
+	case map[string]interface{}:
+		keys := make([]string, 0, len(body))
+		for key := range body {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) > PreviewMaxRows {
+			keys = keys[:PreviewMaxRows]
+		}
+		keys = keys[:fitEntries(len(keys), func(i int) int {
+			// "key":value
+			return encodedLen(keys[i]) + 1 + encodedLen(body[keys[i]])
+		})]
+		trimmed := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			trimmed[key] = body[key]
+		}
+		p.Body = trimmed
+	default:
+		p.Body = nil
+	}
+
+	if len(p.Readme) > PreviewMaxReadme {
+		// don't split multi-byte characters
+		n := PreviewMaxReadme
+		for n > 0 && !utf8.RuneStart(p.Readme[n]) {
+			n--
+		}
+		p.Readme = p.Readme[:n]
+	}
+}
+
+// fitEntries gives the number of leading entries of a JSON array or object
+// that fit within PreviewMaxBytes, given the encoded size of each entry
+func fitEntries(n int, size func(i int) int) int {
+	// opening & closing brackets
+	total := 2
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			// separating comma
+			total++
+		}
+		if total += size(i); total > PreviewMaxBytes {
+			return i
+		}
+	}
+	return n
+}
+
+// encodedLen gives the length of v encoded as JSON
+func encodedLen(v interface{}) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
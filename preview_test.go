@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestNewPreview(t *testing.T) {
+	rows := make([]interface{}, PreviewMaxRows+10)
+	for i := range rows {
+		rows[i] = []interface{}{"city", i}
+	}
+
+	p, err := NewPreview(&dataset.Dataset{Body: rows}, "# cities")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	body, ok := p.Body.([]interface{})
+	if !ok {
+		t.Fatalf("expected array body, got: %T", p.Body)
+	}
+	if len(body) != PreviewMaxRows {
+		t.Errorf("expected %d rows, got: %d", PreviewMaxRows, len(body))
+	}
+	if p.Readme != "# cities" {
+		t.Errorf("readme mismatch. expected: '# cities', got: '%s'", p.Readme)
+	}
+
+	p, err = NewPreview(&dataset.Dataset{Body: "not a collection"}, "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if p.Body != nil {
+		t.Errorf("expected scalar body to be dropped")
+	}
+}
+
+func TestPreviewTrim(t *testing.T) {
+	row := strings.Repeat("a", PreviewMaxBytes/4)
+	p := &Preview{Body: []interface{}{row, row, row, row, row}}
+	p.Trim()
+	if body := p.Body.([]interface{}); len(body) != 3 {
+		t.Errorf("expected oversized body to be trimmed to 3 rows, got: %d", len(body))
+	}
+
+	p = &Preview{Body: map[string]interface{}{"a": row, "b": row, "c": row, "d": row, "e": row}}
+	p.Trim()
+	body := p.Body.(map[string]interface{})
+	if len(body) != 3 {
+		t.Errorf("expected oversized object to be trimmed to 3 keys, got: %d", len(body))
+	}
+	if _, ok := body["a"]; !ok {
+		t.Errorf("expected object trimming to keep the first keys")
+	}
+
+	// trimming keeps as many rows as fit exactly within PreviewMaxBytes
+	rows := []interface{}{}
+	for i := 0; i < PreviewMaxRows; i++ {
+		rows = append(rows, map[string]interface{}{"n": float64(i), "s": strings.Repeat("<", i*13)})
+	}
+	p = &Preview{Body: rows}
+	p.Trim()
+	kept := p.Body.([]interface{})
+	if len(kept) == len(rows) || encodedLen(kept) > PreviewMaxBytes {
+		t.Errorf("expected body to be trimmed within %d bytes, got %d rows of %d bytes", PreviewMaxBytes, len(kept), encodedLen(kept))
+	}
+	if next := rows[:len(kept)+1]; encodedLen(next) <= PreviewMaxBytes {
+		t.Errorf("expected trimming to keep %d rows, got: %d", len(next), len(kept))
+	}
+
+	p = &Preview{Readme: strings.Repeat("a", PreviewMaxReadme-1) + "é"}
+	p.Trim()
+	if len(p.Readme) != PreviewMaxReadme-1 {
+		t.Errorf("expected readme to be trimmed without splitting characters, got length: %d", len(p.Readme))
+	}
+}
@@ -64,13 +64,28 @@ func ProfileFromPrivateKey(handle string, privKey crypto.PrivKey) (*Profile, err
 		return nil, fmt.Errorf("error getting pubkey bytes: %s", err.Error())
 	}
 
-	mh, err := multihash.Sum(pubkeybytes, multihash.SHA2_256, 32)
-	if err != nil {
-		return nil, fmt.Errorf("error summing pubkey: %s", err.Error())
+	if p.ProfileID, err = profileIDFromPubKeyBytes(pubkeybytes); err != nil {
+		return nil, err
 	}
-
-	p.ProfileID = mh.B58String()
 	p.PublicKey = base64.StdEncoding.EncodeToString(pubkeybytes)
 
 	return p, nil
 }
+
+// ProfileIDFromPublicKey derives the base58-encoded profileID for a
+// base64-encoded public key
+func ProfileIDFromPublicKey(b64PubKey string) (string, error) {
+	pkbytes, err := base64.StdEncoding.DecodeString(b64PubKey)
+	if err != nil {
+		return "", fmt.Errorf("publickey base64 encoding: %s", err.Error())
+	}
+	return profileIDFromPubKeyBytes(pkbytes)
+}
+
+func profileIDFromPubKeyBytes(pubkeybytes []byte) (string, error) {
+	mh, err := multihash.Sum(pubkeybytes, multihash.SHA2_256, 32)
+	if err != nil {
+		return "", fmt.Errorf("error summing pubkey: %s", err.Error())
+	}
+	return mh.B58String(), nil
+}
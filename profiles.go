@@ -1,7 +1,6 @@
 package registry
 
 import (
-	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -28,6 +27,10 @@ type Profiles interface {
 	Range(iter func(key string, p *Profile) (brk bool))
 	// SortedRange is like range but with deterministic key ordering
 	SortedRange(iter func(key string, p *Profile) (brk bool))
+	// LoadByProfileID fetches a profile by it's profileID
+	LoadByProfileID(profileID string) (value *Profile, ok bool)
+	// LoadByPublicKey fetches a profile by it's base64-encoded public key
+	LoadByPublicKey(pubKey string) (value *Profile, ok bool)
 
 	// Store adds an entry, bypassing the register process
 	// store is only exported for administrative use cases.
@@ -39,13 +42,32 @@ type Profiles interface {
 	Delete(key string)
 }
 
-// RegisterProfile adds a profile to the list if it's valid and the desired handle isn't taken
-func RegisterProfile(store Profiles, p *Profile) error {
+// RegisterProfile adds a profile to the list if it's valid and the desired handle isn't taken.
+// The profile's ProfileID must be derived from it's public key
+func RegisterProfile(store Profiles, p *Profile, opts ...func(o *RegisterOptions)) error {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	if err := p.Validate(); err != nil {
-		return err
+		return withKind(ErrInvalid, err)
 	}
 	if err := p.Verify(); err != nil {
-		return err
+		return withKind(ErrUnauthorized, err)
+	}
+	profileID, err := ProfileIDFromPublicKey(p.PublicKey)
+	if err != nil {
+		return withKind(ErrInvalid, err)
+	}
+	if profileID != p.ProfileID {
+		return NewError(ErrUnauthorized, "profileID does not match publickey")
+	}
+
+	if o.Organizations != nil {
+		if _, ok := o.Organizations.Load(p.Handle); ok {
+			return NewError(ErrConflict, "handle '%s' is taken", p.Handle)
+		}
 	}
 
 	if pro, ok := store.Load(p.Handle); ok {
@@ -53,20 +75,11 @@ func RegisterProfile(store Profiles, p *Profile) error {
 		if pro.ProfileID == p.ProfileID {
 			return nil
 		}
-		return fmt.Errorf("handle '%s' is taken", p.Handle)
+		return NewError(ErrConflict, "handle '%s' is taken", p.Handle)
 	}
 
-	prev := ""
-	store.Range(func(key string, profile *Profile) bool {
-		if profile.ProfileID == p.ProfileID {
-			prev = key
-			return true
-		}
-		return false
-	})
-
-	if prev != "" {
-		store.Delete(prev)
+	if prev, ok := store.LoadByProfileID(p.ProfileID); ok {
+		store.Delete(prev.Handle)
 	}
 
 	store.Store(p.Handle, &Profile{
@@ -82,27 +95,41 @@ func RegisterProfile(store Profiles, p *Profile) error {
 // confirming the user has the authority to do so
 func DeregisterProfile(store Profiles, p *Profile) error {
 	if err := p.Validate(); err != nil {
-		return err
+		return withKind(ErrInvalid, err)
 	}
 	if err := p.Verify(); err != nil {
-		return err
+		return withKind(ErrUnauthorized, err)
 	}
 
+	if pro, ok := store.Load(p.Handle); ok {
+		profileID, err := ProfileIDFromPublicKey(p.PublicKey)
+		if err != nil {
+			return withKind(ErrInvalid, err)
+		}
+		if pro.ProfileID != profileID {
+			return NewError(ErrUnauthorized, "profile '%s' belongs to another key", p.Handle)
+		}
+	}
 	store.Delete(p.Handle)
 	return nil
 }
 
 // MemProfiles is a map of profile data safe for concurrent use
-// heavily inspired by sync.Map
+// heavily inspired by sync.Map. MemProfiles keeps secondary indexes
+// of profileID and public key to keys
 type MemProfiles struct {
 	sync.RWMutex
-	internal map[string]*Profile
+	internal    map[string]*Profile
+	byProfileID map[string]string
+	byPublicKey map[string]string
 }
 
 // NewMemProfiles allocates a new *MemProfiles map
 func NewMemProfiles() *MemProfiles {
 	return &MemProfiles{
-		internal: make(map[string]*Profile),
+		internal:    make(map[string]*Profile),
+		byProfileID: make(map[string]string),
+		byPublicKey: make(map[string]string),
 	}
 }
 
@@ -149,9 +176,38 @@ func (ps *MemProfiles) SortedRange(iter func(key string, p *Profile) (brk bool))
 	}
 }
 
+// LoadByProfileID fetches a profile by it's profileID
+func (ps *MemProfiles) LoadByProfileID(profileID string) (value *Profile, ok bool) {
+	ps.RLock()
+	defer ps.RUnlock()
+	return ps.loadIndex(ps.byProfileID, profileID)
+}
+
+// LoadByPublicKey fetches a profile by it's base64-encoded public key
+func (ps *MemProfiles) LoadByPublicKey(pubKey string) (value *Profile, ok bool) {
+	ps.RLock()
+	defer ps.RUnlock()
+	return ps.loadIndex(ps.byPublicKey, pubKey)
+}
+
+// loadIndex looks up a profile through a secondary index. callers must
+// hold the lock
+func (ps *MemProfiles) loadIndex(idx map[string]string, val string) (value *Profile, ok bool) {
+	if val == "" {
+		return nil, false
+	}
+	key, ok := idx[val]
+	if !ok {
+		return nil, false
+	}
+	value, ok = ps.internal[key]
+	return
+}
+
 // Delete removes a record from MemProfiles at key
 func (ps *MemProfiles) Delete(key string) {
 	ps.Lock()
+	ps.unindex(key)
 	delete(ps.internal, key)
 	ps.Unlock()
 }
@@ -159,6 +215,28 @@ func (ps *MemProfiles) Delete(key string) {
 // Store adds an entry
 func (ps *MemProfiles) Store(key string, value *Profile) {
 	ps.Lock()
+	ps.unindex(key)
 	ps.internal[key] = value
+	if value.ProfileID != "" {
+		ps.byProfileID[value.ProfileID] = key
+	}
+	if value.PublicKey != "" {
+		ps.byPublicKey[value.PublicKey] = key
+	}
 	ps.Unlock()
 }
+
+// unindex removes any index entries for the value stored at key. callers
+// must hold the write lock
+func (ps *MemProfiles) unindex(key string) {
+	prev, ok := ps.internal[key]
+	if !ok {
+		return
+	}
+	if ps.byProfileID[prev.ProfileID] == key {
+		delete(ps.byProfileID, prev.ProfileID)
+	}
+	if ps.byPublicKey[prev.PublicKey] == key {
+		delete(ps.byPublicKey, prev.PublicKey)
+	}
+}
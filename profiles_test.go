@@ -81,6 +81,43 @@ func TestRegisterProfile(t *testing.T) {
 	}
 }
 
+func TestRegisterProfileIDMismatch(t *testing.T) {
+	ps := NewMemProfiles()
+
+	src := rand.New(rand.NewSource(0))
+	aliceKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	malloryKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	alice, err := ProfileFromPrivateKey("alice", aliceKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterProfile(ps, alice); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	mallory, err := ProfileFromPrivateKey("mallory", malloryKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mallory.ProfileID = alice.ProfileID
+	if err := RegisterProfile(ps, mallory); ErrorKind(err) != ErrUnauthorized {
+		t.Errorf("expected claiming another profileID to be unauthorized, got: %v", err)
+	}
+	if pro, ok := ps.Load("alice"); !ok || pro.PublicKey != alice.PublicKey {
+		t.Errorf("expected alice's profile to be kept")
+	}
+	if _, ok := ps.Load("mallory"); ok {
+		t.Errorf("expected mismatched profile to not be stored")
+	}
+}
+
 func TestProfilesSortedRange(t *testing.T) {
 	ps := NewMemProfiles()
 
@@ -122,3 +159,27 @@ func TestProfilesSortedRange(t *testing.T) {
 		break
 	}
 }
+
+func TestMemProfilesIndexes(t *testing.T) {
+	ps := NewMemProfiles()
+	ps.Store("a", &Profile{Handle: "a", ProfileID: "QmA", PublicKey: "keyA"})
+	ps.Store("b", &Profile{Handle: "b", ProfileID: "QmB", PublicKey: "keyB"})
+
+	if p, ok := ps.LoadByProfileID("QmB"); !ok || p.Handle != "b" {
+		t.Errorf("expected profileID QmB to load handle 'b'")
+	}
+	if p, ok := ps.LoadByPublicKey("keyA"); !ok || p.Handle != "a" {
+		t.Errorf("expected public key keyA to load handle 'a'")
+	}
+	if _, ok := ps.LoadByProfileID(""); ok {
+		t.Errorf("expected empty profileID to not load")
+	}
+
+	ps.Delete("a")
+	if _, ok := ps.LoadByProfileID("QmA"); ok {
+		t.Errorf("expected deleted profile to be removed from profileID index")
+	}
+	if _, ok := ps.LoadByPublicKey("keyA"); ok {
+		t.Errorf("expected deleted profile to be removed from public key index")
+	}
+}
@@ -0,0 +1,332 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QueryFields lists the field qualifiers supported by search queries
+var QueryFields = []string{"handle", "name", "title", "description", "keyword", "format", "column", "is"}
+
+// QueryError is a search query syntax error. Pos is the 1-based character
+// position of the error in the query
+type QueryError struct {
+	Pos int
+	Msg string
+}
+
+// Error implements the error interface
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// QueryNode is a node in a parsed search query
+type QueryNode interface {
+	String() string
+}
+
+// QueryTerm matches a single word or phrase. Terms without a field match any
+// of SearchFields. The reserved word "deprecated" is shorthand for
+// is:deprecated, use a quoted phrase to search for the word itself
+type QueryTerm struct {
+	Field  string
+	Value  string
+	Phrase bool
+}
+
+// String implements QueryNode
+func (t QueryTerm) String() string {
+	v := t.Value
+	if t.Phrase {
+		v = fmt.Sprintf("%q", v)
+	}
+	if t.Field != "" {
+		return t.Field + ":" + v
+	}
+	return v
+}
+
+// QueryAnd matches when all nodes match
+type QueryAnd []QueryNode
+
+// String implements QueryNode
+func (q QueryAnd) String() string { return joinNodes(q, " ") }
+
+// QueryOr matches when any node matches
+type QueryOr []QueryNode
+
+// String implements QueryNode
+func (q QueryOr) String() string { return joinNodes(q, " OR ") }
+
+// QueryNot matches when it's node doesn't
+type QueryNot struct {
+	Node QueryNode
+}
+
+// String implements QueryNode
+func (q QueryNot) String() string {
+	if _, ok := q.Node.(QueryTerm); ok {
+		return "-" + q.Node.String()
+	}
+	return "-(" + q.Node.String() + ")"
+}
+
+func joinNodes(nodes []QueryNode, sep string) string {
+	strs := make([]string, len(nodes))
+	for i, n := range nodes {
+		strs[i] = n.String()
+		if _, ok := n.(QueryOr); ok && sep != " OR " {
+			strs[i] = "(" + strs[i] + ")"
+		}
+	}
+	return strings.Join(strs, sep)
+}
+
+// ParseQuery parses a search query. Queries are whitespace-separated terms
+// that must all match, with the following syntax:
+//
+//	census               word, matches any search field
+//	"population density" phrase, matches words in order
+//	handle:acme          field qualifier, one of QueryFields
+//	-deprecated          negation
+//	csv OR json          either term matches, binds tighter than AND
+//	(csv OR json) census grouping
+//
+// Values are matched case-insensitively. An empty query returns a nil node
+func ParseQuery(q string) (QueryNode, error) {
+	toks, err := lexQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks, end: len([]rune(q)) + 1}
+	if len(toks) == 0 {
+		return nil, nil
+	}
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("unexpected '%s'", t.text)}
+	}
+	return node, nil
+}
+
+type queryTokenKind int
+
+const (
+	tokWord queryTokenKind = iota
+	tokPhrase
+	tokLParen
+	tokRParen
+	tokMinus
+	tokOr
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	// field is set for qualified words & phrases
+	field string
+	pos   int
+}
+
+// lexQuery breaks a query into tokens
+func lexQuery(q string) ([]queryToken, error) {
+	rs := []rune(q)
+	var toks []queryToken
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, queryToken{kind: tokLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			toks = append(toks, queryToken{kind: tokRParen, text: ")", pos: pos})
+			i++
+		case r == '-':
+			toks = append(toks, queryToken{kind: tokMinus, text: "-", pos: pos})
+			i++
+		case r == '"':
+			text, next, err := lexPhrase(rs, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, queryToken{kind: tokPhrase, text: text, pos: pos})
+			i = next
+		default:
+			start := i
+			for i < len(rs) && !unicode.IsSpace(rs[i]) && !strings.ContainsRune(`()"`, rs[i]) {
+				if rs[i] == ':' {
+					break
+				}
+				i++
+			}
+			word := string(rs[start:i])
+
+			if i < len(rs) && rs[i] == ':' {
+				// field qualifier
+				i++
+				tok := queryToken{kind: tokWord, field: strings.ToLower(word), pos: pos}
+				if !validQueryField(tok.field) {
+					return nil, &QueryError{Pos: pos, Msg: fmt.Sprintf("unknown field '%s'", word)}
+				}
+				if i < len(rs) && rs[i] == '"' {
+					text, next, err := lexPhrase(rs, i)
+					if err != nil {
+						return nil, err
+					}
+					tok.kind, tok.text, i = tokPhrase, text, next
+				} else {
+					vstart := i
+					for i < len(rs) && !unicode.IsSpace(rs[i]) && !strings.ContainsRune(`()"`, rs[i]) {
+						i++
+					}
+					tok.text = string(rs[vstart:i])
+				}
+				if tok.text == "" {
+					return nil, &QueryError{Pos: pos, Msg: fmt.Sprintf("field '%s' requires a value", word)}
+				}
+				toks = append(toks, tok)
+				continue
+			}
+
+			if word == "OR" {
+				toks = append(toks, queryToken{kind: tokOr, text: word, pos: pos})
+			} else if word != "AND" {
+				toks = append(toks, queryToken{kind: tokWord, text: word, pos: pos})
+			}
+		}
+	}
+	return toks, nil
+}
+
+// lexPhrase reads a quoted phrase starting at rs[start], returning the
+// phrase text & the index after the closing quote
+func lexPhrase(rs []rune, start int) (string, int, error) {
+	for i := start + 1; i < len(rs); i++ {
+		if rs[i] == '"' {
+			return string(rs[start+1 : i]), i + 1, nil
+		}
+	}
+	return "", 0, &QueryError{Pos: start + 1, Msg: "unterminated phrase"}
+}
+
+func validQueryField(f string) bool {
+	for _, field := range QueryFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// queryParser is a recursive descent parser over query tokens:
+//
+//	and   = or { or }
+//	or    = unary { "OR" unary }
+//	unary = [ "-" ] atom
+//	atom  = word | phrase | "(" and ")"
+type queryParser struct {
+	toks []queryToken
+	i    int
+	// end is the position reported for errors at the end of the query
+	end int
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.i < len(p.toks) {
+		return &p.toks[p.i]
+	}
+	return nil
+}
+
+func (p *queryParser) parseAnd() (QueryNode, error) {
+	var nodes QueryAnd
+	for t := p.peek(); t != nil && t.kind != tokRParen; t = p.peek() {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) parseOr() (QueryNode, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := QueryOr{n}
+	for t := p.peek(); t != nil && t.kind == tokOr; t = p.peek() {
+		p.i++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) parseUnary() (QueryNode, error) {
+	t := p.peek()
+	if t != nil && t.kind == tokMinus {
+		p.i++
+		n, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		return QueryNot{Node: n}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *queryParser) parseAtom() (QueryNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, &QueryError{Pos: p.end, Msg: "unexpected end of query"}
+	}
+	switch t.kind {
+	case tokWord, tokPhrase:
+		p.i++
+		term := QueryTerm{Field: t.field, Value: strings.ToLower(t.text), Phrase: t.kind == tokPhrase}
+		if term.Field == "" && !term.Phrase && term.Value == "deprecated" {
+			term.Field = "is"
+		}
+		if term.Field == "is" && term.Value != "deprecated" {
+			return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("unknown flag 'is:%s'", t.text)}
+		}
+		return term, nil
+	case tokLParen:
+		p.i++
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			return nil, &QueryError{Pos: t.pos, Msg: "empty group"}
+		}
+		if c := p.peek(); c == nil || c.kind != tokRParen {
+			return nil, &QueryError{Pos: t.pos, Msg: "unclosed '('"}
+		}
+		p.i++
+		return n, nil
+	default:
+		return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("unexpected '%s'", t.text)}
+	}
+}
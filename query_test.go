@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		q      string
+		expect string
+	}{
+		{"", "<nil>"},
+		{"census", "census"},
+		{"Census Tracts", "census tracts"},
+		{`handle:acme format:csv keyword:census "population density" -deprecated`, `handle:acme format:csv keyword:census "population density" -is:deprecated`},
+		{`title:"city data"`, `title:"city data"`},
+		{"csv OR json census", "(csv OR json) census"},
+		{"(csv OR json) AND census", "(csv OR json) census"},
+		{"-(handle:a OR handle:b)", "-(handle:a OR handle:b)"},
+		{"a (b c)", "a b c"},
+		{`"deprecated"`, `"deprecated"`},
+		{"well-known", "well-known"},
+	}
+
+	for i, c := range cases {
+		n, err := ParseQuery(c.q)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		got := "<nil>"
+		if n != nil {
+			got = n.String()
+		}
+		if got != c.expect {
+			t.Errorf("case %d mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	cases := []struct {
+		q   string
+		pos int
+		err string
+	}{
+		{`"population density`, 1, "unterminated phrase"},
+		{`census title:"city`, 14, "unterminated phrase"},
+		{"foo:bar", 1, "unknown field 'foo'"},
+		{"census handle:", 8, "field 'handle' requires a value"},
+		{"is:public", 1, "unknown flag 'is:public'"},
+		{"census)", 7, "unexpected ')'"},
+		{"(census", 1, "unclosed '('"},
+		{"()", 1, "empty group"},
+		{"census OR", 10, "unexpected end of query"},
+		{"OR census", 1, "unexpected 'OR'"},
+		{"census -", 9, "unexpected end of query"},
+	}
+
+	for i, c := range cases {
+		_, err := ParseQuery(c.q)
+		qe, ok := err.(*QueryError)
+		if !ok {
+			t.Errorf("case %d expected a *QueryError, got: %v", i, err)
+			continue
+		}
+		if qe.Pos != c.pos || qe.Msg != c.err {
+			t.Errorf("case %d mismatch. expected: %d '%s', got: %d '%s'", i, c.pos, c.err, qe.Pos, qe.Msg)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-crypto"
+)
+
+// Redirect records that a dataset previously registered at From now lives
+// at To. From & To are dataset keys in the form handle/name
+type Redirect struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Created time.Time `json:"created"`
+}
+
+// DatasetMove is a signed request to rename a dataset or move it to a new
+// handle. From & To are dataset keys in the form handle/name
+type DatasetMove struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+	PublicKey string    `json:"publicKey"`
+	Signature string    `json:"signature"`
+}
+
+// NewDatasetMove creates a dataset move request signed by privKey
+func NewDatasetMove(from, to string, privKey crypto.PrivKey) (*DatasetMove, error) {
+	pubkeybytes, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+	}
+
+	mv := &DatasetMove{
+		From:      from,
+		To:        to,
+		Timestamp: nowFunc().UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(pubkeybytes),
+	}
+	sigbytes, err := privKey.Sign(mv.sigBytes())
+	if err != nil {
+		return nil, fmt.Errorf("error signing %s", err.Error())
+	}
+	mv.Signature = base64.StdEncoding.EncodeToString(sigbytes)
+	return mv, nil
+}
+
+// Validate is a sanity check that all required values are present
+func (mv *DatasetMove) Validate() error {
+	if _, _, err := SplitDatasetKey(mv.From); err != nil {
+		return fmt.Errorf("from: %s", err.Error())
+	}
+	if _, _, err := SplitDatasetKey(mv.To); err != nil {
+		return fmt.Errorf("to: %s", err.Error())
+	}
+	if mv.From == mv.To {
+		return fmt.Errorf("from and to must differ")
+	}
+	if mv.PublicKey == "" {
+		return fmt.Errorf("publicKey is required")
+	}
+	if mv.Signature == "" {
+		return fmt.Errorf("signature is required")
+	}
+	return nil
+}
+
+// Verify checks the move was signed by the provided public key
+func (mv *DatasetMove) Verify() error {
+	return verify(mv.PublicKey, mv.Signature, mv.sigBytes())
+}
+
+// sigBytes gives the signable bytes from a dataset move
+func (mv *DatasetMove) sigBytes() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%s", mv.From, mv.To, mv.Timestamp.UTC().Format(time.RFC3339Nano)))
+}
+
+// Deprecation marks a dataset as no longer maintained, with an optional
+// message and the key of a successor dataset. Deprecations are signed by the
+// dataset owner for Operation, one of OpDeprecate or OpUndeprecate
+type Deprecation struct {
+	Ref       string    `json:"ref"`
+	Operation string    `json:"operation"`
+	Message   string    `json:"message,omitempty"`
+	Successor string    `json:"successor,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	PublicKey string    `json:"publicKey,omitempty"`
+	Signature string    `json:"signature,omitempty"`
+}
+
+// NewDeprecation creates a deprecation notice for the dataset at ref,
+// signed by privKey
+func NewDeprecation(ref, message, successor string, privKey crypto.PrivKey) (*Deprecation, error) {
+	return newDeprecation(OpDeprecate, ref, message, successor, privKey)
+}
+
+// NewUndeprecation creates a request to remove the deprecation of the dataset
+// at ref, signed by privKey
+func NewUndeprecation(ref string, privKey crypto.PrivKey) (*Deprecation, error) {
+	return newDeprecation(OpUndeprecate, ref, "", "", privKey)
+}
+
+func newDeprecation(op, ref, message, successor string, privKey crypto.PrivKey) (*Deprecation, error) {
+	pubkeybytes, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pubkey bytes: %s", err.Error())
+	}
+
+	dep := &Deprecation{
+		Ref:       ref,
+		Operation: op,
+		Message:   message,
+		Successor: successor,
+		Timestamp: nowFunc().UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(pubkeybytes),
+	}
+	sigbytes, err := privKey.Sign(dep.sigBytes())
+	if err != nil {
+		return nil, fmt.Errorf("error signing %s", err.Error())
+	}
+	dep.Signature = base64.StdEncoding.EncodeToString(sigbytes)
+	return dep, nil
+}
+
+// Validate is a sanity check that all required values are present
+func (dep *Deprecation) Validate() error {
+	if _, _, err := SplitDatasetKey(dep.Ref); err != nil {
+		return fmt.Errorf("ref: %s", err.Error())
+	}
+	if dep.Operation != OpDeprecate && dep.Operation != OpUndeprecate {
+		return fmt.Errorf("operation must be one of '%s' or '%s'", OpDeprecate, OpUndeprecate)
+	}
+	if dep.Successor != "" {
+		if _, _, err := SplitDatasetKey(dep.Successor); err != nil {
+			return fmt.Errorf("successor: %s", err.Error())
+		}
+	}
+	if dep.Timestamp.IsZero() {
+		return fmt.Errorf("timestamp is required")
+	}
+	if dep.PublicKey == "" {
+		return fmt.Errorf("publicKey is required")
+	}
+	if dep.Signature == "" {
+		return fmt.Errorf("signature is required")
+	}
+	return nil
+}
+
+// Verify checks the deprecation was signed by the provided public key
+func (dep *Deprecation) Verify() error {
+	return verify(dep.PublicKey, dep.Signature, dep.sigBytes())
+}
+
+// sigBytes gives the signable bytes from a deprecation. Fields are encoded
+// as a JSON array because messages may contain newlines
+func (dep *Deprecation) sigBytes() []byte {
+	data, _ := json.Marshal([]string{dep.Ref, dep.Operation, dep.Message, dep.Successor, dep.Timestamp.UTC().Format(time.RFC3339Nano)})
+	return data
+}
+
+// SplitDatasetKey breaks a dataset key into handle & name components
+func SplitDatasetKey(key string) (handle, name string, err error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("'%s' is not a valid dataset key, must be in the form handle/name", key)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,236 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+)
+
+// MaxRedirects is the maximum number of chained redirects ResolveRedirect
+// will follow
+const MaxRedirects = 10
+
+// Redirects is the interface for working with a set of *Redirect's, keyed
+// by the dataset key the redirect is from
+type Redirects interface {
+	// Len returns the number of records in the set
+	Len() int
+	// Load fetches a redirect from the set by previous dataset key
+	Load(from string) (value *Redirect, ok bool)
+	// SortedRange calls an iteration fuction on each element in the set in
+	// key order until the end of the list is reached or iter returns true
+	SortedRange(iter func(from string, r *Redirect) (brk bool))
+	// Store adds an entry
+	Store(from string, value *Redirect)
+	// Delete removes a record from the set
+	Delete(from string)
+}
+
+// ResolveRedirect follows redirects starting at key, returning the key of
+// the dataset's current location. ok is false if no redirect exists for key
+func ResolveRedirect(rs Redirects, key string) (to string, ok bool) {
+	to = key
+	for i := 0; i < MaxRedirects; i++ {
+		r, exists := rs.Load(to)
+		if !exists {
+			break
+		}
+		to = r.To
+		ok = true
+	}
+	return to, ok
+}
+
+// MoveDataset renames a dataset or moves it to a new handle. The signer of mv
+// must own the dataset and be allowed to publish under the destination handle.
+// If opts configure Redirects, a redirect is left at the previous key
+func MoveDataset(store Datasets, mv *DatasetMove, opts ...func(o *RegisterOptions)) (*Dataset, error) {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := mv.Validate(); err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+	if err := mv.Verify(); err != nil {
+		return nil, withKind(ErrUnauthorized, err)
+	}
+	profileID, err := ProfileIDFromPublicKey(mv.PublicKey)
+	if err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+
+	prev, ok := store.Load(mv.From)
+	if !ok {
+		return nil, NewError(ErrNotFound, "dataset '%s' not found", mv.From)
+	}
+	if err := checkOwner(prev, profileID, o); err != nil {
+		return nil, err
+	}
+	if prev.Commit != nil && mv.Timestamp.Before(prev.Commit.Timestamp) {
+		return nil, NewError(ErrConflict, "move is older than dataset '%s'", mv.From)
+	}
+	if prev.Moved != nil && !mv.Timestamp.After(*prev.Moved) {
+		return nil, NewError(ErrConflict, "move must be newer than the last move of dataset '%s'", mv.From)
+	}
+
+	handle, name, _ := SplitDatasetKey(mv.To)
+	if _, err := checkHandle(handle, profileID, mv.PublicKey, o); err != nil {
+		return nil, err
+	}
+	if _, exists := store.Load(mv.To); exists {
+		return nil, NewError(ErrConflict, "dataset '%s' already exists", mv.To)
+	}
+
+	moved := *prev
+	moved.Handle = handle
+	moved.Name = name
+	ts := mv.Timestamp
+	moved.Moved = &ts
+	if moved.SignatureVersion == SignatureV2 {
+		// keep the record verifiable against the key it was signed under
+		if moved.SignedKey == "" {
+			moved.SignedKey = prev.Key()
+		}
+		if moved.SignedKey == mv.To {
+			moved.SignedKey = ""
+		}
+	}
+	store.Delete(mv.From)
+	store.Store(mv.To, &moved)
+
+	if o.Redirects != nil {
+		o.Redirects.Delete(mv.To)
+		o.Redirects.Store(mv.From, &Redirect{From: mv.From, To: mv.To, Created: mv.Timestamp})
+	}
+	return &moved, nil
+}
+
+// DeprecateDataset marks a dataset as deprecated. The signer of dep must own
+// the dataset and sign it for OpDeprecate
+func DeprecateDataset(store Datasets, dep *Deprecation, opts ...func(o *RegisterOptions)) (*Dataset, error) {
+	prev, err := checkDeprecation(store, dep, OpDeprecate, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d := *prev
+	d.Deprecation = dep
+	ts := dep.Timestamp
+	d.DeprecationUpdated = &ts
+	store.Store(dep.Ref, &d)
+	return &d, nil
+}
+
+// UndeprecateDataset removes a dataset's deprecation. The signer of dep must
+// own the dataset and sign it for OpUndeprecate
+func UndeprecateDataset(store Datasets, dep *Deprecation, opts ...func(o *RegisterOptions)) (*Dataset, error) {
+	prev, err := checkDeprecation(store, dep, OpUndeprecate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if prev.Deprecation == nil {
+		return nil, NewError(ErrConflict, "dataset '%s' is not deprecated", dep.Ref)
+	}
+
+	d := *prev
+	d.Deprecation = nil
+	ts := dep.Timestamp
+	d.DeprecationUpdated = &ts
+	store.Store(dep.Ref, &d)
+	return &d, nil
+}
+
+// checkDeprecation validates & verifies a deprecation signed for op,
+// returning the dataset it refers to if the signer owns it
+func checkDeprecation(store Datasets, dep *Deprecation, op string, opts []func(o *RegisterOptions)) (*Dataset, error) {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := dep.Validate(); err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+	if dep.Operation != op {
+		return nil, NewError(ErrInvalid, "expected operation '%s', got '%s'", op, dep.Operation)
+	}
+	if err := dep.Verify(); err != nil {
+		return nil, withKind(ErrUnauthorized, err)
+	}
+	profileID, err := ProfileIDFromPublicKey(dep.PublicKey)
+	if err != nil {
+		return nil, withKind(ErrInvalid, err)
+	}
+
+	prev, ok := store.Load(dep.Ref)
+	if !ok {
+		return nil, NewError(ErrNotFound, "dataset '%s' not found", dep.Ref)
+	}
+	if err := checkOwner(prev, profileID, o); err != nil {
+		return nil, err
+	}
+	if prev.DeprecationUpdated != nil && !dep.Timestamp.After(*prev.DeprecationUpdated) {
+		return nil, NewError(ErrConflict, "deprecation change must be newer than the last deprecation change")
+	}
+	return prev, nil
+}
+
+// MemRedirects is a map of redirects safe for concurrent use
+type MemRedirects struct {
+	sync.RWMutex
+	internal map[string]*Redirect
+}
+
+// NewMemRedirects allocates a new *MemRedirects map
+func NewMemRedirects() *MemRedirects {
+	return &MemRedirects{
+		internal: make(map[string]*Redirect),
+	}
+}
+
+// Len returns the number of records in the map
+func (rs *MemRedirects) Len() int {
+	rs.RLock()
+	defer rs.RUnlock()
+	return len(rs.internal)
+}
+
+// Load fetches a redirect from the map by previous dataset key
+func (rs *MemRedirects) Load(from string) (value *Redirect, ok bool) {
+	rs.RLock()
+	defer rs.RUnlock()
+	value, ok = rs.internal[from]
+	return
+}
+
+// SortedRange calls an iteration fuction on each element in the map in key
+// order until the end of the list is reached or iter returns true
+func (rs *MemRedirects) SortedRange(iter func(from string, r *Redirect) (brk bool)) {
+	rs.RLock()
+	defer rs.RUnlock()
+	keys := make([]string, 0, len(rs.internal))
+	for key := range rs.internal {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if iter(key, rs.internal[key]) {
+			break
+		}
+	}
+}
+
+// Store adds an entry
+func (rs *MemRedirects) Store(from string, value *Redirect) {
+	rs.Lock()
+	rs.internal[from] = value
+	rs.Unlock()
+}
+
+// Delete removes a record from MemRedirects at from
+func (rs *MemRedirects) Delete(from string) {
+	rs.Lock()
+	delete(rs.internal, from)
+	rs.Unlock()
+}
@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-crypto"
+)
+
+func TestMoveDataset(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	ownerKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	otherKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ps := NewMemProfiles()
+	for handle, key := range map[string]crypto.PrivKey{"owner": ownerKey, "other": otherKey} {
+		pro, err := ProfileFromPrivateKey(handle, key)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := RegisterProfile(ps, pro); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	store := NewMemDatasets()
+	rs := NewMemRedirects()
+	opts := []func(o *RegisterOptions){WithProfiles(ps), WithRedirects(rs)}
+	ds := signedTestDataset(t, "owner", "cities", ownerKey)
+	if err := ds.Sign(ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := RegisterDataset(store, ds, opts...); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		from, to string
+		key      crypto.PrivKey
+		err      string
+	}{
+		{"owner", "owner/towns", ownerKey, "from: 'owner' is not a valid dataset key, must be in the form handle/name"},
+		{"owner/cities", "owner/cities", ownerKey, "from and to must differ"},
+		{"owner/missing", "owner/towns", ownerKey, "dataset 'owner/missing' not found"},
+		{"owner/cities", "owner/towns", otherKey, "dataset 'owner/cities' is owned by another profile"},
+		{"owner/cities", "other/towns", ownerKey, "publickey does not match profile 'other'"},
+		{"owner/cities", "nobody/towns", ownerKey, "handle 'nobody' is not registered"},
+	}
+	for i, c := range cases {
+		mv, err := NewDatasetMove(c.from, c.to, c.key)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := MoveDataset(store, mv, opts...); err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+		}
+	}
+
+	mv, err := NewDatasetMove("owner/cities", "owner/towns", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	d, err := MoveDataset(store, mv, opts...)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Key() != "owner/towns" {
+		t.Errorf("expected moved dataset key to be owner/towns, got: %s", d.Key())
+	}
+	if _, ok := store.Load("owner/cities"); ok {
+		t.Errorf("expected dataset to be removed from previous key")
+	}
+	if d.SignedKey != "owner/cities" {
+		t.Errorf("expected moved dataset to record the key it was signed under, got: %s", d.SignedKey)
+	}
+	if err := d.Verify(); err != nil {
+		t.Errorf("expected moved dataset to verify against the key it was signed under: %s", err.Error())
+	}
+
+	// moving back & replaying the original move must not succeed
+	back, err := NewDatasetMove("owner/towns", "owner/cities", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	back.Timestamp = mv.Timestamp.Add(time.Second)
+	if err := resignMove(back, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d, err = MoveDataset(store, back, opts...); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.SignedKey != "" {
+		t.Errorf("expected a dataset moved back to its signed key to drop SignedKey, got: %s", d.SignedKey)
+	}
+	if _, err := MoveDataset(store, mv, opts...); ErrorKind(err) != ErrConflict {
+		t.Errorf("expected replayed move to conflict, got: %v", err)
+	}
+	mv, err = NewDatasetMove("owner/cities", "owner/towns", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mv.Timestamp = back.Timestamp.Add(time.Second)
+	if err := resignMove(mv, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := MoveDataset(store, mv, opts...); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	mv, err = NewDatasetMove("owner/towns", "owner/villages", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mv.Timestamp = back.Timestamp.Add(time.Second * 2)
+	if err := resignMove(mv, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := MoveDataset(store, mv, opts...); err != nil {
+		t.Fatal(err.Error())
+	}
+	if to, ok := ResolveRedirect(rs, "owner/cities"); !ok || to != "owner/villages" {
+		t.Errorf("expected chained redirect to resolve to owner/villages, got: %s", to)
+	}
+	if _, ok := ResolveRedirect(rs, "owner/villages"); ok {
+		t.Errorf("expected current location to have no redirect")
+	}
+}
+
+func TestDeprecateDataset(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	ownerKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	otherKey, _, err := crypto.GenerateEd25519Key(src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	store := NewMemDatasets()
+	if err := RegisterDataset(store, signedTestDataset(t, "owner", "cities", ownerKey)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dep, err := NewDeprecation("owner/cities", "use towns instead", "owner/towns", otherKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := DeprecateDataset(store, dep); err == nil {
+		t.Errorf("expected non-owner deprecation to error")
+	}
+
+	dep, err = NewDeprecation("owner/cities", "use towns instead", "owner/towns", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	d, err := DeprecateDataset(store, dep)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Deprecation == nil || d.Deprecation.Successor != "owner/towns" {
+		t.Errorf("expected dataset to be deprecated with successor")
+	}
+	if _, err := DeprecateDataset(store, dep); err == nil {
+		t.Errorf("expected replayed deprecation to error")
+	}
+	if _, err := UndeprecateDataset(store, dep); ErrorKind(err) != ErrInvalid {
+		t.Errorf("expected a deprecation to be refused for undeprecating, got: %v", err)
+	}
+
+	// re-registering keeps the stored deprecation
+	rereg := signedTestDataset(t, "owner", "cities", ownerKey)
+	rereg.Commit.Timestamp = rereg.Commit.Timestamp.Add(time.Second)
+	if err := rereg.SignOperation(OpRegister, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	rereg.Deprecation = nil
+	if err := RegisterDataset(store, rereg); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d, _ := store.Load("owner/cities"); d.Deprecation == nil {
+		t.Errorf("expected re-registering to keep the deprecation")
+	}
+
+	undep, err := NewUndeprecation("owner/cities", ownerKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	undep.Timestamp = dep.Timestamp.Add(time.Second)
+	if err := resignDeprecation(undep, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	d, err = UndeprecateDataset(store, undep)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Deprecation != nil {
+		t.Errorf("expected deprecation to be removed")
+	}
+	if _, err := UndeprecateDataset(store, undep); err == nil {
+		t.Errorf("expected undeprecating a dataset that isn't deprecated to error")
+	}
+	if _, err := DeprecateDataset(store, dep); ErrorKind(err) != ErrConflict {
+		t.Errorf("expected replaying a deprecation after undeprecating to conflict, got: %v", err)
+	}
+
+	forged := signedTestDataset(t, "owner", "cities", ownerKey)
+	forged.Commit.Timestamp = forged.Commit.Timestamp.Add(time.Second * 2)
+	if err := forged.SignOperation(OpRegister, ownerKey); err != nil {
+		t.Fatal(err.Error())
+	}
+	forged.Deprecation = dep
+	if err := RegisterDataset(store, forged); err != nil {
+		t.Fatal(err.Error())
+	}
+	if d, _ := store.Load("owner/cities"); d.Deprecation != nil {
+		t.Errorf("expected a client-supplied deprecation to be ignored")
+	}
+}
+
+// resignMove updates the signature of a modified dataset move
+func resignMove(mv *DatasetMove, privKey crypto.PrivKey) error {
+	sig, err := privKey.Sign(mv.sigBytes())
+	if err != nil {
+		return err
+	}
+	mv.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// resignDeprecation updates the signature of a modified deprecation
+func resignDeprecation(dep *Deprecation, privKey crypto.PrivKey) error {
+	sig, err := privKey.Sign(dep.sigBytes())
+	if err != nil {
+		return err
+	}
+	dep.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
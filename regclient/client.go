@@ -2,8 +2,15 @@
 package regclient
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 var (
@@ -32,9 +39,100 @@ type Client struct {
 type Config struct {
 	// Location is the URL base to call to
 	Location string
+	// RateLimitRetries is the number of times a request the registry rejects
+	// with 429 Too Many Requests is retried. 0 uses DefaultRateLimitRetries,
+	// a negative value disables retries
+	RateLimitRetries int
+	// Retries is the number of times idempotent requests (GET, PUT & DELETE)
+	// are retried after network errors or 502, 503 & 504 responses. Retries
+	// back off like rate limited requests. 0 disables retries
+	Retries int
+	// Timeout limits how long each request can take, including retries &
+	// reading the response. 0 means no timeout
+	Timeout time.Duration
+	// PinPollInterval is how often Pin checks on a pin the registry hasn't
+	// finished. 0 uses DefaultPinPollInterval
+	PinPollInterval time.Duration
 }
 
 // NewClient creates a registry from a provided Registry configuration
 func NewClient(cfg *Config) *Client {
-	return &Client{cfg, HTTPClient}
+	retries := cfg.RateLimitRetries
+	if retries == 0 {
+		retries = DefaultRateLimitRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	hc := *HTTPClient
+	if cfg.Timeout > 0 {
+		hc.Timeout = cfg.Timeout
+	}
+	if retries > 0 || cfg.Retries > 0 {
+		hc.Transport = &retryTransport{base: HTTPClient.Transport, retries: retries, failureRetries: cfg.Retries}
+	}
+	return &Client{cfg, &hc}
+}
+
+// do sends a request to a registry endpoint & decodes the data of the
+// response envelope into data. body is sent as JSON if it isn't nil, data may
+// be nil to discard the response. Responses other than 200 OK return an *Error
+func (c Client) do(ctx context.Context, method, endpoint string, body, data interface{}) error {
+	if c.cfg.Location == "" {
+		return ErrNoRegistry
+	}
+
+	var r io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, c.cfg.Location+endpoint, r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return transportError(err)
+	}
+	defer func() {
+		// drain so the connection can be reused
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}()
+	return decodeResponse(res, data)
+}
+
+// decodeResponse reads a registry response envelope. Error responses may not
+// be JSON, eg: from a proxy, so they fall back to the status text
+func decodeResponse(res *http.Response, data interface{}) error {
+	env := struct {
+		Data interface{}
+		Meta struct {
+			Error  string
+			Status string
+			Code   int
+		}
+	}{Data: data}
+
+	err := json.NewDecoder(res.Body).Decode(&env)
+	if res.StatusCode != http.StatusOK {
+		return newResponseError(res.StatusCode, env.Meta.Error)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding registry response: %s", err.Error())
+	}
+	return nil
 }
@@ -1,11 +1,8 @@
 package regclient
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"strings"
 
 	crypto "github.com/libp2p/go-libp2p-crypto"
 	util "github.com/qri-io/apiutil"
@@ -16,6 +13,11 @@ import (
 
 // GetDataset fetches a dataset from a registry
 func (c Client) GetDataset(peername, dsname, profileID, hash string) (*registry.Dataset, error) {
+	return c.GetDatasetContext(context.Background(), peername, dsname, profileID, hash)
+}
+
+// GetDatasetContext is GetDataset with a context that can cancel the request
+func (c Client) GetDatasetContext(ctx context.Context, peername, dsname, profileID, hash string) (*registry.Dataset, error) {
 	ref := ns.Ref{
 		Peername:  peername,
 		Name:      dsname,
@@ -23,130 +25,237 @@ func (c Client) GetDataset(peername, dsname, profileID, hash string) (*registry.
 		Path:      hash,
 	}
 
-	return c.doDatasetReq("GET", ref)
+	return c.doDatasetReq(ctx, "GET", ref)
 }
 
 // PutDataset adds a dataset to a registry
 func (c Client) PutDataset(peername, dsname string, ds *dataset.Dataset, pubKey crypto.PubKey) error {
+	return c.PutDatasetContext(context.Background(), peername, dsname, ds, pubKey)
+}
+
+// PutDatasetContext is PutDataset with a context that can cancel the request
+func (c Client) PutDatasetContext(ctx context.Context, peername, dsname string, ds *dataset.Dataset, pubKey crypto.PubKey) error {
 	d, err := registry.NewDataset(peername, dsname, ds, pubKey)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.doJSONDatasetReq("POST", d)
+	_, err = c.doJSONDatasetReq(ctx, "POST", d)
 	return err
 }
 
 // DeleteDataset removes a dataset from the registry
 func (c Client) DeleteDataset(peername, dsname string, ds *dataset.Dataset, pubKey crypto.PubKey) error {
+	return c.DeleteDatasetContext(context.Background(), peername, dsname, ds, pubKey)
+}
+
+// DeleteDatasetContext is DeleteDataset with a context that can cancel the
+// request
+func (c Client) DeleteDatasetContext(ctx context.Context, peername, dsname string, ds *dataset.Dataset, pubKey crypto.PubKey) error {
 	d, err := registry.NewDataset(peername, dsname, ds, pubKey)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.doJSONDatasetReq("DELETE", d)
+	_, err = c.doJSONDatasetReq(ctx, "DELETE", d)
 	return err
 }
 
-// ListDatasets returns a list of the datasets in the registry, using limit and offset
-func (c Client) ListDatasets(limit, offset int) ([]*registry.Dataset, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
+// SignDataset creates a registry dataset signed with privKey using the
+// registry's current signature scheme, which covers handle, name & path
+func SignDataset(peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	return signDataset(registry.OpRegister, peername, dsname, ds, privKey)
+}
+
+// signDataset creates a registry dataset signed for op with privKey
+func signDataset(op, peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	d, err := registry.NewDataset(peername, dsname, ds, privKey.GetPublic())
+	if err != nil {
+		return nil, err
 	}
+	if err := d.SignOperation(op, privKey); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
 
-	page := util.NewPageFromOffsetAndLimit(offset, limit)
+// PutSignedDataset adds a dataset to a registry, signing the registry record
+// with privKey
+func (c Client) PutSignedDataset(peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) error {
+	return c.PutSignedDatasetContext(context.Background(), peername, dsname, ds, privKey)
+}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/datasets?page=%d&pageSize=%d", c.cfg.Location, page.Number, page.Size), nil)
+// PutSignedDatasetContext is PutSignedDataset with a context that can cancel
+// the request
+func (c Client) PutSignedDatasetContext(ctx context.Context, peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) error {
+	d, err := SignDataset(peername, dsname, ds, privKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
+	_, err = c.doJSONDatasetReq(ctx, "POST", d)
+	return err
+}
+
+// PutSignedDatasetWithPreview adds a dataset to a registry along with a
+// preview of ds.Body and a readme excerpt, signing the registry record with
+// privKey. previews are trimmed to registry bounds before sending
+func (c Client) PutSignedDatasetWithPreview(peername, dsname string, ds *dataset.Dataset, readme string, privKey crypto.PrivKey) error {
+	return c.PutSignedDatasetWithPreviewContext(context.Background(), peername, dsname, ds, readme, privKey)
+}
+
+// PutSignedDatasetWithPreviewContext is PutSignedDatasetWithPreview with a
+// context that can cancel the request
+func (c Client) PutSignedDatasetWithPreviewContext(ctx context.Context, peername, dsname string, ds *dataset.Dataset, readme string, privKey crypto.PrivKey) error {
+	d, err := SignDataset(peername, dsname, ds, privKey)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if d.Preview, err = registry.NewPreview(ds, readme); err != nil {
+		return err
 	}
 
-	env := struct {
-		Data []*registry.Dataset
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
-		}
-	}{}
+	_, err = c.doJSONDatasetReq(ctx, "POST", d)
+	return err
+}
+
+// GetDatasetPreview fetches the body & readme preview of a dataset
+func (c Client) GetDatasetPreview(peername, dsname, profileID, hash string) (*registry.Preview, error) {
+	return c.GetDatasetPreviewContext(context.Background(), peername, dsname, profileID, hash)
+}
 
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+// GetDatasetPreviewContext is GetDatasetPreview with a context that can
+// cancel the request
+func (c Client) GetDatasetPreviewContext(ctx context.Context, peername, dsname, profileID, hash string) (*registry.Preview, error) {
+	ref := ns.Ref{
+		Peername:  peername,
+		Name:      dsname,
+		ProfileID: profileID,
+		Path:      hash,
+	}
+	p := &registry.Preview{}
+	if err := c.do(ctx, "GET", fmt.Sprintf("/dataset/%s/preview", ref.String()), nil, p); err != nil {
 		return nil, err
 	}
+	return p, nil
+}
+
+// DeleteSignedDataset removes a dataset from the registry, signing the
+// registry record for deregistration with privKey
+func (c Client) DeleteSignedDataset(peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) error {
+	return c.DeleteSignedDatasetContext(context.Background(), peername, dsname, ds, privKey)
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
+// DeleteSignedDatasetContext is DeleteSignedDataset with a context that can
+// cancel the request
+func (c Client) DeleteSignedDatasetContext(ctx context.Context, peername, dsname string, ds *dataset.Dataset, privKey crypto.PrivKey) error {
+	d, err := signDataset(registry.OpDeregister, peername, dsname, ds, privKey)
+	if err != nil {
+		return err
 	}
 
-	return env.Data, nil
+	_, err = c.doJSONDatasetReq(ctx, "DELETE", d)
+	return err
 }
 
-func (c Client) doJSONDatasetReq(method string, d *registry.Dataset) (*registry.Dataset, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
-	}
+// ListDatasets returns a list of the datasets in the registry, using limit and offset
+func (c Client) ListDatasets(limit, offset int) ([]*registry.Dataset, error) {
+	return c.ListDatasetsContext(context.Background(), limit, offset)
+}
 
-	data, err := json.Marshal(d)
-	if err != nil {
+// ListDatasetsContext is ListDatasets with a context that can cancel the
+// request
+func (c Client) ListDatasetsContext(ctx context.Context, limit, offset int) ([]*registry.Dataset, error) {
+	page := util.NewPageFromOffsetAndLimit(offset, limit)
+	return c.doListDatasetsReq(ctx, fmt.Sprintf("/datasets?page=%d&pageSize=%d", page.Number, page.Size))
+}
+
+// TrendingDatasets lists datasets by popularity over the past number of
+// days, using limit and offset. Each dataset includes it's stats for the
+// window
+func (c Client) TrendingDatasets(days, limit, offset int) ([]*registry.Dataset, error) {
+	return c.TrendingDatasetsContext(context.Background(), days, limit, offset)
+}
+
+// TrendingDatasetsContext is TrendingDatasets with a context that can cancel
+// the request
+func (c Client) TrendingDatasetsContext(ctx context.Context, days, limit, offset int) ([]*registry.Dataset, error) {
+	page := util.NewPageFromOffsetAndLimit(offset, limit)
+	return c.doListDatasetsReq(ctx, fmt.Sprintf("/datasets/trending?days=%d&page=%d&pageSize=%d", days, page.Number, page.Size))
+}
+
+func (c Client) doListDatasetsReq(ctx context.Context, endpoint string) ([]*registry.Dataset, error) {
+	var ds []*registry.Dataset
+	if err := c.do(ctx, "GET", endpoint, nil, &ds); err != nil {
 		return nil, err
 	}
+	return ds, nil
+}
+
+// MoveDataset renames a dataset or moves it to a new handle. from & to are
+// dataset keys in the form handle/name. The registry keeps a redirect at from
+// that points to the dataset's new location
+func (c Client) MoveDataset(from, to string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	return c.MoveDatasetContext(context.Background(), from, to, privKey)
+}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/dataset", c.cfg.Location), bytes.NewReader(data))
+// MoveDatasetContext is MoveDataset with a context that can cancel the
+// request
+func (c Client) MoveDatasetContext(ctx context.Context, from, to string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	mv, err := registry.NewDatasetMove(from, to, privKey)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
+	return c.doJSONDatasetEndpointReq(ctx, "POST", "/dataset/move", mv)
+}
+
+// DeprecateDataset marks the dataset at ref as deprecated with an optional
+// message and successor dataset key
+func (c Client) DeprecateDataset(ref, message, successor string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	return c.DeprecateDatasetContext(context.Background(), ref, message, successor, privKey)
+}
+
+// DeprecateDatasetContext is DeprecateDataset with a context that can cancel
+// the request
+func (c Client) DeprecateDatasetContext(ctx context.Context, ref, message, successor string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	dep, err := registry.NewDeprecation(ref, message, successor, privKey)
 	if err != nil {
-		if strings.Contains(err.Error(), "no such host") {
-			return nil, ErrNoRegistry
-		}
 		return nil, err
 	}
-
-	return handleJSONDatasetRes(res)
+	return c.doJSONDatasetEndpointReq(ctx, "POST", "/dataset/deprecation", dep)
 }
 
-func handleJSONDatasetRes(res *http.Response) (*registry.Dataset, error) {
-	// add response to an envelope
-	env := struct {
-		Data *registry.Dataset
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
-		}
-	}{}
+// UndeprecateDataset removes the deprecation of the dataset at ref
+func (c Client) UndeprecateDataset(ref string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	return c.UndeprecateDatasetContext(context.Background(), ref, privKey)
+}
 
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+// UndeprecateDatasetContext is UndeprecateDataset with a context that can
+// cancel the request
+func (c Client) UndeprecateDatasetContext(ctx context.Context, ref string, privKey crypto.PrivKey) (*registry.Dataset, error) {
+	dep, err := registry.NewUndeprecation(ref, privKey)
+	if err != nil {
 		return nil, err
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
-	}
-
-	return env.Data, nil
+	return c.doJSONDatasetEndpointReq(ctx, "DELETE", "/dataset/deprecation", dep)
 }
 
-func (c Client) doDatasetReq(method string, ref ns.Ref) (*registry.Dataset, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
-	}
+func (c Client) doJSONDatasetReq(ctx context.Context, method string, d *registry.Dataset) (*registry.Dataset, error) {
+	return c.doJSONDatasetEndpointReq(ctx, method, "/dataset", d)
+}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/dataset/%s", c.cfg.Location, ref.String()), nil)
-	if err != nil {
+func (c Client) doJSONDatasetEndpointReq(ctx context.Context, method, endpoint string, body interface{}) (*registry.Dataset, error) {
+	d := &registry.Dataset{}
+	if err := c.do(ctx, method, endpoint, body, d); err != nil {
 		return nil, err
 	}
-	res, err := c.httpClient.Do(req)
-	if err != nil {
+	return d, nil
+}
+
+func (c Client) doDatasetReq(ctx context.Context, method string, ref ns.Ref) (*registry.Dataset, error) {
+	d := &registry.Dataset{}
+	if err := c.do(ctx, method, fmt.Sprintf("/dataset/%s", ref.String()), nil, d); err != nil {
 		return nil, err
 	}
-	return handleJSONDatasetRes(res)
+	return d, nil
 }
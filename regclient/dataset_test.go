@@ -21,8 +21,8 @@ func TestDatasetRequests(t *testing.T) {
 	_, err := c.GetDataset(handle, name, "", "")
 	if err == nil {
 		t.Errorf("expected empty get to error")
-	} else if err.Error() != "error 404: " {
-		t.Errorf("error mistmatch. expected: %s, got: %s", "error 404: ", err.Error())
+	} else if err.Error() != "error 404: dataset not found" {
+		t.Errorf("error mistmatch. expected: %s, got: %s", "error 404: dataset not found", err.Error())
 	}
 
 	ts, err := time.Parse(time.RFC3339Nano, "2001-01-01T01:01:01.000000001Z")
@@ -42,6 +42,10 @@ func TestDatasetRequests(t *testing.T) {
 		},
 	}
 
+	if err := c.PutProfile(handle, pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+
 	err = c.PutDataset(handle, name, ds, pk1.GetPublic())
 	if err != nil {
 		t.Error(err.Error())
@@ -75,4 +79,17 @@ func TestDatasetRequests(t *testing.T) {
 		t.Errorf("expected 0 datasets, got %d", len(datasets))
 	}
 
+	if err := c.PutSignedDataset(handle, name, ds, pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := c.GetDataset(handle, name, "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.SignatureVersion != registry.SignatureV2 {
+		t.Errorf("expected signature version %d, got: %d", registry.SignatureV2, got.SignatureVersion)
+	}
+	if err := c.DeleteSignedDataset(handle, name, ds, pk1); err != nil {
+		t.Error(err.Error())
+	}
 }
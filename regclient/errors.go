@@ -0,0 +1,93 @@
+package regclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/qri-io/registry"
+)
+
+var (
+	// ErrRateLimited indicates the registry rejected a request with 429 Too
+	// Many Requests after retries were used up
+	ErrRateLimited = errors.New("registry: rate limited")
+	// ErrUnavailable indicates the registry is temporarily unable to serve
+	// requests, eg: while shutting down
+	ErrUnavailable = errors.New("registry: unavailable")
+)
+
+// Error is an error response from a registry. Kind mirrors the server's
+// error kind: one of registry.ErrNotFound, registry.ErrConflict,
+// registry.ErrUnauthorized, registry.ErrInvalid, ErrRateLimited or
+// ErrUnavailable. Kind is nil for other failures
+type Error struct {
+	StatusCode int
+	Kind       error
+	// Message is the error the registry responded with
+	Message string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("error %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap gives the kind of error
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorKind gives the kind of an error returned by a client method, nil if
+// the error has no kind
+func ErrorKind(err error) error {
+	switch err {
+	case ErrRateLimited, ErrUnavailable:
+		return err
+	}
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+	return registry.ErrorKind(err)
+}
+
+// newResponseError creates an error for a non-200 registry response
+func newResponseError(code int, message string) *Error {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	e := &Error{StatusCode: code, Message: message}
+	switch code {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusRequestEntityTooLarge:
+		e.Kind = registry.ErrInvalid
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Kind = registry.ErrUnauthorized
+	case http.StatusNotFound:
+		e.Kind = registry.ErrNotFound
+	case http.StatusConflict:
+		e.Kind = registry.ErrConflict
+	case http.StatusTooManyRequests:
+		e.Kind = ErrRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		e.Kind = ErrUnavailable
+	}
+	return e
+}
+
+// transportError replaces errors for registry hosts that don't resolve with
+// ErrNoRegistry
+func transportError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		if _, ok := ue.Err.(*net.DNSError); ok {
+			return ErrNoRegistry
+		}
+		if oe, ok := ue.Err.(*net.OpError); ok {
+			if _, ok := oe.Err.(*net.DNSError); ok {
+				return ErrNoRegistry
+			}
+		}
+	}
+	return err
+}
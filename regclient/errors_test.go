@@ -0,0 +1,110 @@
+package regclient
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestErrorKinds(t *testing.T) {
+	reg := registry.Registry{
+		Profiles:      registry.NewMemProfiles(),
+		Datasets:      registry.NewMemDatasets(),
+		Organizations: registry.NewMemOrganizations(),
+	}
+	s := httptest.NewServer(handlers.NewRoutes(reg))
+	defer s.Close()
+	cli := NewClient(&Config{Location: s.URL})
+
+	key2, _, err := crypto.GenerateSecp256k1Key(rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := cli.PutProfile("b5", pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		description string
+		err         error
+		kind        error
+	}{
+		{"taken handle", cli.PutProfile("b5", key2), registry.ErrConflict},
+		{"another key's profile", cli.DeleteProfile("b5", key2), registry.ErrUnauthorized},
+		{"missing profile", cli.GetProfile(&registry.Profile{Handle: "nobody"}), registry.ErrNotFound},
+		{"missing handle", cli.GetProfile(&registry.Profile{}), registry.ErrInvalid},
+	}
+	for _, c := range cases {
+		if c.err == nil {
+			t.Errorf("%s: expected an error", c.description)
+			continue
+		}
+		if got := ErrorKind(c.err); got != c.kind {
+			t.Errorf("%s: expected kind %v, got: %v (%s)", c.description, c.kind, got, c.err.Error())
+		}
+	}
+
+	err = cli.GetProfile(&registry.Profile{Handle: "nobody"})
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusNotFound || e.Message != "profile not found" {
+		t.Errorf("expected the registry's message in a 404 error, got: %#v", err)
+	}
+	if err := cli.Pin("/ipfs/QmFoo", pk1, nil); err != registry.ErrPinsetNotSupported {
+		t.Errorf("expected pinning to a registry without pins to be unsupported, got: %v", err)
+	}
+	if err := NewClient(&Config{}).PutProfile("b5", pk1); err != ErrNoRegistry {
+		t.Errorf("expected ErrNoRegistry without a location, got: %v", err)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	cases := []struct {
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{http.StatusTooManyRequests, `{"meta":{"code":429,"error":"slow down"}}`, ErrRateLimited, "slow down"},
+		{http.StatusServiceUnavailable, `{"meta":{"code":503,"error":"pinset closed"}}`, ErrUnavailable, "pinset closed"},
+		{http.StatusBadGateway, `<html>bad gateway</html>`, ErrUnavailable, "Bad Gateway"},
+		{http.StatusInternalServerError, `{"meta":{"code":500,"error":"boom"}}`, nil, "boom"},
+	}
+
+	for i, c := range cases {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+		cli := NewClient(&Config{Location: s.URL, RateLimitRetries: -1})
+		_, err := cli.ListDatasets(10, 0)
+		s.Close()
+
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("case %d: expected an *Error, got: %#v", i, err)
+			continue
+		}
+		if e.StatusCode != c.status {
+			t.Errorf("case %d: expected status %d, got: %d", i, c.status, e.StatusCode)
+		}
+		if ErrorKind(err) != c.kind {
+			t.Errorf("case %d: expected kind %v, got: %v", i, c.kind, ErrorKind(err))
+		}
+		if e.Message != c.message {
+			t.Errorf("case %d: expected message '%s', got: '%s'", i, c.message, e.Message)
+		}
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`not json`))
+	}))
+	defer s.Close()
+	_, err := NewClient(&Config{Location: s.URL}).ListDatasets(10, 0)
+	if err == nil || ErrorKind(err) != nil {
+		t.Errorf("expected an undecodable response to error without a kind, got: %v", err)
+	}
+}
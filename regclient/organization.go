@@ -0,0 +1,78 @@
+package regclient
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/registry"
+)
+
+// GetOrganization fetches an organization from the registry by handle
+func (c Client) GetOrganization(handle string) (*registry.Organization, error) {
+	return c.GetOrganizationContext(context.Background(), handle)
+}
+
+// GetOrganizationContext is GetOrganization with a context that can cancel
+// the request
+func (c Client) GetOrganizationContext(ctx context.Context, handle string) (*registry.Organization, error) {
+	return c.doJSONOrgReq(ctx, "GET", "/organization?handle="+url.QueryEscape(handle), nil)
+}
+
+// PutOrganization creates an organization on the registry, the owner of
+// privKey becomes the organization's first owner
+func (c Client) PutOrganization(handle string, privKey crypto.PrivKey) (*registry.Organization, error) {
+	return c.PutOrganizationContext(context.Background(), handle, privKey)
+}
+
+// PutOrganizationContext is PutOrganization with a context that can cancel
+// the request
+func (c Client) PutOrganizationContext(ctx context.Context, handle string, privKey crypto.PrivKey) (*registry.Organization, error) {
+	o, err := registry.OrganizationFromPrivateKey(handle, privKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.doJSONOrgReq(ctx, "POST", "/organization", o)
+}
+
+// DeleteOrganization removes an organization from the registry. privKey must
+// belong to an organization owner
+func (c Client) DeleteOrganization(handle string, privKey crypto.PrivKey) error {
+	return c.DeleteOrganizationContext(context.Background(), handle, privKey)
+}
+
+// DeleteOrganizationContext is DeleteOrganization with a context that can
+// cancel the request
+func (c Client) DeleteOrganizationContext(ctx context.Context, handle string, privKey crypto.PrivKey) error {
+	o, err := registry.NewOrganizationRequest(registry.OpDeregister, handle, privKey)
+	if err != nil {
+		return err
+	}
+	_, err = c.doJSONOrgReq(ctx, "DELETE", "/organization", o)
+	return err
+}
+
+// UpdateMembership adds, changes the role of, or removes a profile from an
+// organization. privKey must belong to an organization owner
+func (c Client) UpdateMembership(org, profileID, role string, remove bool, privKey crypto.PrivKey) (*registry.Organization, error) {
+	return c.UpdateMembershipContext(context.Background(), org, profileID, role, remove, privKey)
+}
+
+// UpdateMembershipContext is UpdateMembership with a context that can cancel
+// the request
+func (c Client) UpdateMembershipContext(ctx context.Context, org, profileID, role string, remove bool, privKey crypto.PrivKey) (*registry.Organization, error) {
+	mc, err := registry.NewMembershipChange(org, profileID, role, remove, privKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.doJSONOrgReq(ctx, "POST", "/organization/members", mc)
+}
+
+// doJSONOrgReq is a common wrapper for /organization endpoint requests
+func (c Client) doJSONOrgReq(ctx context.Context, method, endpoint string, body interface{}) (*registry.Organization, error) {
+	o := &registry.Organization{}
+	if err := c.do(ctx, method, endpoint, body, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
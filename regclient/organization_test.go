@@ -0,0 +1,70 @@
+package regclient
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestOrganizationRequests(t *testing.T) {
+	reg := registry.Registry{
+		Profiles:      registry.NewMemProfiles(),
+		Organizations: registry.NewMemOrganizations(),
+		Datasets:      registry.NewMemDatasets(),
+	}
+	ts := httptest.NewServer(handlers.NewRoutes(reg))
+	c := NewClient(&Config{
+		Location: ts.URL,
+	})
+
+	if _, err := c.GetOrganization("acme"); err == nil {
+		t.Errorf("expected empty get to error")
+	}
+
+	o, err := c.PutOrganization("acme", pk1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(o.Owners) != 1 {
+		t.Errorf("expected organization to have 1 owner, got: %d", len(o.Owners))
+	}
+
+	memberKey, _, err := crypto.GenerateEd25519Key(rand.New(rand.NewSource(0)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	member, err := registry.ProfileFromPrivateKey("member", memberKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := c.UpdateMembership("acme", member.ProfileID, registry.RoleMember, false, memberKey); err == nil {
+		t.Errorf("expected non-owner membership change to error")
+	}
+	o, err = c.UpdateMembership("acme", member.ProfileID, registry.RoleMember, false, pk1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !o.IsMember(member.ProfileID) {
+		t.Errorf("expected profile to be a member")
+	}
+
+	o, err = c.GetOrganization("acme")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !o.IsMember(member.ProfileID) {
+		t.Errorf("expected fetched organization to list member")
+	}
+
+	if err := c.DeleteOrganization("acme", memberKey); err == nil {
+		t.Errorf("expected non-owner delete to error")
+	}
+	if err := c.DeleteOrganization("acme", pk1); err != nil {
+		t.Error(err.Error())
+	}
+}
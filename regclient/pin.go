@@ -1,11 +1,10 @@
 package regclient
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
 	"github.com/libp2p/go-libp2p-crypto"
@@ -13,156 +12,94 @@ import (
 	"github.com/qri-io/registry/pinset"
 )
 
-// Status checks if a given path is pinned to this registry
-func (c Client) Status(path string) (s pinset.PinStatus, err error) {
-	var (
-		req *http.Request
-		res *http.Response
-	)
+// DefaultPinPollInterval is how often Pin checks on an unfinished pin when
+// Config.PinPollInterval is 0
+var DefaultPinPollInterval = time.Second
 
-	req, err = http.NewRequest("GET", fmt.Sprintf("%s/pins/status?path=%s", c.cfg.Location, path), nil)
-	if err != nil {
-		return
-	}
-
-	res, err = c.httpClient.Do(req)
-	if err != nil {
-		return
-	}
-
-	rs := struct {
-		Data pinset.PinStatus
-	}{}
-	if err = json.NewDecoder(res.Body).Decode(&rs); err != nil {
-		return
-	}
+// Status checks if a given path is pinned to this registry. Paths the
+// registry has no record of return an error of kind registry.ErrNotFound
+func (c Client) Status(path string) (pinset.PinStatus, error) {
+	return c.StatusContext(context.Background(), path)
+}
 
-	return rs.Data, nil
+// StatusContext is Status with a context that can cancel the request
+func (c Client) StatusContext(ctx context.Context, path string) (pinset.PinStatus, error) {
+	s := pinset.PinStatus{}
+	err := c.do(ctx, "GET", "/pins/status?path="+url.QueryEscape(path), nil, &s)
+	return s, err
 }
 
-// Pin requests a dataset be replicated on the registry
+// Pin requests a dataset be replicated on the registry, waiting until the
+// registry reports the path is pinned. Pin waits as long as pinning takes,
+// use PinContext to give up sooner
 func (c Client) Pin(path string, privKey crypto.PrivKey, addrs []string) error {
+	return c.PinContext(context.Background(), path, privKey, addrs)
+}
+
+// PinContext requests a dataset be replicated on the registry, polling pin
+// status until the path is pinned, pinning fails, or ctx is done
+func (c Client) PinContext(ctx context.Context, path string, privKey crypto.PrivKey, addrs []string) error {
 	req, err := pinset.NewPinRequest(path, privKey, addrs)
 	if err != nil {
 		return err
 	}
-	status, err := c.doJSONPinReq("POST", req)
+	status, err := c.doJSONPinReq(ctx, "POST", req)
 	if err != nil {
 		return err
 	}
 
-	if status.Pinned {
-		return nil
-	} else if status.Error != "" {
-		return fmt.Errorf(status.Error)
+	interval := c.cfg.PinPollInterval
+	if interval <= 0 {
+		interval = DefaultPinPollInterval
 	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
 
-	// poll, checking for pinned == true
-	updates := c.statusPoll(path, stdPollInterval)
-
-	for status := range updates {
+	for {
 		if status.Pinned {
-			break
+			return nil
 		} else if status.Error != "" {
 			return fmt.Errorf(status.Error)
 		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+		s, err := c.StatusContext(ctx, path)
+		if err != nil {
+			return err
+		}
+		status = &s
 	}
-	return nil
 }
 
 // Unpin requests a dataset not be replicated to the registry
 func (c Client) Unpin(path string, privKey crypto.PrivKey) error {
+	return c.UnpinContext(context.Background(), path, privKey)
+}
+
+// UnpinContext is Unpin with a context that can cancel the request
+func (c Client) UnpinContext(ctx context.Context, path string, privKey crypto.PrivKey) error {
 	req, err := pinset.NewPinRequest(path, privKey, nil)
 	if err != nil {
 		return err
 	}
-	_, err = c.doJSONPinReq("DELETE", req)
-	return nil
+	_, err = c.doJSONPinReq(ctx, "DELETE", req)
+	return err
 }
 
-// doJSONPinReq is a common wrapper for /pin endpoint requests
-func (c Client) doJSONPinReq(method string, pr *pinset.PinRequest) (*pinset.PinStatus, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
-	}
-
-	data, err := json.Marshal(pr)
-	if err != nil {
-		fmt.Println("marshal err:", err.Error())
-		return nil, err
-	}
-
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/pins", c.cfg.Location), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		if strings.Contains(err.Error(), "no such host") {
-			return nil, ErrNoRegistry
-		}
-		return nil, err
-	}
-
-	if res.StatusCode == http.StatusNotFound {
-		return nil, registry.ErrPinsetNotSupported
-	}
-
-	// add response to an envelope
-	env := struct {
-		Data *pinset.PinStatus
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
+// doJSONPinReq is a common wrapper for /pin endpoint requests. The pins
+// handler never responds 404 to POST or DELETE, so not found means the
+// registry doesn't serve pins
+func (c Client) doJSONPinReq(ctx context.Context, method string, pr *pinset.PinRequest) (*pinset.PinStatus, error) {
+	status := &pinset.PinStatus{}
+	if err := c.do(ctx, method, "/pins", pr, status); err != nil {
+		if e, ok := err.(*Error); ok && e.StatusCode == http.StatusNotFound {
+			return nil, registry.ErrPinsetNotSupported
 		}
-	}{}
-
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
 		return nil, err
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
-	}
-	if env.Data == nil {
-		return &pinset.PinStatus{}, nil
-	}
-
-	return env.Data, nil
-}
-
-const stdPollInterval = time.Duration(time.Second)
-
-func (c Client) statusPoll(path string, interval time.Duration) (updates chan pinset.PinStatus) {
-	tick := time.NewTicker(interval)
-	updates = make(chan pinset.PinStatus)
-	stopTick := make(chan bool)
-	done := func() {
-		tick.Stop()
-		stopTick <- true
-		close(updates)
-	}
-
-	go func() {
-		for {
-			select {
-			case <-stopTick:
-				return
-			case <-tick.C:
-				status, err := c.Status(path)
-				if err != nil {
-					status.Error = err.Error()
-					go done()
-				}
-				updates <- status
-				if status.Pinned {
-					go done()
-				}
-			}
-		}
-	}()
-
-	return updates
+	return status, nil
 }
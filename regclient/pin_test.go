@@ -1,8 +1,11 @@
 package regclient
 
 import (
+	"context"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/qri-io/registry"
 	"github.com/qri-io/registry/pinset"
@@ -28,19 +31,15 @@ func TestPinRequests(t *testing.T) {
 	}
 
 	path := "foo"
-	status, err := c.Status(path)
-	if err != nil {
-		t.Error(err.Error())
-	}
-	if status.Pinned != false {
-		t.Errorf("expected pinned '%s' to equal false", path)
+	if _, err := c.Status(path); ErrorKind(err) != registry.ErrNotFound {
+		t.Errorf("expected unknown path status to be not found, got: %v", err)
 	}
 
 	if err := c.Pin(path, pk1, nil); err != nil {
 		t.Error(err.Error())
 	}
 
-	status, err = c.Status(path)
+	status, err := c.Status(path)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -52,11 +51,28 @@ func TestPinRequests(t *testing.T) {
 		t.Error(err.Error())
 	}
 
-	status, err = c.Status(path)
-	if err != nil {
-		t.Error(err.Error())
+	if _, err := c.Status(path); ErrorKind(err) != registry.ErrNotFound {
+		t.Errorf("expected unpinned path status to be not found, got: %v", err)
+	}
+}
+
+func TestPinContext(t *testing.T) {
+	polls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pins/status" {
+			polls++
+		}
+		w.Write([]byte(`{"meta":{"code":200},"data":{"Path":"foo","Pinned":false}}`))
+	}))
+	defer s.Close()
+
+	c := NewClient(&Config{Location: s.URL, PinPollInterval: time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err := c.PinContext(ctx, "foo", pk1, nil); err != context.DeadlineExceeded {
+		t.Errorf("expected pinning to stop when the context is done, got: %v", err)
 	}
-	if status.Pinned != false {
-		t.Errorf("expected pinned '%s' to equal false", path)
+	if polls == 0 {
+		t.Error("expected pin status to be polled")
 	}
 }
@@ -0,0 +1,70 @@
+package regclient
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestDatasetPreview(t *testing.T) {
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{
+		Profiles: registry.NewMemProfiles(),
+		Datasets: registry.NewMemDatasets(),
+	}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	if err := c.PutProfile("b5", pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := &dataset.Dataset{
+		Path:      "/ipfs/QmCities",
+		Commit:    &dataset.Commit{Timestamp: time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)},
+		Structure: &dataset.Structure{Checksum: "QmChecksum"},
+	}
+	if err := c.PutSignedDataset("b5", "nopreview", ds, pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := c.GetDatasetPreview("b5", "nopreview", "", ""); err == nil {
+		t.Errorf("expected missing preview to error")
+	}
+
+	ds.Body = []interface{}{[]interface{}{"toronto", 2731571}, []interface{}{"new york", 8175133}}
+	if err := c.PutSignedDatasetWithPreview("b5", "cities", ds, "# cities", pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	p, err := c.GetDatasetPreview("b5", "cities", "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rows, ok := p.Body.([]interface{}); !ok || len(rows) != 2 {
+		t.Errorf("expected preview body to have 2 rows, got: %v", p.Body)
+	}
+	if p.Readme != "# cities" {
+		t.Errorf("readme mismatch. expected: '# cities', got: '%s'", p.Readme)
+	}
+
+	d, err := c.GetDataset("b5", "cities", "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Preview != nil {
+		t.Errorf("expected dataset lookup to omit preview")
+	}
+
+	list, err := c.ListDatasets(0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, d := range list {
+		if d.Preview != nil {
+			t.Errorf("expected dataset list to omit previews")
+		}
+	}
+}
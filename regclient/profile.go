@@ -1,20 +1,36 @@
 package regclient
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"strings"
+	"context"
+	"net/url"
 
 	"github.com/libp2p/go-libp2p-crypto"
 	"github.com/qri-io/registry"
 )
 
-// GetProfile fills in missing fields in p with registry data
+// GetProfile fills in missing fields in p with registry data, looking the
+// profile up by handle, profileID or public key
 func (c Client) GetProfile(p *registry.Profile) error {
-	pro, err := c.doJSONProfileReq("GET", p)
-	if err != nil {
+	return c.GetProfileContext(context.Background(), p)
+}
+
+// GetProfileContext is GetProfile with a context that can cancel the lookup
+func (c Client) GetProfileContext(ctx context.Context, p *registry.Profile) error {
+	q := url.Values{}
+	switch {
+	case p.Handle != "":
+		q.Set("handle", p.Handle)
+	case p.ProfileID != "" || p.PublicKey != "":
+		if p.ProfileID != "" {
+			q.Set("profileID", p.ProfileID)
+		}
+		if p.PublicKey != "" {
+			q.Set("publicKey", p.PublicKey)
+		}
+	}
+
+	pro := &registry.Profile{}
+	if err := c.do(ctx, "GET", "/profile?"+q.Encode(), nil, pro); err != nil {
 		return err
 	}
 	*p = *pro
@@ -23,65 +39,29 @@ func (c Client) GetProfile(p *registry.Profile) error {
 
 // PutProfile adds a profile to the registry
 func (c Client) PutProfile(handle string, privKey crypto.PrivKey) error {
+	return c.PutProfileContext(context.Background(), handle, privKey)
+}
+
+// PutProfileContext adds a profile to the registry, giving up when ctx is done
+func (c Client) PutProfileContext(ctx context.Context, handle string, privKey crypto.PrivKey) error {
 	p, err := registry.ProfileFromPrivateKey(handle, privKey)
 	if err != nil {
 		return err
 	}
-	_, err = c.doJSONProfileReq("POST", p)
-	return err
+	return c.do(ctx, "POST", "/profile", p, nil)
 }
 
 // DeleteProfile removes a profile from the registry
 func (c Client) DeleteProfile(handle string, privKey crypto.PrivKey) error {
-	p, err := registry.ProfileFromPrivateKey(handle, privKey)
-	if err != nil {
-		return err
-	}
-	_, err = c.doJSONProfileReq("DELETE", p)
-	return nil
+	return c.DeleteProfileContext(context.Background(), handle, privKey)
 }
 
-// doJSONProfileReq is a common wrapper for /profile endpoint requests
-func (c Client) doJSONProfileReq(method string, p *registry.Profile) (*registry.Profile, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
-	}
-
-	data, err := json.Marshal(p)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/profile", c.cfg.Location), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
+// DeleteProfileContext removes a profile from the registry, giving up when
+// ctx is done
+func (c Client) DeleteProfileContext(ctx context.Context, handle string, privKey crypto.PrivKey) error {
+	p, err := registry.ProfileFromPrivateKey(handle, privKey)
 	if err != nil {
-		if strings.Contains(err.Error(), "no such host") {
-			return nil, ErrNoRegistry
-		}
-		return nil, err
-	}
-
-	// add response to an envelope
-	env := struct {
-		Data *registry.Profile
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
-		}
-	}{}
-
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
-		return nil, err
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
+		return err
 	}
-
-	return env.Data, nil
+	return c.do(ctx, "DELETE", "/profile", p, nil)
 }
@@ -56,8 +56,8 @@ func TestProfileRequests(t *testing.T) {
 	err = c.GetProfile(p)
 	if err == nil {
 		t.Errorf("expected empty get to error")
-	} else if err.Error() != "error 404: " {
-		t.Errorf("error mistmatch. expected: %s, got: %s", "error 404: ", err.Error())
+	} else if err.Error() != "error 404: profile not found" {
+		t.Errorf("error mistmatch. expected: %s, got: %s", "error 404: profile not found", err.Error())
 	}
 
 	err = c.PutProfile(handle, pk1)
@@ -0,0 +1,68 @@
+package regclient
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestMoveAndDeprecateDataset(t *testing.T) {
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{
+		Profiles:  registry.NewMemProfiles(),
+		Datasets:  registry.NewMemDatasets(),
+		Redirects: registry.NewMemRedirects(),
+	}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	if err := c.PutProfile("b5", pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := &dataset.Dataset{
+		Path:      "/ipfs/QmCities",
+		Commit:    &dataset.Commit{Timestamp: time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)},
+		Structure: &dataset.Structure{Checksum: "QmChecksum"},
+	}
+	if err := c.PutSignedDataset("b5", "cities", ds, pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	d, err := c.MoveDataset("b5/cities", "b5/towns", pk1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Name != "towns" {
+		t.Errorf("expected moved dataset name to be towns, got: %s", d.Name)
+	}
+
+	d, err = c.GetDataset("b5", "cities", "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Name != "towns" {
+		t.Errorf("expected get of previous name to follow redirect, got: %s", d.Name)
+	}
+
+	d, err = c.DeprecateDataset("b5/towns", "no longer maintained", "", pk1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Deprecation == nil || d.Deprecation.Message != "no longer maintained" {
+		t.Errorf("expected dataset to be deprecated")
+	}
+
+	// deprecation timestamps must increase
+	time.Sleep(time.Millisecond)
+	d, err = c.UndeprecateDataset("b5/towns", pk1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Deprecation != nil {
+		t.Errorf("expected deprecation to be removed")
+	}
+}
@@ -1,62 +1,23 @@
 package regclient
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"context"
+	"net/url"
 
 	"github.com/qri-io/registry"
 )
 
 // GetReputation gets the reputation of a profile using the ProfileID
 func (c Client) GetReputation(id string) (*registry.ReputationResponse, error) {
-	repReq := registry.NewReputation(id)
-	res, err := c.doJSONReputationReq("GET", repReq)
-	if err != nil {
-		return nil, err
-	}
-	return res, nil
+	return c.GetReputationContext(context.Background(), id)
 }
 
-// doJSONReputationReq is a common wrapper for /reputation endpoint requests
-func (c Client) doJSONReputationReq(method string, rep *registry.Reputation) (*registry.ReputationResponse, error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
-	}
-
-	data, err := json.Marshal(rep)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/reputation", c.cfg.Location), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := c.httpClient.Do(req)
-	if err != nil {
+// GetReputationContext is GetReputation with a context that can cancel the
+// request
+func (c Client) GetReputationContext(ctx context.Context, id string) (*registry.ReputationResponse, error) {
+	res := &registry.ReputationResponse{}
+	if err := c.do(ctx, "GET", "/reputation?profileID="+url.QueryEscape(id), nil, res); err != nil {
 		return nil, err
 	}
-
-	env := struct {
-		Data *registry.ReputationResponse
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
-		}
-	}{}
-
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
-		return nil, err
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
-	}
-
-	return env.Data, nil
-
+	return res, nil
 }
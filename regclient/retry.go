@@ -0,0 +1,136 @@
+package regclient
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// DefaultRateLimitRetries is the number of times a rate limited request
+	// is retried when Config.RateLimitRetries is 0
+	DefaultRateLimitRetries = 3
+	// MaxRetryWait caps how long the client will wait before retrying a rate
+	// limited request. Requests the registry asks to delay longer fail with
+	// the registry's 429 response
+	MaxRetryWait = time.Second * 30
+	// RetryBackoff is the wait before the first retry of a rate limited
+	// request without a Retry-After header. It doubles on each retry
+	RetryBackoff = time.Millisecond * 500
+)
+
+// retryTransport retries requests that receive a 429 Too Many Requests
+// response, waiting as long as the Retry-After header asks, or backing off
+// exponentially if it's missing. Idempotent requests are also retried after
+// network errors & 502, 503 or 504 responses
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	// failureRetries is the number of times a failed idempotent request is
+	// retried
+	failureRetries int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := base.RoundTrip(req)
+		if attempt >= t.maxRetries(req, res, err) || req.Context().Err() != nil {
+			return res, err
+		}
+
+		wait, ok := time.Duration(0), false
+		if res != nil {
+			wait, ok = retryAfter(res.Header.Get("Retry-After"), time.Now())
+		}
+		if !ok {
+			wait = RetryBackoff << uint(attempt)
+		}
+		if wait > MaxRetryWait {
+			return res, err
+		}
+
+		// requests with bodies can only be retried if the body can be replayed
+		next := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return res, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return res, err
+			}
+			next = new(http.Request)
+			*next = *req
+			next.Body = body
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		req = next
+	}
+}
+
+// maxRetries gives the number of times a request with this outcome can be
+// retried
+func (t *retryTransport) maxRetries(req *http.Request, res *http.Response, err error) int {
+	if err == nil && res.StatusCode == http.StatusTooManyRequests {
+		return t.retries
+	}
+	if !idempotent(req.Method) {
+		return 0
+	}
+	if err != nil {
+		return t.failureRetries
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.failureRetries
+	}
+	return 0
+}
+
+// idempotent reports whether repeating a request with method has the same
+// effect as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package regclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRetries(t *testing.T) {
+	prevBackoff := RetryBackoff
+	RetryBackoff = time.Millisecond
+	defer func() { RetryBackoff = prevBackoff }()
+
+	var (
+		calls  int
+		bodies []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"meta":{"code":200},"data":[]}`))
+		}
+	}))
+	defer s.Close()
+
+	c := NewClient(&Config{Location: s.URL})
+	req, err := http.NewRequest("POST", s.URL, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || calls != 3 {
+		t.Errorf("expected success on 3rd call, got status %d after %d calls", res.StatusCode, calls)
+	}
+	for i, b := range bodies {
+		if b != "body" {
+			t.Errorf("call %d: expected request body to be replayed, got: '%s'", i, b)
+		}
+	}
+
+	calls = 0
+	c = NewClient(&Config{Location: s.URL, RateLimitRetries: -1})
+	if _, err := c.Suggest("foo", 1); err == nil {
+		t.Error("expected rate limited request to fail when retries are disabled")
+	}
+}
+
+func TestFailureRetries(t *testing.T) {
+	prevBackoff := RetryBackoff
+	RetryBackoff = time.Millisecond
+	defer func() { RetryBackoff = prevBackoff }()
+
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"meta":{"code":200},"data":[]}`))
+	}))
+	defer s.Close()
+
+	c := NewClient(&Config{Location: s.URL, Retries: 2})
+	if _, err := c.ListDatasets(10, 0); err != nil {
+		t.Errorf("expected GET to succeed after retries, got: %s", err.Error())
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got: %d", calls)
+	}
+
+	calls = 0
+	if err := c.PutProfile("b5", pk1); ErrorKind(err) != ErrUnavailable {
+		t.Errorf("expected POST to fail without retrying, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected POST to be sent once, got %d calls", calls)
+	}
+
+	calls = 0
+	c = NewClient(&Config{Location: s.URL})
+	if _, err := c.ListDatasets(10, 0); ErrorKind(err) != ErrUnavailable {
+		t.Errorf("expected GET to fail when retries are disabled, got: %v", err)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	block := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(block)
+
+	c := NewClient(&Config{Location: s.URL, Timeout: time.Millisecond * 20, Retries: 1})
+	if _, err := c.ListDatasets(10, 0); err == nil {
+		t.Error("expected request to time out")
+	}
+
+	c = NewClient(&Config{Location: s.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if _, err := c.ListDatasetsContext(ctx, 10, 0); err != context.DeadlineExceeded {
+		t.Errorf("expected context deadline to cancel the request, got: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", time.Second * 3, true},
+		{"Tue, 01 Jan 2019 00:00:10 GMT", time.Second * 10, true},
+		{"soon", 0, false},
+	}
+	for _, c := range cases {
+		wait, ok := retryAfter(c.header, now)
+		if wait != c.wait || ok != c.ok {
+			t.Errorf("'%s': expected %s, %t. got: %s, %t", c.header, c.wait, c.ok, wait, ok)
+		}
+	}
+}
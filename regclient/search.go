@@ -1,10 +1,10 @@
 package regclient
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/qri-io/registry"
@@ -33,42 +33,67 @@ type SearchParams struct {
 	Filters     []SearchFilter
 	Limit       int
 	Offset      int
+	// Column restricts results to datasets with a column of this name
+	Column string
+	// ColumnType restricts results to datasets with a column of this json
+	// schema type, eg: "integer"
+	ColumnType string
+	// Sort is a comma-separated list of fields to order results by, eg:
+	// "-timestamp,name". see registry.ParseSort for details
+	Sort string
+	// Boosts weights matches in each dataset field when scoring relevance,
+	// keyed by registry.SearchFields
+	Boosts map[string]float64
 }
 
 // Search makes a registry search request
 func (c Client) Search(p *SearchParams) ([]*registry.Result, error) {
+	return c.SearchContext(context.Background(), p)
+}
+
+// SearchContext is Search with a context that can cancel the request
+func (c Client) SearchContext(ctx context.Context, p *SearchParams) ([]*registry.Result, error) {
 	params := &registry.SearchParams{
 		Q: p.QueryString,
 		//Filters: p.Filters,
-		Limit:  p.Limit,
-		Offset: p.Offset,
+		Limit:      p.Limit,
+		Offset:     p.Offset,
+		Column:     p.Column,
+		ColumnType: p.ColumnType,
+		Sort:       p.Sort,
+		Boosts:     p.Boosts,
 	}
-	results, err := c.doJSONSearchReq("GET", params)
+	results, err := c.doJSONSearchReq(ctx, "GET", params)
 	if err != nil {
 		return nil, err
 	}
 	return results, nil
 }
 
-func (c Client) prepPostReq(s *registry.SearchParams) (*http.Request, error) {
-	data, err := json.Marshal(s)
-	if err != nil {
-		return nil, err
+// Suggest fetches up to limit autocomplete suggestions for a partial query.
+// a limit <= 0 uses the registry's default
+func (c Client) Suggest(prefix string, limit int) ([]registry.Suggestion, error) {
+	return c.SuggestContext(context.Background(), prefix, limit)
+}
+
+// SuggestContext is Suggest with a context that can cancel the request
+func (c Client) SuggestContext(ctx context.Context, prefix string, limit int) ([]registry.Suggestion, error) {
+	q := url.Values{}
+	q.Add("q", prefix)
+	if limit > 0 {
+		q.Add("limit", fmt.Sprintf("%d", limit))
 	}
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/search", c.cfg.Location), bytes.NewReader(data))
-	if err != nil {
+
+	var res []registry.Suggestion
+	if err := c.do(ctx, "GET", "/search/suggest?"+q.Encode(), nil, &res); err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	return req, nil
+	return res, nil
 }
 
-func (c Client) prepGetReq(s *registry.SearchParams) (*http.Request, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/search", c.cfg.Location), nil)
-	if err != nil {
-		return nil, err
-	}
-	q := req.URL.Query()
+// searchQuery encodes search params as query params for GET requests
+func searchQuery(s *registry.SearchParams) url.Values {
+	q := url.Values{}
 	q.Add("q", s.Q)
 	if s.Limit > 0 {
 		q.Add("limit", fmt.Sprintf("%d", s.Limit))
@@ -76,49 +101,35 @@ func (c Client) prepGetReq(s *registry.SearchParams) (*http.Request, error) {
 	if s.Offset > -1 {
 		q.Add("offset", fmt.Sprintf("%d", s.Offset))
 	}
-	req.URL.RawQuery = q.Encode()
-	return req, nil
-}
-
-func (c Client) doJSONSearchReq(method string, s *registry.SearchParams) (results []*registry.Result, err error) {
-	if c.cfg.Location == "" {
-		return nil, ErrNoRegistry
+	if s.Column != "" {
+		q.Add("column", s.Column)
 	}
-	// req := &http.Request{}
-	var req *http.Request
-	switch method {
-	case "POST":
-		req, err = c.prepPostReq(s)
-	case "GET":
-		req, err = c.prepGetReq(s)
+	if s.ColumnType != "" {
+		q.Add("columnType", s.ColumnType)
 	}
-	if err != nil {
-		return nil, err
+	if s.Sort != "" {
+		q.Add("sort", s.Sort)
 	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		if strings.Contains(err.Error(), "no such host") {
-			return nil, ErrNoRegistry
+	if len(s.Boosts) > 0 {
+		boosts := make([]string, 0, len(s.Boosts))
+		for field, b := range s.Boosts {
+			boosts = append(boosts, fmt.Sprintf("%s:%g", field, b))
 		}
-		return nil, err
+		sort.Strings(boosts)
+		q.Add("boost", strings.Join(boosts, ","))
 	}
-	// add response to an envelope
-	env := struct {
-		Data []*registry.Result
-		Meta struct {
-			Error  string
-			Status string
-			Code   int
-		}
-	}{}
+	return q
+}
 
-	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
-		return nil, err
+func (c Client) doJSONSearchReq(ctx context.Context, method string, s *registry.SearchParams) (results []*registry.Result, err error) {
+	switch method {
+	case "POST":
+		err = c.do(ctx, "POST", "/search", s, &results)
+	default:
+		err = c.do(ctx, method, "/search?"+searchQuery(s).Encode(), nil, &results)
 	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
+	if err != nil {
+		return nil, err
 	}
-	return env.Data, nil
+	return results, nil
 }
@@ -4,8 +4,10 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/registry"
 	"github.com/qri-io/registry/regserver/handlers"
+	"github.com/qri-io/registry/search"
 )
 
 func TestSearchRequests(t *testing.T) {
@@ -29,3 +31,107 @@ func TestSearchRequests(t *testing.T) {
 		t.Errorf("error executing search: %s", err)
 	}
 }
+
+func TestColumnSearchRequests(t *testing.T) {
+	idx := search.NewIndex()
+	ds := &registry.Dataset{
+		Handle: "b5",
+		Name:   "counties",
+		Structure: &dataset.Structure{Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": []interface{}{map[string]interface{}{"title": "fips_code", "type": "integer"}},
+			},
+		}},
+	}
+	if err := idx.IndexDatasets([]*registry.Dataset{ds}); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{Search: idx}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	res, err := c.Search(&SearchParams{Column: "fips_code", ColumnType: "integer"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 1 || res[0].ID != "b5/counties" {
+		t.Errorf("expected column search to find b5/counties, got: %v", res)
+	}
+
+	res, err = c.Search(&SearchParams{Column: "fips_code", ColumnType: "string"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 0 {
+		t.Errorf("expected mismatched column type to return no results, got: %d", len(res))
+	}
+}
+
+func TestSortedSearchRequests(t *testing.T) {
+	idx := search.NewIndex()
+	if err := idx.IndexDatasets([]*registry.Dataset{
+		{Handle: "b5", Name: "a", Meta: &dataset.Meta{Description: "census"}},
+		{Handle: "b5", Name: "b", Meta: &dataset.Meta{Title: "census"}},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{Search: idx}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	res, err := c.Search(&SearchParams{QueryString: "census", Boosts: map[string]float64{"description": 10}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 2 || res[0].ID != "b5/a" {
+		t.Errorf("expected description boost to rank b5/a first, got: %v", res)
+	}
+
+	res, err = c.Search(&SearchParams{QueryString: "census", Sort: "-name"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 2 || res[0].ID != "b5/b" {
+		t.Errorf("expected sort by descending name to rank b5/b first, got: %v", res)
+	}
+
+	if _, err := c.Search(&SearchParams{Sort: "size"}); err == nil {
+		t.Errorf("expected invalid sort to error")
+	}
+}
+
+func TestSearchSyntaxError(t *testing.T) {
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{Search: search.NewIndex()}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	_, err := c.Search(&SearchParams{QueryString: `census "population`})
+	expect := "error 400: query syntax error at position 8: unterminated phrase"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%v'", expect, err)
+	}
+}
+
+func TestSuggestRequests(t *testing.T) {
+	idx := search.NewIndex()
+	if err := idx.IndexDatasets([]*registry.Dataset{{Handle: "b5", Name: "cities"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{Suggester: idx}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	res, err := c.Suggest("b5/citeis", 5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 1 || res[0].ID != "b5/cities" || res[0].Distance != 2 {
+		t.Errorf("expected typo-tolerant suggestion for b5/cities, got: %v", res)
+	}
+}
@@ -0,0 +1,55 @@
+package regclient
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestDatasetStats(t *testing.T) {
+	srv := httptest.NewServer(handlers.NewRoutes(registry.Registry{
+		Profiles: registry.NewMemProfiles(),
+		Datasets: registry.NewMemDatasets(),
+		Stats:    registry.NewMemStats(),
+	}))
+	c := NewClient(&Config{
+		Location: srv.URL,
+	})
+
+	if err := c.PutProfile("b5", pk1); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, name := range []string{"cities", "towns"} {
+		ds := &dataset.Dataset{
+			Path:      "/ipfs/Qm" + name,
+			Commit:    &dataset.Commit{Timestamp: time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)},
+			Structure: &dataset.Structure{Checksum: "QmChecksum"},
+		}
+		if err := c.PutSignedDataset("b5", name, ds, pk1); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if _, err := c.GetDataset("b5", "towns", "", ""); err != nil {
+		t.Fatal(err.Error())
+	}
+	d, err := c.GetDataset("b5", "towns", "", "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if d.Stats == nil || d.Stats.Lookups != 2 {
+		t.Errorf("expected dataset response to include 2 lookups, got: %v", d.Stats)
+	}
+
+	trending, err := c.TrendingDatasets(7, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(trending) != 1 || trending[0].Name != "towns" {
+		t.Errorf("expected only looked-up dataset to be trending, got: %d datasets", len(trending))
+	}
+}
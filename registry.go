@@ -26,11 +26,16 @@ import "fmt"
 
 // Registry a collection of interfaces that together form a registry service
 type Registry struct {
-	Profiles    Profiles
-	Datasets    Datasets
-	Reputations Reputations
-	Search      Searchable
-	Indexer     Indexer
+	Profiles      Profiles
+	Organizations Organizations
+	Datasets      Datasets
+	Redirects     Redirects
+	Reputations   Reputations
+	Stats         Stats
+	Search        Searchable
+	Suggester     Suggester
+	Indexer       Indexer
+	Events        EventLog
 }
 
 // ErrPinsetNotSupported is a cannonical error for a repository that does not
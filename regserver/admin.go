@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/qri-io/registry"
+)
+
+// adminUsage describes regserver administrative subcommands
+const adminUsage = `usage: regserver [command] [flags]
+
+commands:
+  (none)      run the registry server
+  reindex     rebuild the search index of a running server from its datasets store
+  checkindex  report datasets missing from or stale in a running server's search index
+
+`
+
+// runAdminCommand executes an administrative subcommand against a running
+// registry server, returning the process exit code
+func runAdminCommand(cmd string, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	location := fs.String("registry", "http://localhost:3000", "location of the registry server")
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		username = adminUsername
+	}
+	fs.StringVar(&username, "admin-username", username, "admin username of the registry server, defaults to $ADMIN_USERNAME")
+	key := fs.String("key", os.Getenv("ADMIN_KEY"), "admin key of the registry server, defaults to $ADMIN_KEY")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	method := "GET"
+	if cmd == "reindex" {
+		method = "POST"
+	}
+
+	report, err := doIndexReq(method, *location, username, *key)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", cmd, err.Error())
+		return 1
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if report != nil {
+		enc.Encode(report)
+		if cmd == "checkindex" && !report.Consistent() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// doIndexReq calls the /admin/index endpoint of a registry server
+func doIndexReq(method, location, username, key string) (*registry.IndexReport, error) {
+	req, err := http.NewRequest(method, location+"/admin/index", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(username, key)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	env := struct {
+		Data *registry.IndexReport
+		Meta struct {
+			Error string
+			Code  int
+		}
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error %d: %s", res.StatusCode, env.Meta.Error)
+	}
+	return env.Data, nil
+}
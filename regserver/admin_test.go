@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminUsername(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "admin" || p != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"meta":{"code":401,"error":"unauthorized"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"checked":1},"meta":{"code":200}}`))
+	}))
+	defer s.Close()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if code := runAdminCommand("checkindex", []string{"-registry", s.URL, "-key", "key"}, stdout, stderr); code != 1 {
+		t.Errorf("expected the default username to be refused, got exit code %d", code)
+	}
+	args := []string{"-registry", s.URL, "-key", "key", "-admin-username", "admin"}
+	if code := runAdminCommand("checkindex", args, stdout, stderr); code != 0 {
+		t.Errorf("expected configured username to be accepted, got exit code %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/qri-io/registry/regserver/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+// Storage backends
+const (
+	// StorageMemory keeps all records in memory, they're lost on exit
+	StorageMemory = "memory"
+	// StorageSQLite stores profiles, datasets, organizations, redirects,
+	// reputations & pins in SQLite
+	StorageSQLite = "sqlite3"
+	// StoragePostgres stores profiles, datasets, organizations, redirects,
+	// reputations & pins in PostgreSQL
+	StoragePostgres = "postgres"
+)
+
+// Config configures regserver. Settings are read from an optional JSON or
+// YAML config file, then environment variables, then command line flags,
+// each overriding the last
+type Config struct {
+	// Port is the port the server listens on
+	Port string `json:"port"`
+	// AcceptLegacySignatures allows datasets signed with version 1 signatures
+	AcceptLegacySignatures bool `json:"acceptLegacySignatures"`
+
+	Log        LogConfig        `json:"log"`
+	Storage    StorageConfig    `json:"storage"`
+	Auth       AuthConfig       `json:"auth"`
+	TLS        TLSConfig        `json:"tls"`
+	Subsystems SubsystemsConfig `json:"subsystems"`
+	Dsync      DsyncConfig      `json:"dsync"`
+	Limits     LimitsConfig     `json:"limits"`
+	Timeouts   TimeoutsConfig   `json:"timeouts"`
+}
+
+// LogConfig configures server logging
+type LogConfig struct {
+	// Level is one of the logrus levels: debug, info, warn, error, etc.
+	Level string `json:"level"`
+	// Format is either "text" or "json"
+	Format string `json:"format"`
+}
+
+// StorageConfig selects where registry records are kept. Stats are always
+// kept in memory
+type StorageConfig struct {
+	// Backend is one of "memory", "sqlite3" or "postgres"
+	Backend string `json:"backend"`
+	// DSN is the data source name passed to the database driver, required
+	// for sql backends
+	DSN string `json:"dsn"`
+}
+
+// AuthConfig configures basic auth for administrative endpoints
+type AuthConfig struct {
+	Username string `json:"username"`
+	// Key is the admin password. If neither Key nor KeyFile is set a random
+	// key is generated & logged at startup
+	Key string `json:"key"`
+	// KeyFile is a path to a file containing the admin key
+	KeyFile string `json:"keyFile"`
+}
+
+// TLSConfig enables HTTPS, either with a certificate & key or a generated
+// self-signed certificate
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// SelfSigned generates a certificate for localhost at startup. It's
+	// intended for development only
+	SelfSigned bool `json:"selfSigned"`
+}
+
+// Enabled is true when TLS is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.SelfSigned
+}
+
+// SubsystemsConfig toggles optional registry features
+type SubsystemsConfig struct {
+	Search      bool `json:"search"`
+	Stats       bool `json:"stats"`
+	Reputations bool `json:"reputations"`
+	Pinset      bool `json:"pinset"`
+	Webhooks    bool `json:"webhooks"`
+	// Metrics serves prometheus metrics at /metrics
+	Metrics bool `json:"metrics"`
+	// Dsync accepts pushes of registered dataset DAGs at /dsync
+	Dsync bool `json:"dsync"`
+}
+
+// DsyncConfig configures the dsync remote. Pushed blocks are kept in memory,
+// they're lost on exit
+type DsyncConfig struct {
+	// RequireAllBlocks makes pushes send every block of a DAG instead of only
+	// the blocks the registry doesn't have
+	RequireAllBlocks bool `json:"requireAllBlocks"`
+}
+
+// LimitsConfig bounds the resources clients can use
+type LimitsConfig struct {
+	// MaxBodyBytes caps the size of request bodies, 0 means no limit
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	// MaxHeaderBytes caps the size of request headers, 0 uses the net/http
+	// default
+	MaxHeaderBytes int `json:"maxHeaderBytes"`
+	// PerIP rate limits requests from each client IP address
+	PerIP handlers.RateLimit `json:"perIP"`
+	// PerProfile rate limits requests from each profileID
+	PerProfile handlers.RateLimit `json:"perProfile"`
+	// TrustForwardedFor identifies clients by the X-Forwarded-For header
+	TrustForwardedFor bool `json:"trustForwardedFor"`
+	// Routes overrides limits for individual routes, keyed by route pattern.
+	// Unset fields inherit the limits above
+	Routes map[string]handlers.RouteLimits `json:"routes"`
+}
+
+// HandlerLimits converts limits config to route limits
+func (c LimitsConfig) HandlerLimits() handlers.Limits {
+	l := handlers.Limits{
+		Default: handlers.RouteLimits{
+			PerIP:        c.PerIP,
+			PerProfile:   c.PerProfile,
+			MaxBodyBytes: c.MaxBodyBytes,
+		},
+		Routes:            map[string]handlers.RouteLimits{},
+		TrustForwardedFor: c.TrustForwardedFor,
+	}
+	for pattern, rl := range c.Routes {
+		if rl.PerIP == (handlers.RateLimit{}) {
+			rl.PerIP = c.PerIP
+		}
+		if rl.PerProfile == (handlers.RateLimit{}) {
+			rl.PerProfile = c.PerProfile
+		}
+		if rl.MaxBodyBytes == 0 {
+			rl.MaxBodyBytes = c.MaxBodyBytes
+		}
+		l.Routes[pattern] = rl
+	}
+	return l
+}
+
+// TimeoutsConfig bounds how long the server waits on clients. Values are
+// duration strings like "30s" or "2m", zero means no timeout
+type TimeoutsConfig struct {
+	// ReadHeader is the time allowed to read request headers
+	ReadHeader Duration `json:"readHeader"`
+	// Read is the time allowed to read an entire request
+	Read Duration `json:"read"`
+	// Write is the time allowed to write a response, measured from the end
+	// of the request headers
+	Write Duration `json:"write"`
+	// Idle is how long keep-alive connections wait for the next request
+	Idle Duration `json:"idle"`
+	// Shutdown is how long to wait for in-flight requests, pin jobs &
+	// webhook deliveries to finish when the server is stopped
+	Shutdown Duration `json:"shutdown"`
+}
+
+// Duration is a time.Duration that encodes as a duration string
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durations must be strings like \"30s\"")
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+	return nil
+}
+
+// DefaultConfig returns the configuration regserver uses when nothing is
+// overridden
+func DefaultConfig() *Config {
+	return &Config{
+		Port:                   "3000",
+		AcceptLegacySignatures: true,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Storage: StorageConfig{
+			Backend: StorageMemory,
+		},
+		Auth: AuthConfig{
+			Username: adminUsername,
+		},
+		Subsystems: SubsystemsConfig{
+			Search:      true,
+			Stats:       true,
+			Reputations: true,
+			Pinset:      true,
+			Webhooks:    true,
+			Metrics:     true,
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes: 1 << 20,
+			PerIP:        handlers.RateLimit{Rate: 10, Burst: 50},
+			Routes: map[string]handlers.RouteLimits{
+				// profile registration & pinning are expensive
+				"/profile": {
+					PerIP:      handlers.RateLimit{Rate: 0.5, Burst: 10},
+					PerProfile: handlers.RateLimit{Rate: 0.1, Burst: 5},
+				},
+				"/pins": {
+					PerIP:      handlers.RateLimit{Rate: 1, Burst: 10},
+					PerProfile: handlers.RateLimit{Rate: 0.2, Burst: 5},
+				},
+				// dsync pushes send a request per block
+				"/dsync": {
+					PerIP:        handlers.RateLimit{Rate: 50, Burst: 200},
+					MaxBodyBytes: 2 << 20,
+				},
+			},
+		},
+		Timeouts: TimeoutsConfig{
+			ReadHeader: Duration(10 * time.Second),
+			Read:       Duration(time.Minute),
+			Write:      Duration(2 * time.Minute),
+			Idle:       Duration(2 * time.Minute),
+			Shutdown:   Duration(30 * time.Second),
+		},
+	}
+}
+
+// LoadConfig builds configuration from defaults, the config file named by
+// the -config flag or REGSERVER_CONFIG, environment variables & args, in
+// increasing order of precedence. getenv is usually os.Getenv
+func LoadConfig(args []string, getenv func(string) string) (*Config, error) {
+	// find the config file path before the file's values become flag defaults
+	pre := flag.NewFlagSet("regserver", flag.ContinueOnError)
+	pre.SetOutput(ioutil.Discard)
+	path := bindFlags(pre, &Config{}, getenv("REGSERVER_CONFIG"))
+	pre.Parse(args)
+
+	cfg := DefaultConfig()
+	if *path != "" {
+		if err := cfg.ReadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+
+	fs := flag.NewFlagSet("regserver", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), adminUsage)
+		fmt.Fprintln(fs.Output(), "flags:")
+		fs.PrintDefaults()
+	}
+	bindFlags(fs, cfg, *path)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	return cfg, nil
+}
+
+// bindFlags registers command line flags that write to cfg, using cfg's
+// current values as defaults. It returns the value of the -config flag
+func bindFlags(fs *flag.FlagSet, cfg *Config, configPath string) *string {
+	path := fs.String("config", configPath, "path to a json or yaml config file, env: REGSERVER_CONFIG")
+	fs.StringVar(&cfg.Port, "port", cfg.Port, "port to listen on, env: PORT")
+	fs.BoolVar(&cfg.AcceptLegacySignatures, "accept-legacy-signatures", cfg.AcceptLegacySignatures, "accept version 1 dataset signatures, env: ACCEPT_LEGACY_SIGNATURES")
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "log level, env: REGSERVER_LOG_LEVEL")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "log format, one of text, json. env: REGSERVER_LOG_FORMAT")
+	fs.StringVar(&cfg.Storage.Backend, "storage", cfg.Storage.Backend, "storage backend, one of memory, sqlite3, postgres. env: REGSERVER_STORAGE")
+	fs.StringVar(&cfg.Storage.DSN, "dsn", cfg.Storage.DSN, "database connection string for sql storage, env: REGSERVER_DSN")
+	fs.StringVar(&cfg.Auth.Username, "admin-username", cfg.Auth.Username, "basic auth username for admin endpoints, env: ADMIN_USERNAME")
+	fs.StringVar(&cfg.Auth.KeyFile, "admin-key-file", cfg.Auth.KeyFile, "file containing the admin key, env: ADMIN_KEY_FILE")
+	fs.StringVar(&cfg.TLS.CertFile, "tls-cert", cfg.TLS.CertFile, "TLS certificate file, env: REGSERVER_TLS_CERT")
+	fs.StringVar(&cfg.TLS.KeyFile, "tls-key", cfg.TLS.KeyFile, "TLS private key file, env: REGSERVER_TLS_KEY")
+	fs.BoolVar(&cfg.TLS.SelfSigned, "tls-self-signed", cfg.TLS.SelfSigned, "serve HTTPS with a generated self-signed certificate, for development only")
+	fs.BoolVar(&cfg.Subsystems.Search, "search", cfg.Subsystems.Search, "enable search & autocomplete, env: REGSERVER_SEARCH")
+	fs.BoolVar(&cfg.Subsystems.Stats, "stats", cfg.Subsystems.Stats, "enable dataset stats & trending, env: REGSERVER_STATS")
+	fs.BoolVar(&cfg.Subsystems.Reputations, "reputations", cfg.Subsystems.Reputations, "enable reputations, env: REGSERVER_REPUTATIONS")
+	fs.BoolVar(&cfg.Subsystems.Pinset, "pinset", cfg.Subsystems.Pinset, "enable pinning, env: REGSERVER_PINSET")
+	fs.BoolVar(&cfg.Subsystems.Webhooks, "webhooks", cfg.Subsystems.Webhooks, "enable webhooks, env: REGSERVER_WEBHOOKS")
+	fs.BoolVar(&cfg.Subsystems.Metrics, "metrics", cfg.Subsystems.Metrics, "serve prometheus metrics at /metrics, env: REGSERVER_METRICS")
+	fs.BoolVar(&cfg.Subsystems.Dsync, "dsync", cfg.Subsystems.Dsync, "accept pushes of registered dataset DAGs at /dsync, env: REGSERVER_DSYNC")
+	fs.BoolVar(&cfg.Dsync.RequireAllBlocks, "dsync-require-all-blocks", cfg.Dsync.RequireAllBlocks, "make dsync pushes send every block, env: REGSERVER_DSYNC_REQUIRE_ALL_BLOCKS")
+	fs.Int64Var(&cfg.Limits.MaxBodyBytes, "max-body-bytes", cfg.Limits.MaxBodyBytes, "maximum request body size, 0 for no limit. env: REGSERVER_MAX_BODY_BYTES")
+	fs.IntVar(&cfg.Limits.MaxHeaderBytes, "max-header-bytes", cfg.Limits.MaxHeaderBytes, "maximum request header size, 0 for the default. env: REGSERVER_MAX_HEADER_BYTES")
+	fs.Float64Var(&cfg.Limits.PerIP.Rate, "rate-per-ip", cfg.Limits.PerIP.Rate, "requests per second allowed from each IP address, 0 for no limit. env: REGSERVER_RATE_PER_IP")
+	fs.IntVar(&cfg.Limits.PerIP.Burst, "burst-per-ip", cfg.Limits.PerIP.Burst, "requests each IP address can make in a burst, env: REGSERVER_BURST_PER_IP")
+	fs.Float64Var(&cfg.Limits.PerProfile.Rate, "rate-per-profile", cfg.Limits.PerProfile.Rate, "requests per second allowed from each profileID, 0 for no limit. env: REGSERVER_RATE_PER_PROFILE")
+	fs.IntVar(&cfg.Limits.PerProfile.Burst, "burst-per-profile", cfg.Limits.PerProfile.Burst, "requests each profileID can make in a burst, env: REGSERVER_BURST_PER_PROFILE")
+	fs.BoolVar(&cfg.Limits.TrustForwardedFor, "trust-forwarded-for", cfg.Limits.TrustForwardedFor, "identify clients by the X-Forwarded-For header, env: REGSERVER_TRUST_FORWARDED_FOR")
+	fs.DurationVar((*time.Duration)(&cfg.Timeouts.Read), "read-timeout", time.Duration(cfg.Timeouts.Read), "time allowed to read a request")
+	fs.DurationVar((*time.Duration)(&cfg.Timeouts.Write), "write-timeout", time.Duration(cfg.Timeouts.Write), "time allowed to write a response")
+	fs.DurationVar((*time.Duration)(&cfg.Timeouts.Idle), "idle-timeout", time.Duration(cfg.Timeouts.Idle), "time keep-alive connections wait for the next request")
+	fs.DurationVar((*time.Duration)(&cfg.Timeouts.Shutdown), "shutdown-timeout", time.Duration(cfg.Timeouts.Shutdown), "time allowed to drain in-flight work on shutdown")
+	return path
+}
+
+// ReadFile loads a JSON or YAML config file over cfg. The format is chosen
+// by file extension. Unknown fields are an error
+func (cfg *Config) ReadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %s", err.Error())
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return fmt.Errorf("parsing config file %s: %s", path, err.Error())
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension '%s', use .json, .yaml or .yml", filepath.Ext(path))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parsing config file %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+// applyEnv overrides cfg with any set environment variables
+func (cfg *Config) applyEnv(getenv func(string) string) error {
+	strs := map[string]*string{
+		"PORT":                 &cfg.Port,
+		"REGSERVER_LOG_LEVEL":  &cfg.Log.Level,
+		"REGSERVER_LOG_FORMAT": &cfg.Log.Format,
+		"REGSERVER_STORAGE":    &cfg.Storage.Backend,
+		"REGSERVER_DSN":        &cfg.Storage.DSN,
+		"ADMIN_USERNAME":       &cfg.Auth.Username,
+		"ADMIN_KEY":            &cfg.Auth.Key,
+		"ADMIN_KEY_FILE":       &cfg.Auth.KeyFile,
+		"REGSERVER_TLS_CERT":   &cfg.TLS.CertFile,
+		"REGSERVER_TLS_KEY":    &cfg.TLS.KeyFile,
+	}
+	for name, s := range strs {
+		if v := getenv(name); v != "" {
+			*s = v
+		}
+	}
+
+	parsed := []struct {
+		name  string
+		parse func(v string) error
+	}{
+		{"ACCEPT_LEGACY_SIGNATURES", envBool(&cfg.AcceptLegacySignatures)},
+		{"REGSERVER_SEARCH", envBool(&cfg.Subsystems.Search)},
+		{"REGSERVER_STATS", envBool(&cfg.Subsystems.Stats)},
+		{"REGSERVER_REPUTATIONS", envBool(&cfg.Subsystems.Reputations)},
+		{"REGSERVER_PINSET", envBool(&cfg.Subsystems.Pinset)},
+		{"REGSERVER_WEBHOOKS", envBool(&cfg.Subsystems.Webhooks)},
+		{"REGSERVER_METRICS", envBool(&cfg.Subsystems.Metrics)},
+		{"REGSERVER_DSYNC", envBool(&cfg.Subsystems.Dsync)},
+		{"REGSERVER_DSYNC_REQUIRE_ALL_BLOCKS", envBool(&cfg.Dsync.RequireAllBlocks)},
+		{"REGSERVER_MAX_BODY_BYTES", func(v string) error {
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("expected an integer")
+			}
+			cfg.Limits.MaxBodyBytes = i
+			return nil
+		}},
+		{"REGSERVER_MAX_HEADER_BYTES", envInt(&cfg.Limits.MaxHeaderBytes)},
+		{"REGSERVER_RATE_PER_IP", envFloat(&cfg.Limits.PerIP.Rate)},
+		{"REGSERVER_BURST_PER_IP", envInt(&cfg.Limits.PerIP.Burst)},
+		{"REGSERVER_RATE_PER_PROFILE", envFloat(&cfg.Limits.PerProfile.Rate)},
+		{"REGSERVER_BURST_PER_PROFILE", envInt(&cfg.Limits.PerProfile.Burst)},
+		{"REGSERVER_TRUST_FORWARDED_FOR", envBool(&cfg.Limits.TrustForwardedFor)},
+	}
+	for _, p := range parsed {
+		if v := getenv(p.name); v != "" {
+			if err := p.parse(v); err != nil {
+				return fmt.Errorf("invalid %s value '%s', %s", p.name, v, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func envBool(dst *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func envInt(dst *int) func(string) error {
+	return func(v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		*dst = i
+		return nil
+	}
+}
+
+func envFloat(dst *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		*dst = f
+		return nil
+	}
+}
+
+// Validate checks configuration for errors, reporting all problems at once
+func (cfg *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		add("port: '%s' is not a valid port number", cfg.Port)
+	}
+
+	if _, err := logrus.ParseLevel(cfg.Log.Level); err != nil {
+		add("log.level: '%s' is not a valid log level", cfg.Log.Level)
+	}
+	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		add("log.format: '%s' must be one of text, json", cfg.Log.Format)
+	}
+
+	switch cfg.Storage.Backend {
+	case StorageMemory:
+		if cfg.Storage.DSN != "" {
+			add("storage.dsn: must be empty for memory storage")
+		}
+	case StorageSQLite, StoragePostgres:
+		if cfg.Storage.DSN == "" {
+			add("storage.dsn: required for %s storage", cfg.Storage.Backend)
+		}
+	default:
+		add("storage.backend: '%s' must be one of memory, sqlite3, postgres", cfg.Storage.Backend)
+	}
+
+	if cfg.Auth.Username == "" {
+		add("auth.username: required")
+	}
+	if cfg.Auth.Key != "" && cfg.Auth.KeyFile != "" {
+		add("auth: key and keyFile can't both be set")
+	}
+	if cfg.Auth.KeyFile != "" {
+		if _, err := os.Stat(cfg.Auth.KeyFile); err != nil {
+			add("auth.keyFile: %s", err.Error())
+		}
+	}
+
+	if cfg.TLS.SelfSigned {
+		if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+			add("tls: selfSigned can't be combined with certFile or keyFile")
+		}
+	} else if cfg.TLS.Enabled() {
+		if cfg.TLS.CertFile == "" || cfg.TLS.KeyFile == "" {
+			add("tls: certFile and keyFile must be set together")
+		}
+		for _, f := range []string{cfg.TLS.CertFile, cfg.TLS.KeyFile} {
+			if f == "" {
+				continue
+			}
+			if _, err := os.Stat(f); err != nil {
+				add("tls: %s", err.Error())
+			}
+		}
+	}
+
+	if cfg.Limits.MaxBodyBytes < 0 {
+		add("limits.maxBodyBytes: can't be negative")
+	}
+	if cfg.Limits.MaxHeaderBytes < 0 {
+		add("limits.maxHeaderBytes: can't be negative")
+	}
+	validateRate := func(field string, rl handlers.RateLimit) {
+		if rl.Rate < 0 || rl.Burst < 0 {
+			add("%s: rate and burst can't be negative", field)
+		}
+	}
+	validateRate("limits.perIP", cfg.Limits.PerIP)
+	validateRate("limits.perProfile", cfg.Limits.PerProfile)
+	for pattern, rl := range cfg.Limits.Routes {
+		if !strings.HasPrefix(pattern, "/") {
+			add("limits.routes: '%s' must be a route pattern starting with '/'", pattern)
+		}
+		validateRate(fmt.Sprintf("limits.routes[%s].perIP", pattern), rl.PerIP)
+		validateRate(fmt.Sprintf("limits.routes[%s].perProfile", pattern), rl.PerProfile)
+		if rl.MaxBodyBytes < 0 {
+			add("limits.routes[%s].maxBodyBytes: can't be negative", pattern)
+		}
+	}
+
+	timeouts := map[string]Duration{
+		"readHeader": cfg.Timeouts.ReadHeader,
+		"read":       cfg.Timeouts.Read,
+		"write":      cfg.Timeouts.Write,
+		"idle":       cfg.Timeouts.Idle,
+		"shutdown":   cfg.Timeouts.Shutdown,
+	}
+	for _, name := range []string{"readHeader", "read", "write", "idle", "shutdown"} {
+		if timeouts[name] < 0 {
+			add("timeouts.%s: can't be negative", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// AdminKey returns the configured admin key, reading KeyFile if set. The
+// returned key is empty if none is configured
+func (cfg *Config) AdminKey() (string, error) {
+	if cfg.Auth.KeyFile == "" {
+		return cfg.Auth.Key, nil
+	}
+	data, err := ioutil.ReadFile(cfg.Auth.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("reading admin key file: %s", err.Error())
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("admin key file %s is empty", cfg.Auth.KeyFile)
+	}
+	return key, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regserver_config")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	data := "port: \"4000\"\nlog:\n  level: debug\nstorage:\n  backend: sqlite3\n  dsn: registry.db\nsubsystems:\n  webhooks: false\ntimeouts:\n  idle: 90s\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	env := map[string]string{
+		"REGSERVER_CONFIG":         path,
+		"PORT":                     "5000",
+		"REGSERVER_LOG_LEVEL":      "warn",
+		"ACCEPT_LEGACY_SIGNATURES": "false",
+		"REGSERVER_DSYNC":          "true",
+		"REGSERVER_STATS":          "false",
+		"REGSERVER_MAX_BODY_BYTES": "2048",
+		"REGSERVER_RATE_PER_IP":    "2.5",
+		"REGSERVER_BURST_PER_IP":   "20",
+	}
+	cfg, err := LoadConfig([]string{"-port", "6000", "-search=false"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if cfg.Port != "6000" {
+		t.Errorf("expected flag to override port, got: %s", cfg.Port)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("expected env to override log level, got: %s", cfg.Log.Level)
+	}
+	if cfg.Storage.Backend != StorageSQLite || cfg.Storage.DSN != "registry.db" {
+		t.Errorf("expected storage from config file, got: %#v", cfg.Storage)
+	}
+	if cfg.AcceptLegacySignatures {
+		t.Error("expected env to disable legacy signatures")
+	}
+	if cfg.Subsystems.Webhooks || cfg.Subsystems.Search || !cfg.Subsystems.Pinset {
+		t.Errorf("unexpected subsystems: %#v", cfg.Subsystems)
+	}
+	if !cfg.Subsystems.Dsync || cfg.Subsystems.Stats {
+		t.Errorf("expected env to enable dsync & disable stats, got: %#v", cfg.Subsystems)
+	}
+	if cfg.Limits.MaxBodyBytes != 2048 || cfg.Limits.PerIP != (handlers.RateLimit{Rate: 2.5, Burst: 20}) {
+		t.Errorf("expected env to override limits, got: %#v", cfg.Limits)
+	}
+	if cfg.Timeouts.Idle != Duration(time.Second*90) {
+		t.Errorf("expected idle timeout from config file, got: %s", time.Duration(cfg.Timeouts.Idle))
+	}
+	if l := cfg.Limits.HandlerLimits().Route("/profile"); l.MaxBodyBytes != cfg.Limits.MaxBodyBytes || l.PerIP.Rate != 0.5 {
+		t.Errorf("expected /profile limits to override rate & inherit body size, got: %#v", l)
+	}
+	if cfg.Log.Format != "json" {
+		t.Errorf("expected default log format, got: %s", cfg.Log.Format)
+	}
+
+	env["REGSERVER_BURST_PER_IP"] = "lots"
+	if _, err := LoadConfig(nil, func(k string) string { return env[k] }); err == nil || err.Error() != "invalid REGSERVER_BURST_PER_IP value 'lots', expected an integer" {
+		t.Errorf("expected invalid burst error, got: %v", err)
+	}
+	delete(env, "REGSERVER_BURST_PER_IP")
+
+	if err := ioutil.WriteFile(path, []byte("prot: 4000\n"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := LoadConfig(nil, func(k string) string { return env[k] }); err == nil || !strings.Contains(err.Error(), `unknown field "prot"`) {
+		t.Errorf("expected unknown field error, got: %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected default config to be valid, got: %s", err.Error())
+	}
+
+	cfg.Port = "0"
+	cfg.Log.Format = "xml"
+	cfg.Storage.Backend = StoragePostgres
+	cfg.TLS.CertFile = "cert.pem"
+	cfg.Timeouts.Write = -1
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, problem := range []string{
+		"port: '0' is not a valid port number",
+		"log.format: 'xml' must be one of text, json",
+		"storage.dsn: required for postgres storage",
+		"tls: certFile and keyFile must be set together",
+		"timeouts.write: can't be negative",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected error to report '%s', got: %s", problem, err.Error())
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	// merkledag registers decoders for protobuf, raw & cbor blocks
+	_ "github.com/ipfs/go-merkledag"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/qri-io/dag"
+	"github.com/qri-io/dag/dsync"
+	"github.com/qri-io/registry"
+)
+
+// newDsync creates a dsync remote that keeps blocks in memory. It only
+// accepts pushes of DAGs whose root is the path of a registered dataset
+func newDsync(cfg DsyncConfig, datasets registry.Datasets) (*dsync.Dsync, error) {
+	blks := newMemBlocks()
+	return dsync.New(blks, memBlockAPI{blks}, func(c *dsync.Config) {
+		c.PinAPI = newMemPins()
+		c.RequireAllBlocks = cfg.RequireAllBlocks
+		c.PushPreCheck = func(ctx context.Context, info dag.Info, meta map[string]string) error {
+			if info.Manifest == nil || len(info.Manifest.Nodes) == 0 {
+				return fmt.Errorf("manifest is required")
+			}
+			root := info.RootCID().String()
+			if _, ok := datasets.LoadByPath("/ipfs/" + root); ok {
+				return nil
+			}
+			if _, ok := datasets.LoadByPath(root); ok {
+				return nil
+			}
+			return fmt.Errorf("'%s' is not the path of a registered dataset", root)
+		}
+	})
+}
+
+// pathCid parses the cid of a bare or /ipfs/ prefixed path
+func pathCid(p path.Path) (cid.Cid, error) {
+	return cid.Decode(strings.TrimPrefix(p.String(), "/ipfs/"))
+}
+
+// memBlocks is an in-memory ipld.NodeGetter. Blocks are never evicted
+type memBlocks struct {
+	sync.RWMutex
+	blocks map[string]blocks.Block
+}
+
+func newMemBlocks() *memBlocks {
+	return &memBlocks{blocks: map[string]blocks.Block{}}
+}
+
+// compile-time assertion that memBlocks is a NodeGetter
+var _ ipld.NodeGetter = (*memBlocks)(nil)
+
+func (m *memBlocks) block(id cid.Cid) (blocks.Block, error) {
+	m.RLock()
+	defer m.RUnlock()
+	blk, ok := m.blocks[id.KeyString()]
+	if !ok {
+		return nil, ipld.ErrNotFound
+	}
+	return blk, nil
+}
+
+// Get implements ipld.NodeGetter
+func (m *memBlocks) Get(ctx context.Context, id cid.Cid) (ipld.Node, error) {
+	blk, err := m.block(id)
+	if err != nil {
+		return nil, err
+	}
+	return ipld.Decode(blk)
+}
+
+// GetMany implements ipld.NodeGetter
+func (m *memBlocks) GetMany(ctx context.Context, ids []cid.Cid) <-chan *ipld.NodeOption {
+	out := make(chan *ipld.NodeOption, len(ids))
+	for _, id := range ids {
+		nd, err := m.Get(ctx, id)
+		out <- &ipld.NodeOption{Node: nd, Err: err}
+	}
+	close(out)
+	return out
+}
+
+// memBlockAPI adds & fetches raw blocks in memBlocks
+type memBlockAPI struct {
+	*memBlocks
+}
+
+// compile-time assertion that memBlockAPI is a BlockAPI
+var _ coreiface.BlockAPI = memBlockAPI{}
+
+// Put implements coreiface.BlockAPI
+func (m memBlockAPI) Put(ctx context.Context, r io.Reader, opts ...options.BlockPutOption) (coreiface.BlockStat, error) {
+	_, prefix, err := options.BlockPutOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := prefix.Sum(data)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := blocks.NewBlockWithCid(data, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	m.blocks[id.KeyString()] = blk
+	m.Unlock()
+	return blockStat{size: len(data), path: path.IpfsPath(id)}, nil
+}
+
+// Get implements coreiface.BlockAPI
+func (m memBlockAPI) Get(ctx context.Context, p path.Path) (io.Reader, error) {
+	id, err := pathCid(p)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := m.block(id)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(blk.RawData()), nil
+}
+
+// Rm implements coreiface.BlockAPI
+func (m memBlockAPI) Rm(ctx context.Context, p path.Path, opts ...options.BlockRmOption) error {
+	id, err := pathCid(p)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.blocks[id.KeyString()]; !ok {
+		return ipld.ErrNotFound
+	}
+	delete(m.blocks, id.KeyString())
+	return nil
+}
+
+// Stat implements coreiface.BlockAPI
+func (m memBlockAPI) Stat(ctx context.Context, p path.Path) (coreiface.BlockStat, error) {
+	id, err := pathCid(p)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := m.block(id)
+	if err != nil {
+		return nil, err
+	}
+	return blockStat{size: len(blk.RawData()), path: path.IpfsPath(id)}, nil
+}
+
+type blockStat struct {
+	size int
+	path path.Resolved
+}
+
+func (s blockStat) Size() int           { return s.size }
+func (s blockStat) Path() path.Resolved { return s.path }
+
+// memPins records pushed DAG roots dsync is asked to pin. memBlocks never
+// evicts blocks, so pins only track what clients have asked to keep
+type memPins struct {
+	sync.RWMutex
+	pins map[string]cid.Cid
+}
+
+func newMemPins() *memPins {
+	return &memPins{pins: map[string]cid.Cid{}}
+}
+
+// compile-time assertion that memPins is a PinAPI
+var _ coreiface.PinAPI = (*memPins)(nil)
+
+// Add implements coreiface.PinAPI
+func (m *memPins) Add(ctx context.Context, p path.Path, opts ...options.PinAddOption) error {
+	id, err := pathCid(p)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	m.pins[id.KeyString()] = id
+	m.Unlock()
+	return nil
+}
+
+// Ls implements coreiface.PinAPI, listing pins in path order
+func (m *memPins) Ls(ctx context.Context, opts ...options.PinLsOption) ([]coreiface.Pin, error) {
+	m.RLock()
+	defer m.RUnlock()
+	pins := make([]coreiface.Pin, 0, len(m.pins))
+	for _, id := range m.pins {
+		pins = append(pins, pin{path.IpfsPath(id)})
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].Path().String() < pins[j].Path().String()
+	})
+	return pins, nil
+}
+
+// Rm implements coreiface.PinAPI
+func (m *memPins) Rm(ctx context.Context, p path.Path, opts ...options.PinRmOption) error {
+	id, err := pathCid(p)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.pins[id.KeyString()]; !ok {
+		return fmt.Errorf("'%s' is not pinned", id.String())
+	}
+	delete(m.pins, id.KeyString())
+	return nil
+}
+
+// Update implements coreiface.PinAPI
+func (m *memPins) Update(ctx context.Context, from path.Path, to path.Path, opts ...options.PinUpdateOption) error {
+	return fmt.Errorf("updating pins is not supported")
+}
+
+// Verify implements coreiface.PinAPI
+func (m *memPins) Verify(context.Context) (<-chan coreiface.PinStatus, error) {
+	return nil, fmt.Errorf("verifying pins is not supported")
+}
+
+type pin struct {
+	path path.Resolved
+}
+
+func (p pin) Path() path.Resolved { return p.path }
+func (p pin) Type() string        { return "recursive" }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	merkledag "github.com/ipfs/go-merkledag"
+	"github.com/qri-io/dag"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/regclient"
+	"github.com/qri-io/registry/regserver/handlers"
+)
+
+func TestDsyncPush(t *testing.T) {
+	ctx := context.Background()
+
+	// build a two-block DAG on the client side
+	src := newMemBlocks()
+	child := merkledag.NodeWithData([]byte("child"))
+	root := merkledag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("child", child); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, nd := range []*merkledag.ProtoNode{child, root} {
+		if _, err := (memBlockAPI{src}).Put(ctx, bytes.NewReader(nd.RawData())); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	mfst, err := dag.NewManifest(ctx, src, root.Cid())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	reg := registry.Registry{
+		Profiles: registry.NewMemProfiles(),
+		Datasets: registry.NewMemDatasets(),
+	}
+	d, err := newDsync(DsyncConfig{}, reg.Datasets)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s := httptest.NewServer(handlers.NewRoutes(reg, handlers.AddDsync(d)))
+	defer s.Close()
+	c := regclient.NewClient(&regclient.Config{Location: s.URL})
+
+	err = c.DsyncSend(ctx, src, mfst)
+	if err == nil || !strings.Contains(err.Error(), "is not the path of a registered dataset") {
+		t.Errorf("expected push of an unregistered DAG to be rejected, got: %v", err)
+	}
+
+	reg.Datasets.Store("peer/cities", &registry.Dataset{Handle: "peer", Name: "cities", Path: "/ipfs/" + root.Cid().String()})
+	if err := c.DsyncSend(ctx, src, mfst); err != nil {
+		t.Fatalf("pushing a registered DAG: %s", err.Error())
+	}
+
+	dst := newMemBlocks()
+	if err := c.DsyncFetch(ctx, root.Cid().String(), dst, memBlockAPI{dst}); err != nil {
+		t.Fatalf("fetching a pushed DAG: %s", err.Error())
+	}
+	nd, err := dst.Get(ctx, child.Cid())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(nd.RawData(), child.RawData()) {
+		t.Errorf("fetched block doesn't match pushed block")
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the ID of a request. Clients may set it to
+// correlate their logs with the registry's, otherwise an ID is generated.
+// The ID is always echoed in the response
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID restricts client-provided request IDs to short, log-safe
+// strings
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type ctxKey int
+
+const requestIDKey ctxKey = iota
+
+// RequestID returns the ID of the request a context belongs to, or an empty
+// string if there isn't one
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// requestLog returns a logger that tags lines with the request's ID
+func requestLog(r *http.Request) *logrus.Entry {
+	return log.WithField("request_id", RequestID(r.Context()))
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// accessLog assigns each request an ID & logs a structured line for each
+// completed request. route is the pattern the handler is registered under.
+// metrics may be nil
+func accessLog(route string, metrics *Metrics) func(h http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey, id))
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			h(rec, r)
+			elapsed := time.Since(start)
+
+			if metrics != nil {
+				metrics.observeRequest(route, r.Method, rec.status, elapsed)
+			}
+			log.WithFields(logrus.Fields{
+				"request_id":  id,
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"route":       route,
+				"status":      rec.status,
+				"bytes":       rec.bytes,
+				"duration_ms": float64(elapsed) / float64(time.Millisecond),
+				"remote_addr": r.RemoteAddr,
+			}).Info("request")
+		}
+	}
+}
+
+// statusRecorder captures the status code & size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code & passes it on
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts bytes written to the response
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer does
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
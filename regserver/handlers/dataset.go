@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/qri-io/apiutil"
 	"github.com/qri-io/registry"
@@ -16,34 +16,26 @@ import (
 const DefaultLimit = 25
 
 // NewDatasetsHandler creates a datasets handler function that operates
-// on a *registry.Datasets
-func NewDatasetsHandler(datasets registry.Datasets, idxr registry.Indexer) http.HandlerFunc {
+// on a *registry.Datasets. opts supply the event log for stored datasets
+func NewDatasetsHandler(datasets registry.Datasets, idxr registry.Indexer, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
+	uow := registry.NewUnitOfWork(datasets, idxr, opts...)
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
 			ps := []*registry.Dataset{}
-			switch r.Header.Get("Content-Type") {
-			case "application/json":
-				if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
-					apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-					return
-				}
-			default:
-				err := fmt.Errorf("Content-Type must be application/json")
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			if !readJSON(w, r, &ps) {
 				return
 			}
 
-			for _, pro := range ps {
-				datasets.Store(pro.Handle, pro)
-			}
-
-			// add datasets to search index if it's defined
-			if idxr != nil {
-				if err := idxr.IndexDatasets(ps); err != nil {
-					apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
-					return
+			err := uow.Apply(registry.EventDatasetsStored, func(datasets registry.Datasets, _ ...func(o *registry.RegisterOptions)) error {
+				for _, pro := range ps {
+					datasets.Store(pro.Key(), pro)
 				}
+				return nil
+			})
+			if err != nil {
+				writeErr(w, r, err)
+				return
 			}
 
 			fallthrough
@@ -61,42 +53,50 @@ func NewDatasetsHandler(datasets registry.Datasets, idxr registry.Indexer) http.
 				if len(ds) == limit {
 					return true
 				}
+				// previews are served individually from /dataset/<ref>/preview
+				if d.Preview != nil {
+					cp := *d
+					cp.Preview = nil
+					d = &cp
+				}
 				ds = append(ds, d)
 				return false
 			})
 
 			apiutil.WriteResponse(w, ds)
+		default:
+			methodNotAllowed(w, r, "GET", "POST")
 		}
 	}
 }
 
 // NewDatasetHandler creates a dataset handler func that operats on
-// a *registry.Datasets
-func NewDatasetHandler(datasets registry.Datasets, idxr registry.Indexer) http.HandlerFunc {
+// a *registry.Datasets. opts are passed along to registration calls
+func NewDatasetHandler(datasets registry.Datasets, idxr registry.Indexer, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
+	o := &registry.RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	uow := registry.NewUnitOfWork(datasets, idxr, opts...)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		p := &registry.Dataset{}
-		switch r.Header.Get("Content-Type") {
-		case "application/json":
-			if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-				return
-			}
-		default:
-			if r.Method != "GET" {
-				err := fmt.Errorf("Content-Type must be application/json")
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-				return
-			}
-		}
-
 		switch r.Method {
 		case "GET":
-			if !strings.HasPrefix(r.URL.Path, "/dataset/") {
-				err := fmt.Errorf("no reference provided")
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			refstr := pathSuffix(r, "/dataset/")
+			if refstr == "" {
+				refstr = r.FormValue("ref")
+			}
+			if refstr == "" {
+				writeErr(w, r, registry.NewError(registry.ErrInvalid, "no reference provided"))
 				return
 			}
-			path := ns.HTTPPathToQriPath(r.URL.Path[len("/dataset/"):])
+			preview := false
+			if trimmed := strings.TrimSuffix(refstr, "/preview"); trimmed != refstr && strings.Contains(trimmed, "/") {
+				refstr = trimmed
+				preview = true
+			}
+			path := ns.HTTPPathToQriPath(refstr)
 			ref, err := ns.ParseRef(path)
 			if err != nil {
 				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
@@ -104,52 +104,85 @@ func NewDatasetHandler(datasets registry.Datasets, idxr registry.Indexer) http.H
 			}
 
 			if ref.IsEmpty() {
-				err := fmt.Errorf("no reference provided")
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+				writeErr(w, r, registry.NewError(registry.ErrInvalid, "no reference provided"))
 				return
 			}
 
-			var ok bool
-			datasets.Range(func(key string, ds *registry.Dataset) bool {
-				if ds.Path == ref.Path || (ref.Name == ds.Name && ref.Peername == ds.Handle) {
-					*p = *ds
-					ok = true
-					return true
-				}
-				return false
-			})
-
+			ds, ok := lookupDataset(datasets, ref)
 			if !ok {
-				apiutil.NotFoundHandler(w, r)
+				// point requests for moved datasets at their new location
+				if o.Redirects != nil && ref.Name != "" {
+					if to, ok := registry.ResolveRedirect(o.Redirects, fmt.Sprintf("%s/%s", ref.Peername, ref.Name)); ok {
+						loc := "/dataset/" + to
+						if strings.HasPrefix(r.RequestURI, APIPrefix+"/") {
+							loc = APIPrefix + loc
+						}
+						if preview {
+							loc += "/preview"
+						}
+						http.Redirect(w, r, loc, http.StatusMovedPermanently)
+						return
+					}
+				}
+				writeErr(w, r, registry.NewError(registry.ErrNotFound, "dataset not found"))
+				return
+			}
+			if preview {
+				if ds.Preview == nil {
+					writeErr(w, r, registry.NewError(registry.ErrNotFound, "dataset has no preview"))
+					return
+				}
+				apiutil.WriteResponse(w, ds.Preview)
 				return
 			}
+			*p = *ds
+			// previews are served individually from /dataset/<ref>/preview
+			p.Preview = nil
+			if o.Stats != nil {
+				o.Stats.Record(ds.Key(), registry.StatLookup, time.Now())
+				p.Stats, _ = o.Stats.Load(ds.Key())
+			}
 		case "PUT", "POST":
-			if err := registry.RegisterDataset(datasets, p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			if !readJSON(w, r, p) {
 				return
 			}
-			if idxr != nil {
-				if err := idxr.IndexDatasets([]*registry.Dataset{p}); err != nil {
-					apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-					return
-				}
+			err := uow.Apply(registry.EventDatasetRegistered, func(datasets registry.Datasets, opts ...func(o *registry.RegisterOptions)) error {
+				return registry.RegisterDataset(datasets, p, opts...)
+			})
+			if err != nil {
+				writeErr(w, r, err)
+				return
 			}
 		case "DELETE":
-			if err := registry.DeregisterDataset(datasets, p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			if !readJSON(w, r, p) {
 				return
 			}
-			if idxr != nil {
-				if err := idxr.UnindexDatasets([]*registry.Dataset{p}); err != nil {
-					apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-					return
-				}
+			err := uow.Apply(registry.EventDatasetDeregistered, func(datasets registry.Datasets, opts ...func(o *registry.RegisterOptions)) error {
+				return registry.DeregisterDataset(datasets, p, opts...)
+			})
+			if err != nil {
+				writeErr(w, r, err)
+				return
 			}
 		default:
-			apiutil.NotFoundHandler(w, r)
+			methodNotAllowed(w, r, "GET", "PUT", "POST", "DELETE")
 			return
 		}
 
 		apiutil.WriteResponse(w, p)
 	}
 }
+
+// lookupDataset finds a dataset by path if one is provided, falling back to
+// loading by handle & name
+func lookupDataset(datasets registry.Datasets, ref ns.Ref) (*registry.Dataset, bool) {
+	if ref.Path != "" {
+		if ds, ok := datasets.LoadByPath(ref.Path); ok {
+			return ds, true
+		}
+	}
+	if ref.Name != "" {
+		return datasets.Load(ref.Peername + "/" + ref.Name)
+	}
+	return nil, false
+}
@@ -18,13 +18,18 @@ import (
 var nilSearch registry.NilSearch
 
 func TestDataset(t *testing.T) {
-	s := httptest.NewServer(NewRoutes(registry.Registry{Profiles: registry.NewMemProfiles(), Datasets: registry.NewMemDatasets()}))
+	ps := registry.NewMemProfiles()
+	s := httptest.NewServer(NewRoutes(registry.Registry{Profiles: ps, Datasets: registry.NewMemDatasets()}))
 
 	b5, err := registry.ProfileFromPrivateKey("b5", privKey1)
 	if err != nil {
 		t.Errorf("error generating profile: %s", err.Error())
 		return
 	}
+	if err := registry.RegisterProfile(ps, b5); err != nil {
+		t.Errorf("error registering profile: %s", err.Error())
+		return
+	}
 
 	data, err := ioutil.ReadFile("testdata/cities.dataset.json")
 	if err != nil {
@@ -58,10 +63,10 @@ func TestDataset(t *testing.T) {
 		resStatus   int
 		res         *env
 	}{
-		{"OPTIONS", "", nil, http.StatusBadRequest, nil},
-		{"OPTIONS", "application/json", nil, http.StatusBadRequest, nil},
-		{"OPTIONS", "application/json", &registry.Dataset{Handle: "foo"}, http.StatusNotFound, nil},
-		{"POST", "", nil, http.StatusBadRequest, nil},
+		{"OPTIONS", "", nil, http.StatusMethodNotAllowed, nil},
+		{"OPTIONS", "application/json", nil, http.StatusMethodNotAllowed, nil},
+		{"OPTIONS", "application/json", &registry.Dataset{Handle: "foo"}, http.StatusMethodNotAllowed, nil},
+		{"POST", "", nil, http.StatusUnsupportedMediaType, nil},
 		{"POST", "application/json", nil, http.StatusBadRequest, nil},
 		{"POST", "application/json", &registry.Dataset{Handle: b5.Handle}, http.StatusBadRequest, nil},
 		{"POST", "application/json", ds, http.StatusOK, nil},
@@ -272,3 +277,28 @@ func TestPostDataset(t *testing.T) {
 	// 	}
 
 }
+
+// brokenIndex is an Indexer that always fails
+type brokenIndex bool
+
+func (brokenIndex) IndexDatasets([]*registry.Dataset) error   { return fmt.Errorf("index unavailable") }
+func (brokenIndex) UnindexDatasets([]*registry.Dataset) error { return fmt.Errorf("index unavailable") }
+
+func TestDatasetsIndexFailure(t *testing.T) {
+	datasets := registry.NewMemDatasets()
+	s := httptest.NewServer(NewDatasetsHandler(datasets, brokenIndex(true)))
+	defer s.Close()
+
+	body := `[{"handle":"b5","name":"cities","path":"/ipfs/QmCities"}]`
+	res, err := http.Post(s.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got: %d", http.StatusInternalServerError, res.StatusCode)
+	}
+	if datasets.Len() != 0 {
+		t.Errorf("expected failed commit to leave store empty, got %d datasets", datasets.Len())
+	}
+}
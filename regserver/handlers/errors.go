@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+// errJSONRequired is the response to requests without a JSON body
+var errJSONRequired = fmt.Errorf("Content-Type must be application/json")
+
+// statusCode gives the HTTP status for an error by its registry error kind.
+// errors without a kind are the server's fault
+func statusCode(err error) int {
+	switch registry.ErrorKind(err) {
+	case registry.ErrNotFound:
+		return http.StatusNotFound
+	case registry.ErrConflict:
+		return http.StatusConflict
+	case registry.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case registry.ErrInvalid:
+		return http.StatusBadRequest
+	}
+	switch err {
+	case pinset.ErrClosed:
+		return http.StatusServiceUnavailable
+	case registry.ErrSearchNotSupported:
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// writeErr writes an error response with the status code for err, logging
+// server errors
+func writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	code := statusCode(err)
+	if code >= http.StatusInternalServerError {
+		requestLog(r).Errorf("%s %s: %s", r.Method, r.URL.Path, err.Error())
+	}
+	apiutil.WriteErrResponse(w, code, err)
+}
+
+// methodNotAllowed responds 405 Method Not Allowed, listing allowed methods
+// in the Allow header
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	apiutil.WriteErrResponse(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+}
+
+// isJSON reports whether a request has a JSON body
+func isJSON(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mt == "application/json"
+}
+
+// readJSON decodes a JSON request body into v. If the body isn't JSON or is
+// too large readJSON writes an error response & returns false
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if !isJSON(r) {
+		apiutil.WriteErrResponse(w, http.StatusUnsupportedMediaType, errJSONRequired)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if bodyTooLarge(err) {
+			apiutil.WriteErrResponse(w, http.StatusRequestEntityTooLarge, err)
+			return false
+		}
+		apiutil.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %s", err.Error()))
+		return false
+	}
+	return true
+}
+
+// bodyTooLarge reports whether err is from reading a request body past the
+// limit set by http.MaxBytesReader. bodies without a Content-Length, like
+// chunked requests, are only caught while reading
+func bodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
+
+// readJSONQuery decodes a JSON request body into v if the request has one.
+// GET lookups take query or path params, older clients send a JSON body
+func readJSONQuery(r *http.Request, v interface{}) error {
+	if !isJSON(r) || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return registry.NewError(registry.ErrInvalid, "invalid request body: %s", err.Error())
+	}
+	return nil
+}
+
+// pathSuffix gives the part of the request path after prefix, eg: the
+// handle in /profile/<handle>
+func pathSuffix(r *http.Request, prefix string) string {
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		return ""
+	}
+	return strings.Trim(r.URL.Path[len(prefix):], "/")
+}
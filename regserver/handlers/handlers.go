@@ -2,12 +2,14 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
-	"time"
+	"sync"
 
 	"github.com/qri-io/dag/dsync"
 	"github.com/qri-io/registry"
 	"github.com/qri-io/registry/pinset"
+	"github.com/qri-io/registry/webhook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,11 +28,29 @@ func SetLogLevel(level string) error {
 	return nil
 }
 
+// SetLogFormat chooses between "text" & "json" formatted handler logs
+func SetLogFormat(format string) error {
+	switch format {
+	case "text":
+		log.SetFormatter(&logrus.TextFormatter{})
+	case "json":
+		log.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format: '%s'", format)
+	}
+	return nil
+}
+
 // RouteOptions defines configuration details for NewRoutes
 type RouteOptions struct {
 	Protector MethodProtector
 	Pinset    pinset.Pinset
 	Dsync     *dsync.Dsync
+	Webhooks  *webhook.Dispatcher
+	Limits    *Limits
+	Metrics   *Metrics
+	// HealthChecks are added to the default readiness checks
+	HealthChecks []Component
 }
 
 // AddPinset creates a configuration func for passing to NewRoutes
@@ -47,6 +67,13 @@ func AddDsync(rec *dsync.Dsync) func(o *RouteOptions) {
 	}
 }
 
+// AddWebhooks creates a configuration func for passing to NewRoutes
+func AddWebhooks(d *webhook.Dispatcher) func(o *RouteOptions) {
+	return func(o *RouteOptions) {
+		o.Webhooks = d
+	}
+}
+
 // AddProtector creates a configuration func for passing to NewRoutes
 func AddProtector(p MethodProtector) func(o *RouteOptions) {
 	return func(o *RouteOptions) {
@@ -63,48 +90,157 @@ func NewRoutes(reg registry.Registry, opts ...func(o *RouteOptions)) *http.Serve
 		opt(o)
 	}
 
+	// observe & check the pinset before it's wrapped, so it's job count &
+	// shutdown stay visible
+	if o.Metrics != nil {
+		o.Metrics.observeStores(reg.Profiles, reg.Datasets, o.Pinset)
+	}
+	components := append(registryComponents(reg, o.Pinset, o.Dsync != nil), o.HealthChecks...)
+
+	if o.Webhooks != nil {
+		// wrap stores so changes publish events to webhook subscribers
+		if reg.Profiles != nil {
+			reg.Profiles = webhook.Profiles{Profiles: reg.Profiles, Dispatcher: o.Webhooks}
+		}
+		if reg.Datasets != nil {
+			// publish once dataset changes are committed, so rolled back
+			// changes never reach subscribers
+			reg.Events = webhook.Events{EventLog: reg.Events, Dispatcher: o.Webhooks}
+		}
+		if o.Pinset != nil {
+			o.Pinset = webhook.Pinset{Pinset: o.Pinset, Dispatcher: o.Webhooks}
+		}
+	}
+
+	if reg.Stats != nil && reg.Datasets != nil && o.Pinset != nil {
+		o.Pinset = statsPinset{Pinset: o.Pinset, datasets: reg.Datasets, stats: reg.Stats}
+	}
+
+	// dataset & index handlers share a lock so changes, rollbacks & reindexing
+	// don't interleave
+	lock := &sync.Mutex{}
+	regOpts := []func(o *registry.RegisterOptions){registry.WithLock(lock)}
+	if reg.Profiles != nil {
+		regOpts = append(regOpts, registry.WithProfiles(reg.Profiles))
+	}
+	if reg.Organizations != nil {
+		regOpts = append(regOpts, registry.WithOrganizations(reg.Organizations))
+	}
+	if reg.Redirects != nil {
+		regOpts = append(regOpts, registry.WithRedirects(reg.Redirects))
+	}
+	if reg.Stats != nil {
+		regOpts = append(regOpts, registry.WithStats(reg.Stats))
+	}
+	if reg.Events != nil {
+		regOpts = append(regOpts, registry.WithEvents(reg.Events))
+	}
+
 	pro := o.Protector
 	m := http.NewServeMux()
-	m.HandleFunc("/", HealthCheckHandler)
+	var routes []string
+	// register serves h on pattern under APIPrefix & as an unprefixed alias.
+	// both share limits, so aliases don't double a client's allowance
+	register := func(pattern string, h http.HandlerFunc) {
+		routes = append(routes, pattern)
+		if o.Limits != nil {
+			h = NewLimiter(o.Limits.Route(pattern), o.Limits.TrustForwardedFor)(h)
+		}
+		m.HandleFunc(pattern, accessLog(pattern, o.Metrics)(h))
+		m.HandleFunc(APIPrefix+pattern, accessLog(APIPrefix+pattern, o.Metrics)(http.StripPrefix(APIPrefix, h).ServeHTTP))
+	}
+	// handle registers a handler that writes JSON response envelopes
+	handle := func(pattern string, h http.HandlerFunc) {
+		register(pattern, jsonContentType(h))
+	}
+	handle("/", HealthCheckHandler)
+	handle("/healthz", NewLivenessHandler())
+	handle("/readyz", NewReadinessHandler(components))
 
 	if ps := reg.Profiles; ps != nil {
-		m.HandleFunc("/profile", logReq(NewProfileHandler(ps)))
-		m.HandleFunc("/profiles", pro.ProtectMethods("POST")(logReq(NewProfilesHandler(ps))))
+		handle("/profile", NewProfileHandler(ps, regOpts...))
+		handle("/profile/", NewProfileHandler(ps, regOpts...))
+		handle("/profiles", pro.ProtectMethods("POST")(NewProfilesHandler(ps)))
+	}
+
+	if orgs := reg.Organizations; orgs != nil {
+		handle("/organization", NewOrganizationHandler(orgs, reg.Profiles))
+		handle("/organization/", NewOrganizationHandler(orgs, reg.Profiles))
+		handle("/organization/members", NewMembershipHandler(orgs))
 	}
 
 	if ds := reg.Datasets; ds != nil {
-		m.HandleFunc("/dataset", logReq(NewDatasetHandler(ds, reg.Indexer)))
-		m.HandleFunc("/dataset/", logReq(NewDatasetHandler(ds, reg.Indexer)))
-		m.HandleFunc("/datasets", pro.ProtectMethods("POST")(logReq(NewDatasetsHandler(ds, reg.Indexer))))
+		handle("/dataset", NewDatasetHandler(ds, reg.Indexer, regOpts...))
+		handle("/dataset/", NewDatasetHandler(ds, reg.Indexer, regOpts...))
+		handle("/dataset/move", NewDatasetMoveHandler(ds, reg.Indexer, regOpts...))
+		handle("/dataset/deprecation", NewDeprecationHandler(ds, reg.Indexer, regOpts...))
+		handle("/datasets", pro.ProtectMethods("POST")(NewDatasetsHandler(ds, reg.Indexer, regOpts...)))
+		if reg.Stats != nil {
+			handle("/datasets/trending", NewTrendingHandler(ds, reg.Stats))
+		}
+	}
+
+	if reg.Datasets != nil && reg.Indexer != nil {
+		handle("/admin/index", pro.ProtectMethods("*")(NewIndexHandler(reg.Datasets, reg.Indexer, registry.WithLock(lock))))
 	}
 
 	if s := reg.Search; s != nil {
-		m.HandleFunc("/search", logReq(NewSearchHandler(s)))
+		handle("/search", NewSearchHandler(s))
+	}
+	if s := reg.Suggester; s != nil {
+		handle("/search/suggest", NewSuggestHandler(s))
 	}
 	if rs := reg.Reputations; rs != nil {
-		m.HandleFunc("/reputation", (logReq(NewReputationHandler(rs))))
+		handle("/reputation", NewReputationHandler(rs))
+		handle("/reputation/", NewReputationHandler(rs))
 	}
 
 	if o.Pinset != nil {
-		m.HandleFunc("/pins", logReq(NewPinsHandler(o.Pinset)))
-		m.HandleFunc("/pins/status", logReq(NewPinStatusHandler(o.Pinset)))
+		handle("/pins", NewPinsHandler(o.Pinset))
+		handle("/pins/status", NewPinStatusHandler(o.Pinset))
 	}
 	if o.Dsync != nil {
-		m.HandleFunc("/dsync", logReq(dsync.HTTPRemoteHandler(o.Dsync)))
+		h := dsync.HTTPRemoteHandler(o.Dsync)
+		if reg.Stats != nil && reg.Datasets != nil {
+			h = countFetches(reg.Datasets, reg.Stats, h)
+		}
+		if o.Metrics != nil {
+			h = o.Metrics.countDsyncBytes(h)
+		}
+		register("/dsync", h)
+	}
+	if o.Metrics != nil {
+		register("/metrics", o.Metrics.Handler())
+	}
+	if o.Webhooks != nil {
+		handle("/webhooks", pro.ProtectMethods("*")(NewWebhooksHandler(o.Webhooks)))
+		handle("/webhooks/deadletters", pro.ProtectMethods("*")(NewWebhookDeadLettersHandler(o.Webhooks)))
+	}
+
+	handle("/openapi.json", NewOpenAPIHandler(NewOpenAPI(append(routes, "/openapi.json")...)))
+	for _, pattern := range undocumentedRoutes(routes) {
+		log.Warnf("route %s is missing from the OpenAPI description", pattern)
 	}
 
 	return m
 }
 
-func logReq(h http.HandlerFunc) http.HandlerFunc {
+// jsonContentType defaults the response Content-Type to application/json,
+// apiutil error responses don't set one
+func jsonContentType(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Infof("%s %s %s", time.Now().Format(time.RFC3339), r.Method, r.URL.Path)
-		h.ServeHTTP(w, r)
+		w.Header().Set("Content-Type", "application/json")
+		h(w, r)
 	}
 }
 
-// HealthCheckHandler is a basic "hey I'm fine" for load balancers & co
+// HealthCheckHandler is a basic "hey I'm fine" for load balancers & co.
+// it's mounted at "/", so any other unmatched path is not found
 func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeErr(w, r, registry.NewError(registry.ErrNotFound, "%s not found", r.URL.Path))
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"meta":{"code": 200,"status":"ok"},"data":null}`))
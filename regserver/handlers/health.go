@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+var (
+	// Version is the registry server version, set at build time with
+	// -ldflags "-X github.com/qri-io/registry/regserver/handlers.Version=..."
+	Version = "dev"
+	// Commit is the source revision the server was built from, set like
+	// Version
+	Commit = ""
+
+	// HealthCheckTimeout bounds how long readiness checks may take
+	HealthCheckTimeout = time.Second * 5
+)
+
+// Check reports the health of a registry component, returning an error if
+// the component is degraded
+type Check func(ctx context.Context) error
+
+// Component is a named part of a registry with a readiness check
+type Component struct {
+	Name  string
+	Check Check
+}
+
+// AddHealthCheck creates a configuration func for passing to NewRoutes that
+// adds a component to the readiness check, eg: database connectivity
+func AddHealthCheck(name string, check Check) func(o *RouteOptions) {
+	return func(o *RouteOptions) {
+		o.HealthChecks = append(o.HealthChecks, Component{Name: name, Check: check})
+	}
+}
+
+// VersionInfo describes the running server
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+func versionInfo() VersionInfo {
+	return VersionInfo{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+}
+
+// Component statuses
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// ComponentStatus is the result of checking one component
+type ComponentStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latencyMs"`
+}
+
+// Readiness reports the status of each registry component. Status is
+// StatusOK only if every component is ok
+type Readiness struct {
+	Status     string            `json:"status"`
+	Version    VersionInfo       `json:"version"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// NewLivenessHandler creates a handler that reports the server is running
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiutil.WriteResponse(w, map[string]interface{}{
+			"status":  StatusOK,
+			"version": versionInfo(),
+		})
+	}
+}
+
+// NewReadinessHandler creates a handler that checks each component, responding
+// 200 OK if all are healthy & 503 Service Unavailable otherwise. Checks run
+// concurrently & are bound by HealthCheckTimeout
+func NewReadinessHandler(components []Component) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), HealthCheckTimeout)
+		defer cancel()
+
+		rd := CheckReadiness(ctx, components)
+		if rd.Status == StatusOK {
+			apiutil.WriteResponse(w, rd)
+			return
+		}
+
+		requestLog(r).Warnf("registry is not ready: %v", rd.Components)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"meta": map[string]interface{}{
+				"code":  http.StatusServiceUnavailable,
+				"error": "registry is not ready",
+			},
+			"data": rd,
+		})
+	}
+}
+
+// CheckReadiness runs all component checks, ordering results by name
+func CheckReadiness(ctx context.Context, components []Component) Readiness {
+	rd := Readiness{
+		Status:     StatusOK,
+		Version:    versionInfo(),
+		Components: make([]ComponentStatus, len(components)),
+	}
+
+	wg := sync.WaitGroup{}
+	for i, c := range components {
+		wg.Add(1)
+		go func(i int, c Component) {
+			defer wg.Done()
+			start := time.Now()
+			err := runCheck(ctx, c.Check)
+			cs := ComponentStatus{
+				Name:      c.Name,
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+			}
+			if err != nil {
+				cs.Status = StatusDegraded
+				cs.Error = err.Error()
+			}
+			rd.Components[i] = cs
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.Slice(rd.Components, func(i, j int) bool {
+		return rd.Components[i].Name < rd.Components[j].Name
+	})
+	for _, cs := range rd.Components {
+		if cs.Status != StatusOK {
+			rd.Status = StatusDegraded
+		}
+	}
+	return rd
+}
+
+// runCheck runs a check, failing if ctx is done before it returns
+func runCheck(ctx context.Context, check Check) error {
+	errs := make(chan error, 1)
+	go func() { errs <- check(ctx) }()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %s", ctx.Err().Error())
+	}
+}
+
+// lenCheck checks a store responds to a Len call
+func lenCheck(length func() int) Check {
+	return func(ctx context.Context) error {
+		length()
+		return nil
+	}
+}
+
+// pinsetCheck checks a pinset can count pins & is accepting pin jobs
+func pinsetCheck(ps pinset.Pinset) Check {
+	closer, _ := ps.(interface{ Closed() bool })
+	return func(ctx context.Context) error {
+		if closer != nil && closer.Closed() {
+			return fmt.Errorf("pinset is shutting down")
+		}
+		_, err := ps.PinLen()
+		return err
+	}
+}
+
+// registryComponents lists default readiness checks for a registry
+func registryComponents(reg registry.Registry, ps pinset.Pinset, dsyncEnabled bool) []Component {
+	var cs []Component
+	if reg.Profiles != nil {
+		cs = append(cs, Component{"profiles", lenCheck(reg.Profiles.Len)})
+	}
+	if reg.Datasets != nil {
+		cs = append(cs, Component{"datasets", lenCheck(reg.Datasets.Len)})
+	}
+	if idx, ok := reg.Indexer.(interface{ Len() int }); ok {
+		cs = append(cs, Component{"index", lenCheck(idx.Len)})
+	}
+	if ps != nil {
+		cs = append(cs, Component{"pinset", pinsetCheck(ps)})
+	}
+	if dsyncEnabled {
+		cs = append(cs, Component{"dsync", func(context.Context) error { return nil }})
+	}
+	return cs
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+	"github.com/qri-io/registry/webhook"
+)
+
+func TestReadiness(t *testing.T) {
+	testReadiness(t)
+}
+
+func TestReadinessWebhooks(t *testing.T) {
+	// webhooks wrap the pinset, which mustn't hide it shutting down
+	testReadiness(t, AddWebhooks(webhook.NewDispatcher(webhook.NewMemSubscriptions())))
+}
+
+func testReadiness(t *testing.T, opts ...func(o *RouteOptions)) {
+	reg := registry.Registry{Profiles: registry.NewMemProfiles(), Datasets: registry.NewMemDatasets()}
+	ps := pinset.NewTracker(&pinset.MemPinset{Profiles: reg.Profiles})
+	dbErr := error(nil)
+	opts = append(opts, AddPinset(ps), AddHealthCheck("database", func(ctx context.Context) error {
+		return dbErr
+	}))
+	s := httptest.NewServer(NewRoutes(reg, opts...))
+	defer s.Close()
+
+	ready := func() (int, Readiness) {
+		res, err := http.Get(s.URL + "/readyz")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer res.Body.Close()
+		env := struct{ Data Readiness }{}
+		if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+			t.Fatal(err.Error())
+		}
+		return res.StatusCode, env.Data
+	}
+
+	code, rd := ready()
+	if code != http.StatusOK || rd.Status != StatusOK {
+		t.Errorf("expected ready registry, got: %d %#v", code, rd)
+	}
+	names := ""
+	for _, c := range rd.Components {
+		names += c.Name + " "
+	}
+	if names != "database datasets pinset profiles " {
+		t.Errorf("unexpected components: %s", names)
+	}
+	if rd.Version.Version != Version {
+		t.Errorf("expected version %s, got: %s", Version, rd.Version.Version)
+	}
+
+	dbErr = fmt.Errorf("connection refused")
+	ps.Close(context.Background())
+	code, rd = ready()
+	if code != http.StatusServiceUnavailable || rd.Status != StatusDegraded {
+		t.Errorf("expected degraded registry, got: %d %#v", code, rd)
+	}
+	for _, c := range rd.Components {
+		degraded := c.Name == "database" || c.Name == "pinset"
+		if degraded != (c.Status == StatusDegraded) {
+			t.Errorf("unexpected status for %s: %s", c.Name, c.Status)
+		}
+	}
+
+	res, err := http.Get(s.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected liveness check to pass while degraded, got: %d", res.StatusCode)
+	}
+}
+
+func TestCheckReadinessTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	rd := CheckReadiness(ctx, []Component{{"slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}})
+	if rd.Status != StatusDegraded {
+		t.Errorf("expected slow check to time out")
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+)
+
+// NewIndexHandler creates an administrative handler func for the search
+// index. GET reports inconsistencies between the datasets store & the index,
+// POST rebuilds the index from the store. opts are passed along to
+// registry.CheckIndex & registry.Reindex, and should share the lock used by
+// dataset handlers
+func NewIndexHandler(datasets registry.Datasets, idxr registry.Indexer, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			rdr, ok := idxr.(registry.IndexReader)
+			if !ok {
+				err := fmt.Errorf("indexer does not support consistency checks")
+				apiutil.WriteErrResponse(w, http.StatusNotImplemented, err)
+				return
+			}
+			report, err := registry.CheckIndex(datasets, rdr, opts...)
+			if err != nil {
+				writeErr(w, r, err)
+				return
+			}
+			apiutil.WriteResponse(w, report)
+		case "POST":
+			report, err := registry.Reindex(datasets, idxr, opts...)
+			if err != nil {
+				writeErr(w, r, err)
+				return
+			}
+			requestLog(r).Infof("reindexed %d datasets", datasets.Len())
+			apiutil.WriteResponse(w, report)
+		default:
+			methodNotAllowed(w, r, "GET", "POST")
+		}
+	}
+}
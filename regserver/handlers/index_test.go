@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/search"
+)
+
+func TestIndexHandler(t *testing.T) {
+	datasets := registry.NewMemDatasets()
+	datasets.Store("b5/cities", &registry.Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities"})
+	idx := search.NewIndex()
+	s := httptest.NewServer(NewIndexHandler(datasets, idx))
+	defer s.Close()
+
+	check := func(method string) *registry.IndexReport {
+		req, err := http.NewRequest(method, s.URL, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got: %d", method, res.StatusCode)
+		}
+		env := struct{ Data *registry.IndexReport }{}
+		if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+			t.Fatal(err.Error())
+		}
+		return env.Data
+	}
+
+	if r := check("GET"); len(r.Missing) != 1 || r.Missing[0] != "b5/cities" {
+		t.Errorf("expected b5/cities to be missing, got: %#v", r)
+	}
+	check("POST")
+	if idx.Len() != 1 {
+		t.Errorf("expected 1 indexed dataset, got: %d", idx.Len())
+	}
+	if r := check("GET"); !r.Consistent() {
+		t.Errorf("expected consistent index, got: %#v", r)
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+)
+
+// RateLimit configures a token bucket. Buckets hold up to Burst tokens &
+// refill at Rate tokens per second, each request takes one token. A zero
+// Rate disables the limit
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// RouteLimits bounds requests to a single route
+type RouteLimits struct {
+	// PerIP limits requests from each client IP address
+	PerIP RateLimit `json:"perIP"`
+	// PerProfile limits successful requests from each profile. Profiles are
+	// identified by the "publicKey" field of JSON request bodies, falling
+	// back to "profileID". Requests are only charged once the handler
+	// succeeds, after their signature has been verified, so unsigned or
+	// forged requests can't use up another profile's limit. Requests
+	// without a profile are only subject to PerIP
+	PerProfile RateLimit `json:"perProfile"`
+	// MaxBodyBytes caps the size of request bodies, 0 means no limit
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+}
+
+// Limits configures request limits for NewRoutes
+type Limits struct {
+	// Default applies to routes not listed in Routes
+	Default RouteLimits `json:"default"`
+	// Routes overrides Default, keyed by route pattern, eg: "/profile"
+	Routes map[string]RouteLimits `json:"routes"`
+	// TrustForwardedFor identifies clients by the first address in the
+	// X-Forwarded-For header. Only enable this behind a proxy that sets it
+	TrustForwardedFor bool `json:"trustForwardedFor"`
+}
+
+// Route gives the limits for a route pattern
+func (l Limits) Route(pattern string) RouteLimits {
+	if rl, ok := l.Routes[pattern]; ok {
+		return rl
+	}
+	return l.Default
+}
+
+// AddLimits creates a configuration func for passing to NewRoutes
+func AddLimits(l Limits) func(o *RouteOptions) {
+	return func(o *RouteOptions) {
+		o.Limits = &l
+	}
+}
+
+// NewLimiter creates middleware enforcing rl, responding with
+// 413 Request Entity Too Large for oversized bodies & 429 Too Many Requests
+// with a Retry-After header when a rate limit is exceeded
+func NewLimiter(rl RouteLimits, trustForwardedFor bool) func(h http.HandlerFunc) http.HandlerFunc {
+	ips := newBuckets(rl.PerIP)
+	profiles := newBuckets(rl.PerProfile)
+
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if rl.MaxBodyBytes > 0 && r.Body != nil {
+				if r.ContentLength > rl.MaxBodyBytes {
+					err := fmt.Errorf("request body exceeds %d bytes", rl.MaxBodyBytes)
+					apiutil.WriteErrResponse(w, http.StatusRequestEntityTooLarge, err)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, rl.MaxBodyBytes)
+			}
+
+			now := time.Now()
+			if wait, ok := ips.take(clientIP(r, trustForwardedFor), now); !ok {
+				writeRateLimited(w, wait)
+				return
+			}
+			if profiles != nil {
+				if id := requestProfileID(r); id != "" {
+					if wait, ok := profiles.peek(id, now); !ok {
+						writeRateLimited(w, wait)
+						return
+					}
+					rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+					h(rec, r)
+					if rec.status < 300 {
+						profiles.take(id, time.Now())
+					}
+					return
+				}
+			}
+			h(w, r)
+		}
+	}
+}
+
+// writeRateLimited responds with 429 & a Retry-After header in whole seconds
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	secs := int(math.Ceil(wait.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	apiutil.WriteErrResponse(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded, retry in %d seconds", secs))
+}
+
+// clientIP identifies the client that made a request
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestProfileID identifies the profile making a request by the publicKey
+// or profileID field of a JSON request body, leaving the body intact for the
+// handler. The result is unverified until the handler accepts the request
+func requestProfileID(r *http.Request) string {
+	if r.Body == nil || !isJSON(r) {
+		return ""
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		// replay the read error after the data, so the handler sees it
+		r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{err}))
+		return ""
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	body := struct{ ProfileID, PublicKey string }{}
+	json.Unmarshal(data, &body)
+	if body.PublicKey != "" {
+		id, err := registry.ProfileIDFromPublicKey(body.PublicKey)
+		if err != nil {
+			return ""
+		}
+		return id
+	}
+	return body.ProfileID
+}
+
+// errReader is an io.Reader that always fails with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// bucketSweepInterval is how often idle token buckets are dropped
+const bucketSweepInterval = time.Minute
+
+// buckets is a set of token buckets safe for concurrent use
+type buckets struct {
+	RateLimit
+	sync.Mutex
+	tokens    map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newBuckets returns nil if rl doesn't limit anything
+func newBuckets(rl RateLimit) *buckets {
+	if rl.Rate <= 0 {
+		return nil
+	}
+	if rl.Burst < 1 {
+		rl.Burst = 1
+	}
+	return &buckets{RateLimit: rl, tokens: map[string]*bucket{}}
+}
+
+// take removes a token from the bucket for key. If the bucket is empty take
+// returns false & the time until a token is available
+func (bs *buckets) take(key string, now time.Time) (time.Duration, bool) {
+	return bs.check(key, now, true)
+}
+
+// peek is like take, but leaves the bucket's tokens in place
+func (bs *buckets) peek(key string, now time.Time) (time.Duration, bool) {
+	return bs.check(key, now, false)
+}
+
+func (bs *buckets) check(key string, now time.Time, take bool) (time.Duration, bool) {
+	if bs == nil {
+		return 0, true
+	}
+	bs.Lock()
+	defer bs.Unlock()
+
+	if now.Sub(bs.lastSweep) > bucketSweepInterval {
+		bs.sweep(now)
+	}
+
+	b, ok := bs.tokens[key]
+	if !ok {
+		b = &bucket{tokens: float64(bs.Burst), last: now}
+		bs.tokens[key] = b
+	}
+	b.tokens = math.Min(float64(bs.Burst), b.tokens+now.Sub(b.last).Seconds()*bs.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / bs.Rate * float64(time.Second)), false
+	}
+	if take {
+		b.tokens--
+	}
+	return 0, true
+}
+
+// sweep drops buckets that would have refilled completely, they're
+// indistinguishable from new buckets. callers must hold the lock
+func (bs *buckets) sweep(now time.Time) {
+	full := time.Duration(float64(bs.Burst) / bs.Rate * float64(time.Second))
+	for key, b := range bs.tokens {
+		if now.Sub(b.last) >= full {
+			delete(bs.tokens, key)
+		}
+	}
+	bs.lastSweep = now
+}
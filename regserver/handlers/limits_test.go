@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry"
+)
+
+func TestLimiter(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	h := NewLimiter(RouteLimits{
+		PerIP:        RateLimit{Rate: 0.001, Burst: 3},
+		PerProfile:   RateLimit{Rate: 0.001, Burst: 1},
+		MaxBodyBytes: 64,
+	}, false)(ok)
+
+	cases := []struct {
+		remoteAddr string
+		body       string
+		status     int
+	}{
+		{"1.1.1.1:80", `{"profileID":"QmA"}`, http.StatusOK},
+		{"1.1.1.1:80", `{"profileID":"QmA"}`, http.StatusTooManyRequests},
+		{"1.1.1.1:80", `{"profileID":"QmB"}`, http.StatusOK},
+		{"1.1.1.1:80", `{"profileID":"QmC"}`, http.StatusTooManyRequests},
+		{"2.2.2.2:80", `{"profileID":"QmA"}`, http.StatusTooManyRequests},
+		{"2.2.2.2:80", `{"profileID":"QmD"}`, http.StatusOK},
+		{"3.3.3.3:80", strings.Repeat("a", 65), http.StatusRequestEntityTooLarge},
+	}
+	for i, c := range cases {
+		r := httptest.NewRequest("POST", "/profile", strings.NewReader(c.body))
+		r.Header.Set("Content-Type", "application/json")
+		r.RemoteAddr = c.remoteAddr
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != c.status {
+			t.Errorf("case %d: expected status %d, got: %d", i, c.status, w.Code)
+		}
+		if c.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("case %d: expected Retry-After header", i)
+		}
+	}
+}
+
+func TestLimiterChargesVerifiedProfiles(t *testing.T) {
+	b5, err := registry.ProfileFromPrivateKey("b5", privKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := http.StatusUnauthorized
+	h := NewLimiter(RouteLimits{
+		PerProfile: RateLimit{Rate: 0.001, Burst: 1},
+	}, false)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	do := func(body string) int {
+		r := httptest.NewRequest("POST", "/profile", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+
+	// rejected requests claiming b5's profileID don't use up b5's limit
+	forged := fmt.Sprintf(`{"profileID":%q}`, b5.ProfileID)
+	for i := 0; i < 3; i++ {
+		if code := do(forged); code != http.StatusUnauthorized {
+			t.Fatalf("forged request %d: expected status %d, got: %d", i, http.StatusUnauthorized, code)
+		}
+	}
+
+	// signed requests are keyed on the public key, not the claimed profileID
+	status = http.StatusOK
+	signed := fmt.Sprintf(`{"profileID":"QmOther","publicKey":%q}`, b5.PublicKey)
+	if code := do(signed); code != http.StatusOK {
+		t.Errorf("expected status %d, got: %d", http.StatusOK, code)
+	}
+	if code := do(forged); code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got: %d", http.StatusTooManyRequests, code)
+	}
+	if code := do(`{"profileID":"QmOther"}`); code != http.StatusOK {
+		t.Errorf("expected status %d, got: %d", http.StatusOK, code)
+	}
+}
+
+func TestLimiterChunkedBody(t *testing.T) {
+	decode := func(w http.ResponseWriter, r *http.Request) {
+		v := map[string]interface{}{}
+		if readJSON(w, r, &v) {
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	for _, perProfile := range []RateLimit{{}, {Rate: 1, Burst: 10}} {
+		s := httptest.NewServer(NewLimiter(RouteLimits{
+			PerProfile:   perProfile,
+			MaxBodyBytes: 64,
+		}, false)(decode))
+
+		for _, c := range []struct {
+			body   string
+			status int
+		}{
+			{`{"profileID":"QmA"}`, http.StatusOK},
+			{`{"profileID":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		} {
+			// hiding the reader's length sends the body chunked, without a
+			// Content-Length for the limiter to check up front
+			body := io.MultiReader(strings.NewReader(c.body))
+			res, err := http.Post(s.URL, "application/json", body)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			res.Body.Close()
+			if res.StatusCode != c.status {
+				t.Errorf("per profile limit %v, body of %d bytes: expected status %d, got: %d", perProfile, len(c.body), c.status, res.StatusCode)
+			}
+		}
+		s.Close()
+	}
+}
+
+func TestBucketsRefill(t *testing.T) {
+	bs := newBuckets(RateLimit{Rate: 2, Burst: 1})
+	now := time.Now()
+	if _, ok := bs.take("a", now); !ok {
+		t.Fatal("expected first take to succeed")
+	}
+	wait, ok := bs.take("a", now)
+	if ok || wait != time.Millisecond*500 {
+		t.Errorf("expected to wait 500ms, got: %s, %t", wait, ok)
+	}
+	if _, ok := bs.take("a", now.Add(time.Millisecond*500)); !ok {
+		t.Error("expected bucket to refill")
+	}
+
+	bs.take("b", now)
+	bs.take("c", now.Add(bucketSweepInterval*2))
+	if len(bs.tokens) != 1 {
+		t.Errorf("expected idle buckets to be swept, got %d buckets", len(bs.tokens))
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+// Metrics collects prometheus metrics about requests to registry routes &
+// the size of registry stores. Metrics is a prometheus.Collector
+type Metrics struct {
+	requests   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	dsyncBytes *prometheus.CounterVec
+
+	mu       sync.RWMutex
+	profiles registry.Profiles
+	datasets registry.Datasets
+	pins     pinset.Pinset
+	pinJobs  interface{ Jobs() int }
+
+	profilesDesc *prometheus.Desc
+	datasetsDesc *prometheus.Desc
+	pinsDesc     *prometheus.Desc
+	pinJobsDesc  *prometheus.Desc
+}
+
+// assert at compile time that Metrics is a prometheus.Collector
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// NewMetrics allocates a new set of metrics
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "registry_http_requests_total",
+			Help: "Number of HTTP requests by route, method & status code",
+		}, []string{"route", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "registry_http_request_duration_seconds",
+			Help:    "HTTP request latency by route & method",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		dsyncBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "registry_dsync_transfer_bytes_total",
+			Help: "Bytes transferred over dsync, received (in) & sent (out)",
+		}, []string{"direction"}),
+
+		profilesDesc: prometheus.NewDesc("registry_profiles", "Number of registered profiles", nil, nil),
+		datasetsDesc: prometheus.NewDesc("registry_datasets", "Number of registered datasets", nil, nil),
+		pinsDesc:     prometheus.NewDesc("registry_pins", "Number of pins in the pinset", nil, nil),
+		pinJobsDesc:  prometheus.NewDesc("registry_pin_jobs", "Number of in-flight pin jobs", nil, nil),
+	}
+}
+
+// AddMetrics creates a configuration func for passing to NewRoutes
+func AddMetrics(m *Metrics) func(o *RouteOptions) {
+	return func(o *RouteOptions) {
+		o.Metrics = m
+	}
+}
+
+// observeStores sets the stores whose sizes are reported. pins may be nil.
+// If pins reports in-flight jobs they're reported as well
+func (m *Metrics) observeStores(profiles registry.Profiles, datasets registry.Datasets, pins pinset.Pinset) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles = profiles
+	m.datasets = datasets
+	m.pins = pins
+	m.pinJobs, _ = pins.(interface{ Jobs() int })
+}
+
+// observeRequest records a completed request
+func (m *Metrics) observeRequest(route, method string, status int, elapsed time.Duration) {
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.latency.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.latency.Describe(ch)
+	m.dsyncBytes.Describe(ch)
+	ch <- m.profilesDesc
+	ch <- m.datasetsDesc
+	ch <- m.pinsDesc
+	ch <- m.pinJobsDesc
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.latency.Collect(ch)
+	m.dsyncBytes.Collect(ch)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	gauge := func(desc *prometheus.Desc, n int) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n))
+	}
+	if m.profiles != nil {
+		gauge(m.profilesDesc, m.profiles.Len())
+	}
+	if m.datasets != nil {
+		gauge(m.datasetsDesc, m.datasets.Len())
+	}
+	if m.pins != nil {
+		if n, err := m.pins.PinLen(); err == nil {
+			gauge(m.pinsDesc, n)
+		} else {
+			log.Errorf("collecting pin count: %s", err.Error())
+		}
+	}
+	if m.pinJobs != nil {
+		gauge(m.pinJobsDesc, m.pinJobs.Jobs())
+	}
+}
+
+// Handler serves metrics in the prometheus exposition format
+func (m *Metrics) Handler() http.HandlerFunc {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP
+}
+
+// countDsyncBytes wraps a dsync handler, counting bytes received in request
+// bodies & sent in responses
+func (m *Metrics) countDsyncBytes(h http.HandlerFunc) http.HandlerFunc {
+	in := m.dsyncBytes.WithLabelValues("in")
+	out := m.dsyncBytes.WithLabelValues("out")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = &countingReader{ReadCloser: r.Body, counter: in}
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+		out.Add(float64(rec.bytes))
+	}
+}
+
+// countingReader adds the number of bytes read to a counter
+type countingReader struct {
+	io.ReadCloser
+	counter prometheus.Counter
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.counter.Add(float64(n))
+	return n, err
+}
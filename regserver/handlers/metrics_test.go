@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+	"github.com/qri-io/registry/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMetrics(t *testing.T) {
+	// webhooks wrap the pinset, which mustn't hide it's job count
+	for _, webhooks := range []bool{false, true} {
+		reg := registry.Registry{Profiles: registry.NewMemProfiles(), Datasets: registry.NewMemDatasets()}
+		reg.Datasets.Store("b5/cities", &registry.Dataset{Handle: "b5", Name: "cities"})
+		ps := pinset.NewTracker(&pinset.MemPinset{Profiles: reg.Profiles})
+		opts := []func(o *RouteOptions){AddPinset(ps), AddMetrics(NewMetrics())}
+		if webhooks {
+			opts = append(opts, AddWebhooks(webhook.NewDispatcher(webhook.NewMemSubscriptions())))
+		}
+		s := httptest.NewServer(NewRoutes(reg, opts...))
+		defer s.Close()
+
+		for _, path := range []string{"/", "/datasets", "/dataset/"} {
+			res, err := http.Get(s.URL + path)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			res.Body.Close()
+		}
+
+		res, err := http.Get(s.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		for _, line := range []string{
+			`registry_http_requests_total{code="200",method="GET",route="/"} 1`,
+			`registry_http_requests_total{code="200",method="GET",route="/datasets"} 1`,
+			`registry_http_requests_total{code="400",method="GET",route="/dataset/"} 1`,
+			`registry_http_request_duration_seconds_count{method="GET",route="/datasets"} 1`,
+			`registry_datasets 1`,
+			`registry_profiles 0`,
+			`registry_pins 0`,
+			`registry_pin_jobs 0`,
+		} {
+			if !strings.Contains(string(data), line) {
+				t.Errorf("webhooks %t: expected metrics to contain: %s", webhooks, line)
+			}
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	prevOut, prevFmt, prevLvl := log.Out, log.Formatter, log.Level
+	log.SetOutput(buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetLevel(logrus.InfoLevel)
+	defer func() {
+		log.SetOutput(prevOut)
+		log.SetFormatter(prevFmt)
+		log.SetLevel(prevLvl)
+	}()
+
+	h := accessLog("/test", nil)(func(w http.ResponseWriter, r *http.Request) {
+		requestLog(r).Info("handling")
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	h(w, r)
+	if got := w.Header().Get(RequestIDHeader); got != "abc-123" {
+		t.Errorf("expected request id to be echoed, got: '%s'", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got: %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"request_id":"abc-123"`) {
+			t.Errorf("expected log line to include request id: %s", line)
+		}
+	}
+	if !strings.Contains(lines[1], `"status":418`) || !strings.Contains(lines[1], `"route":"/test"`) {
+		t.Errorf("expected access log line to include status & route: %s", lines[1])
+	}
+
+	r = httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set(RequestIDHeader, "not a valid id\n")
+	w = httptest.NewRecorder()
+	h(w, r)
+	if got := w.Header().Get(RequestIDHeader); got == "" || strings.Contains(got, " ") {
+		t.Errorf("expected invalid request id to be replaced, got: '%s'", got)
+	}
+}
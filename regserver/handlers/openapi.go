@@ -0,0 +1,645 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/apiutil"
+)
+
+// APIVersion is the current version of the registry HTTP API. Routes are
+// served under APIPrefix, unprefixed paths are aliases of the current version
+const (
+	APIVersion = "v1"
+	APIPrefix  = "/" + APIVersion
+)
+
+// OpenAPI is an OpenAPI 3 description of the registry HTTP API
+type OpenAPI struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       OpenAPIInfo         `json:"info"`
+	Servers    []OpenAPIServer     `json:"servers"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a base URL the API is served from
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations a path supports
+type PathItem map[string]*Operation
+
+// Operation describes a single method on a path
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response to an operation. Responses without Content
+// have no meaningful body
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType gives the schema of a body, keyed by content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds schemas & security schemes referenced by operations
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how protected operations authenticate
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// Schema is the subset of OpenAPI schema objects the registry API uses. A
+// schema without a Type or Ref accepts any value
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// NewOpenAPI describes the API served on a set of route patterns, as
+// registered by NewRoutes. Patterns without documentation are skipped
+func NewOpenAPI(patterns ...string) *OpenAPI {
+	registered := map[string]bool{}
+	for _, p := range patterns {
+		registered[p] = true
+	}
+
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "qri registry",
+			Description: "Responses are wrapped in an envelope with a meta object & a data field. Paths without the " + APIPrefix + " prefix are aliases of the current API version",
+			Version:     APIVersion,
+		},
+		Servers: []OpenAPIServer{{URL: APIPrefix}},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         apiSchemas,
+			SecuritySchemes: map[string]*SecurityScheme{"basicAuth": {Type: "http", Scheme: "basic"}},
+		},
+	}
+	for _, ap := range apiPaths {
+		if registered[ap.pattern] {
+			doc.Paths[ap.path] = ap.ops
+		}
+	}
+	return doc
+}
+
+// NewOpenAPIHandler creates a handler that serves an OpenAPI document
+func NewOpenAPIHandler(doc *OpenAPI) http.HandlerFunc {
+	data, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
+			return
+		}
+		if err != nil {
+			apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// undocumentedRoutes lists patterns with no OpenAPI description, sorted
+func undocumentedRoutes(patterns []string) (missing []string) {
+	documented := map[string]bool{}
+	for _, ap := range apiPaths {
+		documented[ap.pattern] = true
+	}
+	for _, p := range patterns {
+		if !documented[p] {
+			missing = append(missing, p)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// apiPath documents an OpenAPI path served by a route pattern. Patterns that
+// match a subtree, like "/dataset/", can serve more than one path
+type apiPath struct {
+	pattern string
+	path    string
+	ops     PathItem
+}
+
+// apiPaths is the description of every route NewRoutes can register. Keep
+// this in sync with handlers, TestOpenAPI checks responses against it
+var apiPaths = []apiPath{
+	{"/", "/", PathItem{
+		"get": op("basic health check for load balancers", &Schema{Nullable: true}),
+	}},
+	{"/healthz", "/healthz", PathItem{
+		"get": op("report the server is running", ref("Liveness")),
+	}},
+	{"/readyz", "/readyz", PathItem{
+		"get": op("check each registry component is ready", ref("Readiness")).
+			respond(http.StatusServiceUnavailable, envelope(ref("Readiness"))),
+	}},
+	{"/openapi.json", "/openapi.json", PathItem{
+		"get": op("this document", nil).
+			content(http.StatusOK, "application/json", &Schema{Type: "object"}),
+	}},
+
+	{"/profile", "/profile", PathItem{
+		"get": op("get a profile by handle, profileID or public key", ref("Profile"), 400, 404).
+			params(
+				query("handle", "profile handle", stringSchema),
+				query("profileID", "profile id", stringSchema),
+				query("publicKey", "base64-encoded public key", stringSchema),
+			).
+			optionalBody(ref("Profile")),
+		"post":   op("register a profile", ref("Profile"), 400, 401, 409).body(ref("Profile")),
+		"put":    op("register a profile", ref("Profile"), 400, 401, 409).body(ref("Profile")),
+		"delete": op("deregister a profile", ref("Profile"), 400, 401).body(ref("Profile")),
+	}},
+	{"/profile/", "/profile/{handle}", PathItem{
+		"get": op("get a profile by handle", ref("Profile"), 404).params(handleParam),
+	}},
+	{"/profiles", "/profiles", PathItem{
+		"get":  op("list profiles", arrayOf(ref("Profile"))),
+		"post": op("store profiles without verification", arrayOf(ref("Profile")), 400).body(arrayOf(ref("Profile"))).protected(),
+	}},
+
+	{"/organization", "/organization", PathItem{
+		"get": op("get an organization by handle", ref("Organization"), 400, 404).
+			params(query("handle", "organization handle", stringSchema)).
+			optionalBody(ref("Organization")),
+		"post":   op("register an organization", ref("Organization"), 400, 401, 409).body(ref("Organization")),
+		"put":    op("register an organization", ref("Organization"), 400, 401, 409).body(ref("Organization")),
+		"delete": op("deregister an organization", ref("Organization"), 400, 401, 404).body(ref("Organization")),
+	}},
+	{"/organization/", "/organization/{handle}", PathItem{
+		"get": op("get an organization by handle", ref("Organization"), 404).params(handleParam),
+	}},
+	{"/organization/members", "/organization/members", PathItem{
+		"post": op("add or remove an organization member", ref("Organization"), 400, 401, 404, 409).body(ref("MembershipChange")),
+		"put":  op("add or remove an organization member", ref("Organization"), 400, 401, 404, 409).body(ref("MembershipChange")),
+	}},
+
+	{"/dataset", "/dataset", PathItem{
+		"get": op("get a dataset by reference, recording a lookup. previews are omitted, see /dataset/{ref}/preview", ref("Dataset"), 400, 404).
+			params(query("ref", "dataset reference, see /dataset/{ref}", stringSchema)).
+			respond(http.StatusMovedPermanently, nil),
+		"post":   op("register a dataset", ref("Dataset"), 400, 401, 500).body(ref("Dataset")),
+		"put":    op("register a dataset", ref("Dataset"), 400, 401, 500).body(ref("Dataset")),
+		"delete": op("deregister a dataset", ref("Dataset"), 400, 401, 500).body(ref("Dataset")),
+	}},
+	{"/dataset/", "/dataset/{ref}", PathItem{
+		"get": op("get a dataset by reference, recording a lookup. previews are omitted, see /dataset/{ref}/preview", ref("Dataset"), 400, 404).
+			params(refParam).
+			respond(http.StatusMovedPermanently, nil),
+	}},
+	{"/dataset/", "/dataset/{ref}/preview", PathItem{
+		"get": op("get a preview of a dataset's body & readme", ref("Preview"), 400, 404).
+			params(refParam).
+			respond(http.StatusMovedPermanently, nil),
+	}},
+	{"/dataset/move", "/dataset/move", PathItem{
+		"post": op("move a dataset to a new name, redirecting the old name", ref("Dataset"), 400, 401, 404, 409, 500).body(ref("DatasetMove")),
+		"put":  op("move a dataset to a new name, redirecting the old name", ref("Dataset"), 400, 401, 404, 409, 500).body(ref("DatasetMove")),
+	}},
+	{"/dataset/deprecation", "/dataset/deprecation", PathItem{
+		"post":   op("deprecate a dataset", ref("Dataset"), 400, 401, 404, 409, 500).body(ref("Deprecation")),
+		"put":    op("deprecate a dataset", ref("Dataset"), 400, 401, 404, 409, 500).body(ref("Deprecation")),
+		"delete": op("remove a dataset deprecation", ref("Dataset"), 400, 401, 404, 409, 500).body(ref("Deprecation")),
+	}},
+	{"/datasets", "/datasets", PathItem{
+		"get":  op("list datasets", arrayOf(ref("Dataset"))).params(pageParams...),
+		"post": op("store datasets without verification", arrayOf(ref("Dataset")), 400, 500).body(arrayOf(ref("Dataset"))).params(pageParams...).protected(),
+	}},
+	{"/datasets/trending", "/datasets/trending", PathItem{
+		"get": op("list datasets by recent use", arrayOf(ref("Dataset"))).
+			params(append(pageParams, query("days", "number of days of use to consider", intSchema))...),
+	}},
+
+	{"/admin/index", "/admin/index", PathItem{
+		"get":  op("check the search index against the datasets store", ref("IndexReport"), 500, 501).protected(),
+		"post": op("rebuild the search index from the datasets store", ref("IndexReport"), 500).protected(),
+	}},
+	{"/search", "/search", PathItem{
+		"get":  op("search datasets & profiles", arrayOf(ref("SearchResult")), 400, 501).params(searchParams...).optionalBody(ref("SearchParams")),
+		"post": op("search datasets & profiles", arrayOf(ref("SearchResult")), 400, 501).params(searchParams...).optionalBody(ref("SearchParams")),
+	}},
+	{"/search/suggest", "/search/suggest", PathItem{
+		"get": op("suggest completions for a partial query", arrayOf(ref("Suggestion")), 400).
+			params(
+				query("q", "partial query", stringSchema),
+				query("limit", "maximum number of suggestions", intSchema),
+			),
+	}},
+	{"/reputation", "/reputation", PathItem{
+		"get": op("get a profile's reputation", ref("ReputationResponse"), 400).
+			params(query("profileID", "profile id", stringSchema)).
+			optionalBody(ref("Reputation")),
+	}},
+	{"/reputation/", "/reputation/{profileID}", PathItem{
+		"get": op("get a profile's reputation", ref("ReputationResponse")).
+			params(Parameter{Name: "profileID", In: "path", Description: "profile id", Required: true, Schema: stringSchema}),
+	}},
+
+	{"/pins", "/pins", PathItem{
+		"get":    op("list pinned paths", arrayOf(stringSchema), 400, 500).params(pageParams...),
+		"post":   op("pin a path", ref("PinStatus"), 400, 500, 503).params(pathParam).optionalBody(ref("PinRequest")),
+		"delete": op("unpin a path", ref("PinStatus"), 400, 500, 503).params(pathParam).optionalBody(ref("PinRequest")),
+	}},
+	{"/pins/status", "/pins/status", PathItem{
+		"get": op("get the status of a pin", ref("PinStatus"), 400, 404, 500).params(pathParam).optionalBody(ref("PinRequest")),
+	}},
+	{"/dsync", "/dsync", PathItem{
+		"get": op("fetch a manifest or block", nil).
+			params(query("manifest", "root id of a manifest to fetch", stringSchema), query("block", "hash of a block to fetch", stringSchema)).
+			content(http.StatusOK, "application/json", nil).
+			content(http.StatusOK, "application/octet-stream", nil),
+		"post": op("start a dsync push session", nil).
+			content(http.StatusOK, "application/json", nil),
+		"put": op("send a block in a dsync push session", nil).
+			params(query("sid", "push session id", stringSchema), query("hash", "block hash", stringSchema)),
+	}},
+	{"/metrics", "/metrics", PathItem{
+		"get": op("prometheus metrics", nil).
+			content(http.StatusOK, "text/plain", nil),
+	}},
+
+	{"/webhooks", "/webhooks", PathItem{
+		"get":    op("list webhook subscriptions", arrayOf(ref("Subscription"))).params(pageParams...).protected(),
+		"post":   op("subscribe to events", ref("Subscription"), 400).body(ref("Subscription")).protected(),
+		"delete": op("remove a subscription", ref("Subscription"), 404).params(query("id", "subscription id", stringSchema)).protected(),
+	}},
+	{"/webhooks/deadletters", "/webhooks/deadletters", PathItem{
+		"get": op("list webhook deliveries that failed every retry", arrayOf(ref("DeadLetter"))).params(pageParams...).protected(),
+	}},
+}
+
+// op creates an operation that responds with data in a response envelope.
+// A nil data schema documents a 200 response without a body. Operations can
+// also fail with each of errCodes, & all operations can be rate limited
+func op(summary string, data *Schema, errCodes ...int) *Operation {
+	o := &Operation{Summary: summary, Responses: map[string]*Response{}}
+	if data != nil {
+		o.respond(http.StatusOK, envelope(data))
+	} else {
+		o.respond(http.StatusOK, nil)
+	}
+	for _, code := range append(errCodes, http.StatusTooManyRequests) {
+		o.respond(code, ref("Error"))
+	}
+	return o
+}
+
+// respond documents a JSON response, a nil schema documents a response
+// without a body
+func (o *Operation) respond(code int, s *Schema) *Operation {
+	if s == nil {
+		return o.content(code, "", nil)
+	}
+	return o.content(code, "application/json", s)
+}
+
+// content documents a response of a content type. An empty content type
+// documents a response without a body
+func (o *Operation) content(code int, contentType string, s *Schema) *Operation {
+	key := strconv.Itoa(code)
+	res, ok := o.Responses[key]
+	if !ok {
+		res = &Response{Description: strings.ToLower(http.StatusText(code))}
+		o.Responses[key] = res
+	}
+	if contentType != "" {
+		if res.Content == nil {
+			res.Content = map[string]MediaType{}
+		}
+		res.Content[contentType] = MediaType{Schema: s}
+	}
+	return o
+}
+
+// body documents a required JSON request body, requests with another
+// content type are rejected
+func (o *Operation) body(s *Schema) *Operation {
+	o.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: s}}}
+	o.respond(http.StatusUnsupportedMediaType, ref("Error"))
+	return o.respond(http.StatusRequestEntityTooLarge, ref("Error"))
+}
+
+// optionalBody documents a JSON request body that replaces query parameters
+func (o *Operation) optionalBody(s *Schema) *Operation {
+	o.body(s)
+	o.RequestBody.Required = false
+	delete(o.Responses, strconv.Itoa(http.StatusUnsupportedMediaType))
+	return o
+}
+
+func (o *Operation) params(ps ...Parameter) *Operation {
+	o.Parameters = append(o.Parameters, ps...)
+	return o
+}
+
+// protected documents an operation requiring admin credentials
+func (o *Operation) protected() *Operation {
+	o.Security = []map[string][]string{{"basicAuth": {}}}
+	return o.respond(http.StatusUnauthorized, ref("Error"))
+}
+
+func query(name, description string, s *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: s}
+}
+
+var (
+	refParam = Parameter{
+		Name:        "ref",
+		In:          "path",
+		Description: "dataset reference in the form handle/name, handle/name@/ipfs/path or /ipfs/path. may contain slashes",
+		Required:    true,
+		Schema:      stringSchema,
+	}
+	handleParam = Parameter{
+		Name:        "handle",
+		In:          "path",
+		Description: "profile or organization handle",
+		Required:    true,
+		Schema:      stringSchema,
+	}
+	pathParam    = query("path", "content path, used if the request has no JSON body", stringSchema)
+	searchParams = []Parameter{
+		query("q", "search query", stringSchema),
+		query("limit", "maximum number of results", intSchema),
+		query("offset", "number of results to skip", intSchema),
+		query("column", "only match datasets with a column of this name", stringSchema),
+		query("columnType", "only match datasets with a column of this type", stringSchema),
+		query("sort", "comma-separated fields to sort by", stringSchema),
+		query("boost", "comma-separated field:weight relevance boosts", stringSchema),
+	}
+	pageParams = []Parameter{
+		query("page", "page number, starting at 1", intSchema),
+		query("pageSize", "number of results per page", intSchema),
+	}
+)
+
+var (
+	stringSchema = &Schema{Type: "string"}
+	intSchema    = &Schema{Type: "integer"}
+	numberSchema = &Schema{Type: "number"}
+	boolSchema   = &Schema{Type: "boolean"}
+	timeSchema   = &Schema{Type: "string", Format: "date-time"}
+	// anySchema accepts any value
+	anySchema = &Schema{}
+)
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// arrayOf creates an array schema. arrays are nullable because go encodes
+// nil slices as null
+func arrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items, Nullable: true}
+}
+
+func object(required []string, props map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Required: required, Properties: props}
+}
+
+func envelope(data *Schema) *Schema {
+	return object([]string{"meta"}, map[string]*Schema{
+		"meta": ref("Meta"),
+		"data": data,
+	})
+}
+
+// apiSchemas are the JSON encodings of values handlers read & write
+var apiSchemas = map[string]*Schema{
+	"Meta": object([]string{"code"}, map[string]*Schema{
+		"code":   intSchema,
+		"error":  stringSchema,
+		"status": stringSchema,
+	}),
+	"Error": object([]string{"meta"}, map[string]*Schema{
+		"meta": ref("Meta"),
+		"data": &Schema{Nullable: true},
+	}),
+
+	"Liveness": object([]string{"status", "version"}, map[string]*Schema{
+		"status":  stringSchema,
+		"version": ref("VersionInfo"),
+	}),
+	"VersionInfo": object([]string{"version", "goVersion"}, map[string]*Schema{
+		"version":   stringSchema,
+		"commit":    stringSchema,
+		"goVersion": stringSchema,
+	}),
+	"Readiness": object([]string{"status", "version", "components"}, map[string]*Schema{
+		"status":     stringSchema,
+		"version":    ref("VersionInfo"),
+		"components": arrayOf(ref("ComponentStatus")),
+	}),
+	"ComponentStatus": object([]string{"name", "status", "latencyMs"}, map[string]*Schema{
+		"name":      stringSchema,
+		"status":    stringSchema,
+		"error":     stringSchema,
+		"latencyMs": numberSchema,
+	}),
+
+	"Profile": object([]string{"ProfileID", "Handle"}, map[string]*Schema{
+		"ProfileID": stringSchema,
+		"Handle":    stringSchema,
+		"Signature": stringSchema,
+		"PublicKey": stringSchema,
+		"Created":   timeSchema,
+	}),
+	"Organization": object([]string{"Handle"}, map[string]*Schema{
+		"Handle":    stringSchema,
+		"Owners":    arrayOf(stringSchema),
+		"Members":   arrayOf(stringSchema),
+		"Created":   timeSchema,
+		"Updated":   timeSchema,
+		"Operation": stringSchema,
+		"Timestamp": timeSchema,
+		"PublicKey": stringSchema,
+		"Signature": stringSchema,
+	}),
+	"MembershipChange": object(nil, map[string]*Schema{
+		"Org":       stringSchema,
+		"ProfileID": stringSchema,
+		"Role":      stringSchema,
+		"Remove":    boolSchema,
+		"Timestamp": timeSchema,
+		"PublicKey": stringSchema,
+		"Signature": stringSchema,
+	}),
+
+	"Dataset": object(nil, map[string]*Schema{
+		"commit":             &Schema{Type: "object", Description: "qri dataset commit"},
+		"meta":               &Schema{Type: "object", Description: "qri dataset metadata"},
+		"structure":          &Schema{Type: "object", Description: "qri dataset structure"},
+		"path":               stringSchema,
+		"profileID":          stringSchema,
+		"Handle":             stringSchema,
+		"Name":               stringSchema,
+		"PublicKey":          stringSchema,
+		"signatureVersion":   intSchema,
+		"signature":          stringSchema,
+		"operation":          stringSchema,
+		"deprecation":        ref("Deprecation"),
+		"deprecationUpdated": timeSchema,
+		"moved":              timeSchema,
+		"signedKey":          stringSchema,
+		"preview":            ref("Preview"),
+		"stats":              ref("DatasetStats"),
+	}),
+	"Preview": object(nil, map[string]*Schema{
+		"body":   anySchema,
+		"readme": stringSchema,
+	}),
+	"DatasetStats": object(nil, map[string]*Schema{
+		"key":     stringSchema,
+		"lookups": intSchema,
+		"fetches": intSchema,
+		"pins":    intSchema,
+		"daily": arrayOf(object(nil, map[string]*Schema{
+			"day":     stringSchema,
+			"lookups": intSchema,
+			"fetches": intSchema,
+			"pins":    intSchema,
+		})),
+	}),
+	"DatasetMove": object([]string{"from", "to"}, map[string]*Schema{
+		"from":      stringSchema,
+		"to":        stringSchema,
+		"timestamp": timeSchema,
+		"publicKey": stringSchema,
+		"signature": stringSchema,
+	}),
+	"Deprecation": object([]string{"ref"}, map[string]*Schema{
+		"ref":       stringSchema,
+		"operation": stringSchema,
+		"message":   stringSchema,
+		"successor": stringSchema,
+		"timestamp": timeSchema,
+		"publicKey": stringSchema,
+		"signature": stringSchema,
+	}),
+	"IndexReport": object([]string{"checked"}, map[string]*Schema{
+		"checked":  intSchema,
+		"missing":  arrayOf(stringSchema),
+		"stale":    arrayOf(stringSchema),
+		"orphaned": arrayOf(stringSchema),
+	}),
+
+	"SearchParams": object(nil, map[string]*Schema{
+		"Q":          stringSchema,
+		"Limit":      intSchema,
+		"Offset":     intSchema,
+		"Column":     stringSchema,
+		"ColumnType": stringSchema,
+		"Sort":       stringSchema,
+		"Boosts":     &Schema{Type: "object", AdditionalProperties: numberSchema, Nullable: true},
+	}),
+	"SearchResult": object([]string{"Type", "ID"}, map[string]*Schema{
+		"Type":  stringSchema,
+		"ID":    stringSchema,
+		"Value": anySchema,
+		"Score": numberSchema,
+	}),
+	"Suggestion": object([]string{"Type", "ID", "Text"}, map[string]*Schema{
+		"Type":     stringSchema,
+		"ID":       stringSchema,
+		"Text":     stringSchema,
+		"Distance": intSchema,
+	}),
+	"Reputation": object(nil, map[string]*Schema{
+		"ProfileID": stringSchema,
+		"Rep":       intSchema,
+	}),
+	"ReputationResponse": object([]string{"Reputation", "Expiration"}, map[string]*Schema{
+		"Reputation": ref("Reputation"),
+		"Expiration": &Schema{Type: "integer", Description: "nanoseconds the reputation can be cached for"},
+	}),
+
+	"PinRequest": object(nil, map[string]*Schema{
+		"ProfileID":     stringSchema,
+		"Signature":     stringSchema,
+		"Path":          stringSchema,
+		"PeerAddresses": arrayOf(stringSchema),
+	}),
+	"PinStatus": object([]string{"Path", "Pinned"}, map[string]*Schema{
+		"Path":        stringSchema,
+		"Pinned":      boolSchema,
+		"TTL":         timeSchema,
+		"PctComplete": numberSchema,
+		"Status":      stringSchema,
+		"Error":       stringSchema,
+	}),
+
+	"Subscription": object([]string{"url", "events"}, map[string]*Schema{
+		"id":      stringSchema,
+		"url":     stringSchema,
+		"events":  arrayOf(stringSchema),
+		"secret":  stringSchema,
+		"created": timeSchema,
+	}),
+	"DeadLetter": object(nil, map[string]*Schema{
+		"subscriptionID": stringSchema,
+		"url":            stringSchema,
+		"event": object([]string{"id", "type"}, map[string]*Schema{
+			"id":      stringSchema,
+			"type":    stringSchema,
+			"created": timeSchema,
+			"data":    anySchema,
+		}),
+		"attempts": intSchema,
+		"error":    stringSchema,
+		"failed":   timeSchema,
+	}),
+}
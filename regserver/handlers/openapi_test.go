@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+	"github.com/qri-io/registry/search"
+	"github.com/qri-io/registry/webhook"
+)
+
+// newAPITestServer serves a registry with every subsystem enabled except
+// dsync, which needs an IPFS node
+func newAPITestServer(t *testing.T) (*httptest.Server, string) {
+	reg := registry.Registry{
+		Profiles:      registry.NewMemProfiles(),
+		Datasets:      registry.NewMemDatasets(),
+		Organizations: registry.NewMemOrganizations(),
+		Redirects:     registry.NewMemRedirects(),
+		Reputations:   registry.NewMemReputations(),
+		Stats:         registry.NewMemStats(),
+		Events:        registry.NewMemEventLog(),
+	}
+	idx := search.NewIndex()
+	idx.Stats = reg.Stats
+	reg.Profiles = search.Profiles{Profiles: reg.Profiles, Index: idx}
+	reg.Search, reg.Suggester, reg.Indexer = idx, idx, idx
+
+	key := NewAdminKey()
+	s := httptest.NewServer(NewRoutes(reg,
+		AddProtector(NewBAProtector("admin", key)),
+		AddPinset(&pinset.MemPinset{Profiles: reg.Profiles}),
+		AddWebhooks(webhook.NewDispatcher(webhook.NewMemSubscriptions())),
+		AddMetrics(NewMetrics()),
+		AddLimits(Limits{}),
+	))
+	return s, key
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	prevOut := log.Out
+	log.SetOutput(buf)
+	defer log.SetOutput(prevOut)
+
+	m := NewRoutes(registry.Registry{
+		Profiles:      registry.NewMemProfiles(),
+		Datasets:      registry.NewMemDatasets(),
+		Organizations: registry.NewMemOrganizations(),
+		Reputations:   registry.NewMemReputations(),
+		Stats:         registry.NewMemStats(),
+		Indexer:       search.NewIndex(),
+		Search:        search.NewIndex(),
+		Suggester:     search.NewIndex(),
+	}, AddPinset(&pinset.MemPinset{}), AddWebhooks(webhook.NewDispatcher(webhook.NewMemSubscriptions())), AddMetrics(NewMetrics()))
+	if strings.Contains(buf.String(), "missing from the OpenAPI description") {
+		t.Errorf("expected all routes to be documented:\n%s", buf.String())
+	}
+
+	r := httptest.NewRequest("GET", APIPrefix+"/openapi.json", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	doc := &OpenAPI{}
+	if err := json.NewDecoder(w.Body).Decode(doc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for path := range doc.Paths {
+		if path == "/" {
+			continue
+		}
+		example := strings.NewReplacer("{ref}", "b5/cities", "{handle}", "b5", "{profileID}", "QmProfile").Replace(path)
+		_, pattern := m.Handler(httptest.NewRequest("GET", example, nil))
+		_, v1Pattern := m.Handler(httptest.NewRequest("GET", APIPrefix+example, nil))
+		if pattern == "/" || v1Pattern != APIPrefix+pattern {
+			t.Errorf("documented path %s isn't routed. alias: '%s', %s: '%s'", path, pattern, APIVersion, v1Pattern)
+		}
+	}
+	if _, ok := doc.Paths["/dsync"]; ok {
+		t.Error("expected unregistered routes to be left out of the description")
+	}
+
+	for name, s := range doc.Components.Schemas {
+		if err := checkRefs(doc, s); err != nil {
+			t.Errorf("schema %s: %s", name, err.Error())
+		}
+	}
+}
+
+// checkRefs makes sure all schema references resolve
+func checkRefs(doc *OpenAPI, s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if _, err := resolveSchema(doc, s); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.Properties {
+		if err := checkRefs(doc, p); err != nil {
+			return err
+		}
+	}
+	if err := checkRefs(doc, s.Items); err != nil {
+		return err
+	}
+	return checkRefs(doc, s.AdditionalProperties)
+}
+
+func TestOpenAPI(t *testing.T) {
+	s, key := newAPITestServer(t)
+	defer s.Close()
+
+	doc := NewOpenAPI()
+	res, err := http.Get(s.URL + APIPrefix + "/openapi.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := json.NewDecoder(res.Body).Decode(doc); err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+
+	b5, err := registry.ProfileFromPrivateKey("b5", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := ioutil.ReadFile("testdata/cities.dataset.json")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cds := &dataset.Dataset{}
+	if err := json.Unmarshal(data, cds); err != nil {
+		t.Fatal(err.Error())
+	}
+	ds, err := registry.NewDataset(b5.Handle, "cities", cds, privKey1.GetPublic())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mv, err := registry.NewDatasetMove("b5/cities", "b5/world_cities", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dep, err := registry.NewDeprecation("b5/world_cities", "use something else", "", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pin, err := pinset.NewPinRequest("/ipfs/QmPin", privKey1, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		method, path string
+		body         interface{}
+		admin        bool
+		status       int
+	}{
+		{"GET", "/", nil, false, 200},
+		{"GET", "/healthz", nil, false, 200},
+		{"GET", "/readyz", nil, false, 200},
+		{"GET", "/openapi.json", nil, false, 200},
+
+		{"POST", "/profile", b5, false, 200},
+		{"GET", "/profile", &registry.Profile{Handle: "b5"}, false, 200},
+		{"GET", "/profile", &registry.Profile{Handle: "nobody"}, false, 404},
+		{"GET", "/profile?handle=b5", nil, false, 200},
+		{"GET", "/profile/b5", nil, false, 200},
+		{"GET", "/profile/nobody", nil, false, 404},
+		{"POST", "/profile", &registry.Profile{Handle: "b5"}, false, 400},
+		{"POST", "/profile", "not a profile", false, 400},
+		{"GET", "/profiles", nil, false, 200},
+		{"POST", "/profiles", []*registry.Profile{}, false, 401},
+
+		{"GET", "/organization", &registry.Organization{Handle: "none"}, false, 404},
+		{"GET", "/organization?handle=none", nil, false, 404},
+		{"GET", "/organization/none", nil, false, 404},
+		{"POST", "/organization/members", &registry.MembershipChange{Org: "none"}, false, 400},
+
+		{"POST", "/dataset", ds, false, 200},
+		{"GET", "/dataset?ref=b5/cities", nil, false, 200},
+		{"GET", "/dataset/b5/cities", nil, false, 200},
+		{"GET", "/dataset/b5/cities/preview", nil, false, 404},
+		{"GET", "/dataset/b5/missing", nil, false, 404},
+		{"POST", "/dataset/move", mv, false, 200},
+		{"GET", "/dataset/b5/cities", nil, false, 301},
+		{"POST", "/dataset/deprecation", dep, false, 200},
+		{"GET", "/datasets", nil, false, 200},
+		{"GET", "/datasets/trending", nil, false, 200},
+
+		{"GET", "/admin/index", nil, true, 200},
+		{"POST", "/admin/index", nil, false, 401},
+		{"GET", "/search?q=cities", nil, false, 200},
+		{"GET", "/search?boost=nope", nil, false, 400},
+		{"POST", "/search", &registry.SearchParams{Q: "cities"}, false, 200},
+		{"GET", "/search/suggest?q=cit", nil, false, 200},
+		{"GET", "/reputation", &registry.Reputation{ProfileID: b5.ProfileID}, false, 200},
+		{"GET", "/reputation", nil, false, 400},
+		{"GET", "/reputation/" + b5.ProfileID, nil, false, 200},
+
+		{"POST", "/pins", pin, false, 200},
+		{"GET", "/pins", nil, false, 200},
+		{"GET", "/pins/status?path=/ipfs/QmPin", nil, false, 200},
+		{"GET", "/pins/status?path=/ipfs/QmMissing", nil, false, 404},
+		{"DELETE", "/pins", pin, false, 200},
+		{"GET", "/metrics", nil, false, 200},
+
+		{"POST", "/webhooks", &webhook.Subscription{URL: "http://localhost/hook", Secret: "shh", Events: []webhook.EventType{webhook.EventDatasetPublished}}, true, 200},
+		{"POST", "/webhooks", &webhook.Subscription{}, true, 400},
+		{"GET", "/webhooks", nil, true, 200},
+		{"DELETE", "/webhooks?id=missing", nil, true, 404},
+		{"GET", "/webhooks/deadletters", nil, true, 200},
+	}
+
+	cli := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	covered := map[string]bool{}
+	for i, c := range cases {
+		for _, prefix := range []string{APIPrefix, ""} {
+			var body []byte
+			if c.body != nil {
+				if body, err = json.Marshal(c.body); err != nil {
+					t.Fatal(err.Error())
+				}
+			}
+			req, err := http.NewRequest(c.method, s.URL+prefix+c.path, bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if c.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if c.admin {
+				req.SetBasicAuth("admin", key)
+			}
+			res, err := cli.Do(req)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			resBody, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+
+			if res.StatusCode != c.status {
+				t.Errorf("case %d %s %s: expected status %d, got %d: %s", i, c.method, prefix+c.path, c.status, res.StatusCode, resBody)
+				continue
+			}
+			path, err := validateResponse(doc, c.method, c.path, res, resBody)
+			if err != nil {
+				t.Errorf("case %d %s %s: %s", i, c.method, prefix+c.path, err.Error())
+			}
+			covered[path] = true
+
+			// mutations only succeed once, run them against the v1 path only
+			if c.method != "GET" && c.status == 200 {
+				break
+			}
+		}
+	}
+
+	for path := range doc.Paths {
+		if !covered[path] {
+			t.Errorf("no responses checked for %s", path)
+		}
+	}
+
+	res, err = cli.Get(s.URL + APIPrefix + "/dataset/b5/cities")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+	if loc := res.Header.Get("Location"); loc != APIPrefix+"/dataset/b5/world_cities" {
+		t.Errorf("expected redirect to stay on the versioned path, got: %s", loc)
+	}
+}
+
+// validateResponse checks a response against the documented response for a
+// request, returning the documented path the request matched
+func validateResponse(doc *OpenAPI, method, reqPath string, res *http.Response, body []byte) (string, error) {
+	path, item := matchPath(doc, strings.Split(reqPath, "?")[0])
+	if item == nil {
+		return "", fmt.Errorf("undocumented path")
+	}
+	op, ok := item[strings.ToLower(method)]
+	if !ok {
+		return path, fmt.Errorf("undocumented method for %s", path)
+	}
+	r, ok := op.Responses[strconv.Itoa(res.StatusCode)]
+	if !ok {
+		return path, fmt.Errorf("undocumented status %d for %s %s", res.StatusCode, method, path)
+	}
+	if len(r.Content) == 0 {
+		return path, nil
+	}
+
+	ct := res.Header.Get("Content-Type")
+	for mt, media := range r.Content {
+		if !strings.HasPrefix(ct, mt) {
+			continue
+		}
+		if media.Schema == nil || mt != "application/json" {
+			return path, nil
+		}
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return path, err
+		}
+		return path, validateSchema(doc, media.Schema, v, "response")
+	}
+	return path, fmt.Errorf("undocumented content type '%s' for %s %s", ct, method, path)
+}
+
+// matchPath finds the documented path for a request path. Path parameters
+// may contain slashes, so the path with the most literal characters wins
+func matchPath(doc *OpenAPI, reqPath string) (string, PathItem) {
+	param := regexp.MustCompile(`\{[^}]+\}`)
+	best, literal := "", -1
+	for path := range doc.Paths {
+		parts := param.Split(path, -1)
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		if regexp.MustCompile("^"+strings.Join(parts, ".+")+"$").MatchString(reqPath) && len(param.ReplaceAllString(path, "")) > literal {
+			best, literal = path, len(param.ReplaceAllString(path, ""))
+		}
+	}
+	return best, doc.Paths[best]
+}
+
+func resolveSchema(doc *OpenAPI, s *Schema) (*Schema, error) {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		resolved, ok := doc.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema reference: %s", s.Ref)
+		}
+		s = resolved
+	}
+	return s, nil
+}
+
+// validateSchema checks v against s. Objects with documented properties may
+// not have undocumented ones, so the description can't drift from handlers
+func validateSchema(doc *OpenAPI, s *Schema, v interface{}, at string) error {
+	s, err := resolveSchema(doc, s)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: expected %s, got null", at, s.Type)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", at, name)
+			}
+		}
+		for name, val := range obj {
+			ps, ok := s.Properties[name]
+			if !ok {
+				ps = s.AdditionalProperties
+			}
+			if ps == nil {
+				if len(s.Properties) > 0 {
+					return fmt.Errorf("%s: undocumented property %s", at, name)
+				}
+				continue
+			}
+			if err := validateSchema(doc, ps, val, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, v)
+		}
+		for i, item := range arr {
+			if err := validateSchema(doc, s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", at, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, v)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported schema type %s", at, s.Type)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+)
+
+// NewOrganizationHandler creates an organization handler func that operates
+// on a registry.Organizations. profiles is used to prevent organizations
+// from claiming profile handles, and may be nil
+func NewOrganizationHandler(orgs registry.Organizations, profiles registry.Profiles) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o := &registry.Organization{}
+		switch r.Method {
+		case "GET":
+			o.Handle = pathSuffix(r, "/organization/")
+			if o.Handle == "" {
+				o.Handle = r.FormValue("handle")
+			}
+			if o.Handle == "" {
+				if err := readJSONQuery(r, o); err != nil {
+					writeErr(w, r, err)
+					return
+				}
+			}
+			if o.Handle == "" {
+				writeErr(w, r, registry.NewError(registry.ErrInvalid, "handle is required"))
+				return
+			}
+			var ok bool
+			if o, ok = orgs.Load(o.Handle); !ok {
+				writeErr(w, r, registry.NewError(registry.ErrNotFound, "organization not found"))
+				return
+			}
+		case "PUT", "POST":
+			if !readJSON(w, r, o) {
+				return
+			}
+			if err := registry.RegisterOrganization(orgs, profiles, o); err != nil {
+				writeErr(w, r, err)
+				return
+			}
+			o, _ = orgs.Load(o.Handle)
+		case "DELETE":
+			if !readJSON(w, r, o) {
+				return
+			}
+			if err := registry.DeregisterOrganization(orgs, o); err != nil {
+				writeErr(w, r, err)
+				return
+			}
+		default:
+			methodNotAllowed(w, r, "GET", "PUT", "POST", "DELETE")
+			return
+		}
+
+		apiutil.WriteResponse(w, o)
+	}
+}
+
+// NewMembershipHandler creates a handler func that applies signed membership
+// changes to organizations
+func NewMembershipHandler(orgs registry.Organizations) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" && r.Method != "PUT" {
+			methodNotAllowed(w, r, "PUT", "POST")
+			return
+		}
+
+		mc := &registry.MembershipChange{}
+		if !readJSON(w, r, mc) {
+			return
+		}
+
+		o, err := registry.UpdateMembership(orgs, mc)
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
+		apiutil.WriteResponse(w, o)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
 	"github.com/qri-io/registry/pinset"
 )
 
@@ -12,38 +13,46 @@ import (
 // on a *registry.Profiles
 func NewPinsHandler(ps pinset.Pinset) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var status pinset.PinStatus
-
-		req, err := parsePinReq(r)
-		if err != nil {
-			apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-			return
-		}
-
 		switch r.Method {
 		case "POST":
+			req, err := parsePinReq(r)
+			if err != nil {
+				writeErr(w, r, err)
+				return
+			}
 			statusChan, err := ps.Pin(req)
 			if err != nil {
-				apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+				writeErr(w, r, err)
 				return
 			}
-			status = <-statusChan
+			status := <-statusChan
+			// keep draining so the pin job can run to completion
+			go func() {
+				for range statusChan {
+				}
+			}()
 			apiutil.WriteResponse(w, status)
-			return
 		case "DELETE":
+			req, err := parsePinReq(r)
+			if err != nil {
+				writeErr(w, r, err)
+				return
+			}
 			if err = ps.Unpin(req); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+				writeErr(w, r, err)
+				return
 			}
+			apiutil.WriteResponse(w, pinset.PinStatus{Path: req.Path})
 		case "GET":
 			p := apiutil.PageFromRequest(r)
 			pins, err := ps.Pins(p.Limit(), p.Offset())
 			if err != nil {
-				apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+				writeErr(w, r, err)
 				return
 			}
 			apiutil.WriteResponse(w, pins)
 		default:
-			apiutil.NotFoundHandler(w, r)
+			methodNotAllowed(w, r, "GET", "POST", "DELETE")
 		}
 	}
 }
@@ -51,19 +60,20 @@ func NewPinsHandler(ps pinset.Pinset) http.HandlerFunc {
 // NewPinStatusHandler creates a handler for getting the pin status of a hash
 func NewPinStatusHandler(ps pinset.Pinset) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
+			return
+		}
+
 		req, err := parsePinReq(r)
 		if err != nil {
-			apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			writeErr(w, r, err)
 			return
 		}
 
 		status, err := ps.Status(req)
 		if err != nil {
-			if err.Error() == "not found" {
-				apiutil.WriteErrResponse(w, http.StatusNotFound, err)
-			} else {
-				apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
-			}
+			writeErr(w, r, err)
 			return
 		}
 
@@ -71,15 +81,18 @@ func NewPinStatusHandler(ps pinset.Pinset) http.HandlerFunc {
 	}
 }
 
-func parsePinReq(r *http.Request) (req *pinset.PinRequest, err error) {
-	req = &pinset.PinRequest{}
-
-	switch r.Header.Get("Content-Type") {
-	case "application/json":
-		err = json.NewDecoder(r.Body).Decode(req)
-	default:
+// parsePinReq reads a pin request from a JSON body or the path query param
+func parsePinReq(r *http.Request) (*pinset.PinRequest, error) {
+	req := &pinset.PinRequest{}
+	if isJSON(r) {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, registry.NewError(registry.ErrInvalid, "invalid request body: %s", err.Error())
+		}
+	} else {
 		req.Path = r.FormValue("path")
 	}
-
-	return
+	if req.Path == "" {
+		return nil, registry.NewError(registry.ErrInvalid, "path is required")
+	}
+	return req, nil
 }
@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/qri-io/apiutil"
@@ -16,15 +14,7 @@ func NewProfilesHandler(profiles registry.Profiles) http.HandlerFunc {
 		switch r.Method {
 		case "POST":
 			ps := []*registry.Profile{}
-			switch r.Header.Get("Content-Type") {
-			case "application/json":
-				if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
-					apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-					return
-				}
-			default:
-				err := fmt.Errorf("Content-Type must be application/json")
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			if !readJSON(w, r, &ps) {
 				return
 			}
 
@@ -43,61 +33,84 @@ func NewProfilesHandler(profiles registry.Profiles) http.HandlerFunc {
 			})
 
 			apiutil.WriteResponse(w, ps)
+		default:
+			methodNotAllowed(w, r, "GET", "POST")
 		}
 	}
 }
 
 // NewProfileHandler creates a profile handler func that operats on
-// a *registry.Profiles
-func NewProfileHandler(profiles registry.Profiles) http.HandlerFunc {
+// a *registry.Profiles. GET looks up a profile, other methods take a signed
+// profile body. opts are passed along to registration calls
+func NewProfileHandler(profiles registry.Profiles, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		p := &registry.Profile{}
-		switch r.Header.Get("Content-Type") {
-		case "application/json":
-			if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-				return
-			}
-		default:
-			err := fmt.Errorf("Content-Type must be application/json")
-			apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-			return
-		}
-
 		switch r.Method {
 		case "GET":
-			var ok bool
-			if p.Handle != "" {
-				p, ok = profiles.Load(p.Handle)
-			} else {
-				profiles.Range(func(handle string, profile *registry.Profile) bool {
-					if profile.ProfileID == p.ProfileID || profile.PublicKey == p.PublicKey {
-						p = profile
-						ok = true
-						return true
-					}
-					return false
-				})
-			}
-			if !ok {
-				apiutil.NotFoundHandler(w, r)
-				return
-			}
-		case "PUT", "POST":
-			if err := registry.RegisterProfile(profiles, p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-				return
-			}
-		case "DELETE":
-			if err := registry.DeregisterProfile(profiles, p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			p, err := lookupProfile(profiles, r)
+			if err != nil {
+				writeErr(w, r, err)
 				return
 			}
+			apiutil.WriteResponse(w, p)
+			return
+		case "PUT", "POST", "DELETE":
 		default:
-			apiutil.NotFoundHandler(w, r)
+			methodNotAllowed(w, r, "GET", "PUT", "POST", "DELETE")
 			return
 		}
 
+		p := &registry.Profile{}
+		if !readJSON(w, r, p) {
+			return
+		}
+		var err error
+		if r.Method == "DELETE" {
+			err = registry.DeregisterProfile(profiles, p)
+		} else {
+			err = registry.RegisterProfile(profiles, p, opts...)
+		}
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
 		apiutil.WriteResponse(w, p)
 	}
 }
+
+// lookupProfile finds the profile a request asks for by handle, either from
+// the path (/profile/<handle>) or a query param, or by profileID or
+// publicKey query params
+func lookupProfile(profiles registry.Profiles, r *http.Request) (*registry.Profile, error) {
+	q := &registry.Profile{
+		Handle:    pathSuffix(r, "/profile/"),
+		ProfileID: r.FormValue("profileID"),
+		PublicKey: r.FormValue("publicKey"),
+	}
+	if q.Handle == "" {
+		q.Handle = r.FormValue("handle")
+	}
+	if *q == (registry.Profile{}) {
+		if err := readJSONQuery(r, q); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		p  *registry.Profile
+		ok bool
+	)
+	switch {
+	case q.Handle != "":
+		p, ok = profiles.Load(q.Handle)
+	case q.ProfileID != "" || q.PublicKey != "":
+		if p, ok = profiles.LoadByProfileID(q.ProfileID); !ok {
+			p, ok = profiles.LoadByPublicKey(q.PublicKey)
+		}
+	default:
+		return nil, registry.NewError(registry.ErrInvalid, "handle, profileID or publicKey is required")
+	}
+	if !ok {
+		return nil, registry.NewError(registry.ErrNotFound, "profile not found")
+	}
+	return p, nil
+}
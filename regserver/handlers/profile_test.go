@@ -98,10 +98,10 @@ func TestProfile(t *testing.T) {
 		resStatus   int
 		res         *env
 	}{
-		{"OPTIONS", "/profile", "", nil, http.StatusBadRequest, nil},
-		{"OPTIONS", "/profile", "application/json", nil, http.StatusBadRequest, nil},
-		{"OPTIONS", "/profile", "application/json", &registry.Profile{Handle: "foo"}, http.StatusNotFound, nil},
-		{"POST", "/profile", "", nil, http.StatusBadRequest, nil},
+		{"OPTIONS", "/profile", "", nil, http.StatusMethodNotAllowed, nil},
+		{"OPTIONS", "/profile", "application/json", nil, http.StatusMethodNotAllowed, nil},
+		{"OPTIONS", "/profile", "application/json", &registry.Profile{Handle: "foo"}, http.StatusMethodNotAllowed, nil},
+		{"POST", "/profile", "", nil, http.StatusUnsupportedMediaType, nil},
 		{"POST", "/profile", "application/json", nil, http.StatusBadRequest, nil},
 		{"POST", "/profile", "application/json", &registry.Profile{Handle: p1.Handle}, http.StatusBadRequest, nil},
 		{"POST", "/profile", "application/json", &registry.Profile{Handle: p1.Handle, ProfileID: p1.ProfileID}, http.StatusBadRequest, nil},
@@ -112,11 +112,16 @@ func TestProfile(t *testing.T) {
 		{"GET", "/profile", "application/json", &registry.Profile{Handle: "b6"}, http.StatusNotFound, nil},
 		{"GET", "/profile", "application/json", &registry.Profile{ProfileID: b5.ProfileID}, http.StatusOK, nil},
 		{"GET", "/profile", "application/json", &registry.Profile{ProfileID: "fooooo"}, http.StatusNotFound, nil},
+		{"GET", "/profile", "", nil, http.StatusBadRequest, nil},
+		{"GET", "/profile/b5", "", nil, http.StatusOK, &env{Data: b5}},
+		{"GET", "/profile/b6", "", nil, http.StatusNotFound, nil},
+		{"GET", "/profile?handle=b5", "", nil, http.StatusOK, &env{Data: b5}},
+		{"GET", "/profile?profileID=" + b5.ProfileID, "", nil, http.StatusOK, &env{Data: b5}},
 		{"POST", "/profile", "application/json", p1, http.StatusOK, nil},
-		{"POST", "/profile", "application/json", p2, http.StatusBadRequest, nil},
+		{"POST", "/profile", "application/json", p2, http.StatusConflict, nil},
 		{"POST", "/profile", "application/json", p1Rename, http.StatusOK, nil},
 		{"GET", "/profile", "application/json", &registry.Profile{Handle: b6.Handle}, http.StatusOK, &env{Data: b6}},
-		{"DELETE", "/profile", "", p1Rename, http.StatusBadRequest, nil},
+		{"DELETE", "/profile", "", p1Rename, http.StatusUnsupportedMediaType, nil},
 		{"DELETE", "/profile", "application/json", nil, http.StatusBadRequest, nil},
 		{"DELETE", "/profile", "application/json", &registry.Profile{Handle: p1.Handle, ProfileID: p1.ProfileID, Signature: p1.Signature}, http.StatusBadRequest, nil},
 		{"DELETE", "/profile", "application/json", p1Rename, http.StatusOK, nil},
@@ -37,8 +37,9 @@ func (ba BAProtector) ProtectMethods(methods ...string) func(http.HandlerFunc) h
 				if r.Method == m || m == "*" {
 					username, password, set := r.BasicAuth()
 					if !set || username != ba.username || password != ba.password {
-						log.Infof("invalid key")
-						apiutil.WriteErrResponse(w, http.StatusForbidden, errors.New("invalid key"))
+						requestLog(r).Info("invalid key")
+						w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+						apiutil.WriteErrResponse(w, http.StatusUnauthorized, errors.New("invalid key"))
 						return
 					}
 				}
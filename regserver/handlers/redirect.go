@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+)
+
+// NewDatasetMoveHandler creates a handler func that applies signed dataset
+// moves. opts are passed along to registry.MoveDataset. If opts configure
+// Stats, dataset stats follow moved datasets
+func NewDatasetMoveHandler(datasets registry.Datasets, idxr registry.Indexer, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
+	o := &registry.RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	uow := registry.NewUnitOfWork(datasets, idxr, opts...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" && r.Method != "PUT" {
+			methodNotAllowed(w, r, "PUT", "POST")
+			return
+		}
+
+		mv := &registry.DatasetMove{}
+		if !readJSON(w, r, mv) {
+			return
+		}
+
+		var d *registry.Dataset
+		err := uow.Apply(registry.EventDatasetMoved, func(datasets registry.Datasets, opts ...func(o *registry.RegisterOptions)) (err error) {
+			d, err = registry.MoveDataset(datasets, mv, opts...)
+			return err
+		})
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
+		if o.Stats != nil {
+			// moved after the move commits, so rolled back moves keep stats
+			o.Stats.Move(mv.From, d.Key())
+		}
+		apiutil.WriteResponse(w, d)
+	}
+}
+
+// NewDeprecationHandler creates a handler func that deprecates datasets on
+// POST & removes deprecations on DELETE, both with a signed
+// registry.Deprecation body
+func NewDeprecationHandler(datasets registry.Datasets, idxr registry.Indexer, opts ...func(o *registry.RegisterOptions)) http.HandlerFunc {
+	uow := registry.NewUnitOfWork(datasets, idxr, opts...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT", "POST", "DELETE":
+		default:
+			methodNotAllowed(w, r, "PUT", "POST", "DELETE")
+			return
+		}
+
+		dep := &registry.Deprecation{}
+		if !readJSON(w, r, dep) {
+			return
+		}
+
+		var (
+			d   *registry.Dataset
+			err error
+		)
+		switch r.Method {
+		case "PUT", "POST":
+			err = uow.Apply(registry.EventDatasetDeprecated, func(datasets registry.Datasets, opts ...func(o *registry.RegisterOptions)) (err error) {
+				d, err = registry.DeprecateDataset(datasets, dep, opts...)
+				return err
+			})
+		case "DELETE":
+			err = uow.Apply(registry.EventDatasetUndeprecated, func(datasets registry.Datasets, opts ...func(o *registry.RegisterOptions)) (err error) {
+				d, err = registry.UndeprecateDataset(datasets, dep, opts...)
+				return err
+			})
+		}
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
+		apiutil.WriteResponse(w, d)
+	}
+}
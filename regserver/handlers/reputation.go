@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -10,40 +8,41 @@ import (
 	"github.com/qri-io/registry"
 )
 
-// NewReputationHandler creates a profile handler func that operates on a *registry.Reputations
+// NewReputationHandler creates a profile handler func that operates on a *registry.Reputations.
+// profiles are looked up by /reputation/<profileID>, a profileID query param
+// or a JSON body
 func NewReputationHandler(rs registry.Reputations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rep := &registry.Reputation{}
-		switch r.Header.Get("Content-Type") {
-		case "application/json":
-			if err := json.NewDecoder(r.Body).Decode(rep); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
-				return
-			}
-		default:
-			err := fmt.Errorf("Content-Type must be application/json")
-			apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
 			return
 		}
 
-		switch r.Method {
-		case "GET":
-			var ok bool
-			// TODO: finesse
-			// For now, if no reputation is found, create a new reputation
-			// add it to the list of reputations, and return the new reputation
-			profileID := rep.ProfileID
-			if profileID != "" {
-				rep, ok = rs.Load(profileID)
-				if !ok {
-					rep = registry.NewReputation(profileID)
-					rs.Add(rep)
-				}
+		profileID := pathSuffix(r, "/reputation/")
+		if profileID == "" {
+			profileID = r.FormValue("profileID")
+		}
+		if profileID == "" {
+			req := &registry.Reputation{}
+			if err := readJSONQuery(r, req); err != nil {
+				writeErr(w, r, err)
+				return
 			}
-		default:
-			apiutil.NotFoundHandler(w, r)
+			profileID = req.ProfileID
+		}
+		if profileID == "" {
+			writeErr(w, r, registry.NewError(registry.ErrInvalid, "profileID is required"))
 			return
 		}
+
+		// TODO: finesse
+		// For now, if no reputation is found, create a new reputation
+		// add it to the list of reputations, and return the new reputation
+		rep, ok := rs.Load(profileID)
+		if !ok {
+			rep = registry.NewReputation(profileID)
+			rs.Add(rep)
+		}
 		res := registry.ReputationResponse{
 			Reputation: rep,
 			Expiration: time.Hour * 24,
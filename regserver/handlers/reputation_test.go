@@ -54,14 +54,16 @@ func TestReputation(t *testing.T) {
 		resStatus   int
 		reputation  *registry.Reputation
 	}{
-		{"BAD_METHOD", "/reputation", "", "", http.StatusBadRequest, nil},
-		{"BAD_METHOD", "/reputation", "application/json", "", http.StatusBadRequest, nil},
-		{"BAD_METHOD", "/reputation", "application/json", "my_id", http.StatusNotFound, nil},
+		{"BAD_METHOD", "/reputation", "", "", http.StatusMethodNotAllowed, nil},
+		{"BAD_METHOD", "/reputation", "application/json", "", http.StatusMethodNotAllowed, nil},
+		{"BAD_METHOD", "/reputation", "application/json", "my_id", http.StatusMethodNotAllowed, nil},
 		{"GET", "/reputation", "", "", http.StatusBadRequest, nil},
 		{"GET", "/reputation", "application/json", "", http.StatusBadRequest, nil},
 		{"GET", "/reputation", "application/json", "freshRep", http.StatusOK, freshRep},
 		{"GET", "/reputation", "application/json", "badRep", http.StatusOK, badRep},
 		{"GET", "/reputation", "application/json", "goodRep", http.StatusOK, goodRep},
+		{"GET", "/reputation/goodRep", "", "", http.StatusOK, goodRep},
+		{"GET", "/reputation?profileID=badRep", "", "", http.StatusOK, badRep},
 	}
 
 	for i, c := range cases {
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/qri-io/apiutil"
 	"github.com/qri-io/registry"
@@ -13,20 +16,25 @@ const (
 	defaultLimit  = 25
 )
 
-// NewSearchHandler creates a search handler function taht operates on a *registry.Searchable
+// NewSearchHandler creates a search handler function taht operates on a *registry.Searchable.
+// GET & POST both search, taking params from a JSON body or the query string
 func NewSearchHandler(s registry.Searchable) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "POST" {
+			methodNotAllowed(w, r, "GET", "POST")
+			return
+		}
+
 		p := &registry.SearchParams{}
-		switch r.Header.Get("Content-Type") {
-		case "application/json":
+		if isJSON(r) {
 			if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+				apiutil.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %s", err.Error()))
 				return
 			}
 			if p.Limit == 0 {
 				p.Limit = defaultLimit
 			}
-		default:
+		} else {
 			// read form values
 			var err error
 			if p.Limit, err = apiutil.ReqParamInt("limit", r); err != nil {
@@ -38,16 +46,41 @@ func NewSearchHandler(s registry.Searchable) http.HandlerFunc {
 				err = nil
 			}
 			p.Q = r.FormValue("q")
-		}
-		switch r.Method {
-		case "GET":
-			results, err := s.Search(*p)
-			if err != nil {
-				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+			p.Column = r.FormValue("column")
+			p.ColumnType = r.FormValue("columnType")
+			p.Sort = r.FormValue("sort")
+			if p.Boosts, err = parseBoosts(r.FormValue("boost")); err != nil {
+				writeErr(w, r, err)
 				return
 			}
-			apiutil.WriteResponse(w, results)
+		}
+
+		results, err := s.Search(*p)
+		if err != nil {
+			writeErr(w, r, err)
 			return
 		}
+		apiutil.WriteResponse(w, results)
+	}
+}
+
+// parseBoosts parses a comma-separated list of field:weight pairs, like
+// "title:3,description:0.5"
+func parseBoosts(s string) (map[string]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	boosts := map[string]float64{}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return nil, registry.NewError(registry.ErrInvalid, "invalid boost: '%s', must be in the form field:weight", pair)
+		}
+		b, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return nil, registry.NewError(registry.ErrInvalid, "invalid boost weight for '%s': %s", kv[0], kv[1])
+		}
+		boosts[kv[0]] = b
 	}
+	return boosts, nil
 }
@@ -27,7 +27,9 @@ func TestSearch(t *testing.T) {
 		params      *registry.SearchParams
 		resStatus   int
 	}{
-		{"GET", "/search", "application/json", &registry.SearchParams{Q: "abc", Limit: 0, Offset: 100}, 400},
+		{"GET", "/search", "application/json", &registry.SearchParams{Q: "abc", Limit: 0, Offset: 100}, 501},
+		{"POST", "/search", "application/json", &registry.SearchParams{Q: "abc"}, 501},
+		{"PUT", "/search", "application/json", &registry.SearchParams{Q: "abc"}, 405},
 	}
 
 	for i, c := range cases {
@@ -60,3 +62,21 @@ func TestSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestParseBoosts(t *testing.T) {
+	b, err := parseBoosts("title:3,description:0.5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if b["title"] != 3 || b["description"] != 0.5 {
+		t.Errorf("boost mismatch. got: %v", b)
+	}
+	if b, err := parseBoosts(""); err != nil || b != nil {
+		t.Errorf("expected empty boosts to parse as nil")
+	}
+	for _, s := range []string{"title", "title:high"} {
+		if _, err := parseBoosts(s); err == nil {
+			t.Errorf("expected '%s' to error", s)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+// DefaultTrendingDays is the default window in days for trending datasets
+const DefaultTrendingDays = 7
+
+// NewTrendingHandler creates a handler func that lists datasets by
+// popularity over a window of days, set with the "days" query param
+func NewTrendingHandler(datasets registry.Datasets, stats registry.Stats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
+			return
+		}
+
+		days, err := apiutil.ReqParamInt("days", r)
+		if err != nil || days <= 0 {
+			days = DefaultTrendingDays
+		}
+		p := apiutil.PageFromRequest(r)
+		since := time.Now().AddDate(0, 0, -days)
+
+		res := []*registry.Dataset{}
+		for _, s := range stats.Trending(since, 0) {
+			ds, ok := datasets.Load(s.Key)
+			if !ok {
+				continue
+			}
+			d := *ds
+			d.Preview = nil
+			d.Stats = s
+			res = append(res, &d)
+		}
+
+		if p.Offset() >= len(res) {
+			res = res[:0]
+		} else {
+			res = res[p.Offset():]
+		}
+		if len(res) > p.Limit() {
+			res = res[:p.Limit()]
+		}
+
+		apiutil.WriteResponse(w, res)
+	}
+}
+
+// countFetches wraps a dsync handler, counting manifest fetches of
+// registered datasets
+func countFetches(datasets registry.Datasets, stats registry.Stats, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id := r.FormValue("manifest"); r.Method == "GET" && id != "" {
+			if ds, ok := lookupPath(datasets, id); ok {
+				stats.Record(ds.Key(), registry.StatFetch, time.Now())
+			}
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+// statsPinset wraps a pinset, counting pins of registered datasets
+type statsPinset struct {
+	pinset.Pinset
+	datasets registry.Datasets
+	stats    registry.Stats
+}
+
+// Pin calls the underlying pinset, counting a pin if the path belongs to a
+// registered dataset
+func (ps statsPinset) Pin(req *pinset.PinRequest) (chan pinset.PinStatus, error) {
+	c, err := ps.Pinset.Pin(req)
+	if err != nil {
+		return c, err
+	}
+	if ds, ok := lookupPath(ps.datasets, req.Path); ok {
+		ps.stats.Record(ds.Key(), registry.StatPin, time.Now())
+	}
+	return c, nil
+}
+
+// lookupPath finds a dataset by path, accepting paths with or without an
+// /ipfs/ prefix
+func lookupPath(datasets registry.Datasets, path string) (*registry.Dataset, bool) {
+	if ds, ok := datasets.LoadByPath(path); ok {
+		return ds, true
+	}
+	return datasets.LoadByPath("/ipfs/" + path)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+func TestStatsCounting(t *testing.T) {
+	datasets := registry.NewMemDatasets()
+	datasets.Store("b5/cities", &registry.Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities"})
+	stats := registry.NewMemStats()
+
+	ps := statsPinset{Pinset: &pinset.MemPinset{}, datasets: datasets, stats: stats}
+	c, err := ps.Pin(&pinset.PinRequest{Path: "/ipfs/QmCities"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	<-c
+	if _, err := ps.Pin(&pinset.PinRequest{Path: "/ipfs/QmUnregistered"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	h := countFetches(datasets, stats, func(w http.ResponseWriter, r *http.Request) {})
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/dsync?manifest=QmCities", nil))
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/dsync?block=QmCities", nil))
+
+	s, ok := stats.Load("b5/cities")
+	if !ok {
+		t.Fatal("expected stats to be recorded")
+	}
+	if s.Pins != 1 || s.Fetches != 1 {
+		t.Errorf("expected 1 pin & 1 fetch, got: %d pins, %d fetches", s.Pins, s.Fetches)
+	}
+}
+
+func TestMoveKeepsStats(t *testing.T) {
+	b5, err := registry.ProfileFromPrivateKey("b5", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	datasets := registry.NewMemDatasets()
+	datasets.Store("b5/cities", &registry.Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities", ProfileID: b5.ProfileID})
+	stats := registry.NewMemStats()
+	stats.Record("b5/cities", registry.StatLookup, time.Now())
+
+	mv, err := registry.NewDatasetMove("b5/cities", "b5/towns", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	body, err := json.Marshal(mv)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r := httptest.NewRequest("POST", "/dataset/move", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewDatasetMoveHandler(datasets, nil, registry.WithStats(stats))(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	if _, ok := stats.Load("b5/cities"); ok {
+		t.Errorf("expected stats to leave the previous key")
+	}
+	if s, ok := stats.Load("b5/towns"); !ok || s.Lookups != 1 {
+		t.Errorf("expected stats to follow the moved dataset, got: %v", s)
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+)
+
+// NewSuggestHandler creates a handler func that returns autocomplete
+// suggestions for the "q" query param, up to "limit" results
+func NewSuggestHandler(s registry.Suggester) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
+			return
+		}
+
+		limit, err := apiutil.ReqParamInt("limit", r)
+		if err != nil {
+			limit = 0
+		}
+		res, err := s.Suggest(r.FormValue("q"), limit)
+		if err != nil {
+			writeErr(w, r, err)
+			return
+		}
+		apiutil.WriteResponse(w, res)
+	}
+}
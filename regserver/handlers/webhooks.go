@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/qri-io/apiutil"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/webhook"
+)
+
+// NewWebhooksHandler creates a handler for managing webhook subscriptions.
+// GET lists subscriptions, POST adds a subscription & DELETE removes one by
+// id. Subscriptions carry secrets, this handler should always be protected.
+// secrets are only ever returned in the response that creates them
+func NewWebhooksHandler(d *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			p := apiutil.PageFromRequest(r)
+			offset := p.Offset()
+			limit := p.Limit()
+			subs := make([]*webhook.Subscription, 0, limit)
+
+			d.Subscriptions.SortedRange(func(id string, s *webhook.Subscription) bool {
+				if offset > 0 {
+					offset--
+					return false
+				}
+				if len(subs) == limit {
+					return true
+				}
+				subs = append(subs, s.Redacted())
+				return false
+			})
+
+			apiutil.WriteResponse(w, subs)
+		case "POST":
+			s := &webhook.Subscription{}
+			if !readJSON(w, r, s) {
+				return
+			}
+			if err := s.Validate(); err != nil {
+				apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+
+			s.ID = webhook.NewID()
+			s.Created = time.Now()
+			d.Subscriptions.Store(s.ID, s)
+			apiutil.WriteResponse(w, s)
+		case "DELETE":
+			id := r.FormValue("id")
+			s, ok := d.Subscriptions.Load(id)
+			if !ok {
+				writeErr(w, r, registry.NewError(registry.ErrNotFound, "subscription '%s' not found", id))
+				return
+			}
+			d.Subscriptions.Delete(id)
+			apiutil.WriteResponse(w, s.Redacted())
+		default:
+			methodNotAllowed(w, r, "GET", "POST", "DELETE")
+		}
+	}
+}
+
+// NewWebhookDeadLettersHandler creates a handler that lists deliveries
+// that failed after exhausting all retries
+func NewWebhookDeadLettersHandler(d *webhook.Dispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			methodNotAllowed(w, r, "GET")
+			return
+		}
+		p := apiutil.PageFromRequest(r)
+		apiutil.WriteResponse(w, d.DeadLetters.List(p.Limit(), p.Offset()))
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/webhook"
+)
+
+func TestWebhooks(t *testing.T) {
+	received := make(chan string, 10)
+	rec := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(webhook.EventHeader)
+	}))
+	defer rec.Close()
+
+	d := webhook.NewDispatcher(webhook.NewMemSubscriptions())
+	pro := NewBAProtector("username", "password")
+	reg := registry.Registry{Profiles: registry.NewMemProfiles(), Datasets: registry.NewMemDatasets()}
+	s := httptest.NewServer(NewRoutes(reg, AddWebhooks(d), AddProtector(pro)))
+	defer s.Close()
+
+	sub := &webhook.Subscription{
+		URL:    rec.URL,
+		Events: []webhook.EventType{webhook.EventProfileChanged},
+		Secret: "secret",
+	}
+	data, err := json.Marshal(sub)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		method, endpoint string
+		body             []byte
+		auth             bool
+		resStatus        int
+	}{
+		{"POST", "/webhooks", data, false, http.StatusUnauthorized},
+		{"GET", "/webhooks", nil, false, http.StatusUnauthorized},
+		{"POST", "/webhooks", []byte(`{}`), true, http.StatusBadRequest},
+		{"POST", "/webhooks", data, true, http.StatusOK},
+		{"GET", "/webhooks", nil, true, http.StatusOK},
+		{"GET", "/webhooks/deadletters", nil, true, http.StatusOK},
+		{"DELETE", "/webhooks?id=unknown", nil, true, http.StatusNotFound},
+	}
+
+	for i, c := range cases {
+		req, err := http.NewRequest(c.method, fmt.Sprintf("%s%s", s.URL, c.endpoint), bytes.NewReader(c.body))
+		if err != nil {
+			t.Errorf("case %d error creating request: %s", i, err.Error())
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.auth {
+			req.SetBasicAuth("username", "password")
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		if res.StatusCode != c.resStatus {
+			t.Errorf("case %d res status mismatch. expected: %d, got: %d", i, c.resStatus, res.StatusCode)
+		}
+	}
+
+	if d.Subscriptions.Len() != 1 {
+		t.Fatalf("expected 1 subscription, got: %d", d.Subscriptions.Len())
+	}
+
+	var created *webhook.Subscription
+	d.Subscriptions.Range(func(id string, s *webhook.Subscription) bool {
+		created = s
+		return true
+	})
+	for _, c := range []struct{ method, endpoint string }{
+		{"GET", "/webhooks"},
+		{"DELETE", "/webhooks?id=" + created.ID},
+	} {
+		req, err := http.NewRequest(c.method, s.URL+c.endpoint, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		req.SetBasicAuth("username", "password")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if bytes.Contains(body, []byte(sub.Secret)) {
+			t.Errorf("%s %s: expected response to omit the subscription secret, got: %s", c.method, c.endpoint, body)
+		}
+	}
+	d.Subscriptions.Store(created.ID, created)
+
+	p, err := registry.ProfileFromPrivateKey("b5", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err = json.Marshal(p)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/profile", s.URL), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	d.Wait()
+
+	select {
+	case et := <-received:
+		if et != string(webhook.EventProfileChanged) {
+			t.Errorf("event type mismatch. expected: %s, got: %s", webhook.EventProfileChanged, et)
+		}
+	default:
+		t.Errorf("expected registering a profile to deliver a webhook")
+	}
+}
+
+func TestDeprecationWebhook(t *testing.T) {
+	received := make(chan string, 10)
+	rec := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(webhook.EventHeader)
+	}))
+	defer rec.Close()
+
+	d := webhook.NewDispatcher(webhook.NewMemSubscriptions())
+	d.Subscriptions.Store("sub", &webhook.Subscription{
+		ID:     "sub",
+		URL:    rec.URL,
+		Events: []webhook.EventType{webhook.EventDatasetPublished, webhook.EventDatasetDeprecated},
+		Secret: "secret",
+	})
+
+	b5, err := registry.ProfileFromPrivateKey("b5", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	datasets := registry.NewMemDatasets()
+	datasets.Store("b5/cities", &registry.Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities", ProfileID: b5.ProfileID})
+	s := httptest.NewServer(NewRoutes(registry.Registry{Datasets: datasets}, AddWebhooks(d)))
+	defer s.Close()
+
+	dep, err := registry.NewDeprecation("b5/cities", "use towns instead", "", privKey1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := json.Marshal(dep)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req, err := http.NewRequest("POST", s.URL+"/dataset/deprecation", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got: %d", http.StatusOK, res.StatusCode)
+	}
+	d.Wait()
+	close(received)
+
+	var got []string
+	for et := range received {
+		got = append(got, et)
+	}
+	if len(got) != 1 || got[0] != string(webhook.EventDatasetDeprecated) {
+		t.Errorf("expected a single %s webhook, got: %v", webhook.EventDatasetDeprecated, got)
+	}
+}
@@ -34,7 +34,7 @@ func NewMockServerWithNumDatasets(num int) (*regclient.Client, *httptest.Server)
 			Name:   name,
 			Handle: "peer",
 		}
-		reg.Datasets.Store(name, ds)
+		reg.Datasets.Store(ds.Key(), ds)
 	}
 	ps := &pinset.MemPinset{Profiles: reg.Profiles}
 	return NewMockServerRegistryPinset(reg, ps)
@@ -57,7 +57,9 @@ func NewMockServerRegistryPinset(reg registry.Registry, ps pinset.Pinset) (*regc
 // NewMemRegistry creates a new in-memory registry
 func NewMemRegistry() registry.Registry {
 	return registry.Registry{
-		Profiles: registry.NewMemProfiles(),
-		Datasets: registry.NewMemDatasets(),
+		Profiles:      registry.NewMemProfiles(),
+		Organizations: registry.NewMemOrganizations(),
+		Redirects:     registry.NewMemRedirects(),
+		Datasets:      registry.NewMemDatasets(),
 	}
 }
@@ -3,48 +3,342 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+	stdlog "log"
+	"math/big"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/qri-io/registry"
 	"github.com/qri-io/registry/pinset"
 	"github.com/qri-io/registry/regserver/handlers"
+	"github.com/qri-io/registry/search"
+	"github.com/qri-io/registry/sqlstore"
+	"github.com/qri-io/registry/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 var (
 	// logger
 	log = logrus.New()
-
-	adminKey string
 )
 
-func init() {
-	adminKey = handlers.NewAdminKey()
-	log.Infof("admin key: %s", adminKey)
-}
+// adminUsername is the default basic auth username for protected endpoints
+const adminUsername = "username"
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		switch os.Args[1] {
+		case "reindex", "checkindex":
+			os.Exit(runAdminCommand(os.Args[1], os.Args[2:], os.Stdout, os.Stderr))
+		default:
+			fmt.Fprint(os.Stderr, adminUsage)
+			os.Exit(2)
+		}
+	}
+
+	cfg, err := LoadConfig(os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	s, err := newServer(cfg)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Infof("serving on: %s", s.Addr)
+		errs <- s.Serve()
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		log.Error(err.Error())
+	case sig := <-sigs:
+		log.Infof("received %s, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeouts.Shutdown))
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// server is a registry http server with the background work it needs to
+// drain before exiting
+type server struct {
+	*http.Server
+	// tls is true if the server serves HTTPS. When certFile & keyFile are
+	// empty, Server.TLSConfig holds a self-signed certificate
+	tls               bool
+	certFile, keyFile string
+
+	pins        *pinset.Tracker
+	webhooks    *webhook.Dispatcher
+	closeStores func() error
+}
+
+// Serve listens for connections, blocking until the server is shut down
+func (s *server) Serve() error {
+	var err error
+	if s.tls {
+		err = s.ListenAndServeTLS(s.certFile, s.keyFile)
+	} else {
+		err = s.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections, waits for in-flight requests, pin
+// jobs & webhook deliveries to finish, then closes stores. Stores are closed
+// even if ctx expires first
+func (s *server) Shutdown(ctx context.Context) error {
+	var errs []string
+	if err := s.Server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("draining requests: %s", err.Error()))
+	}
+	if s.pins != nil {
+		if err := s.pins.Close(ctx); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if s.webhooks != nil {
+		done := make(chan struct{})
+		go func() {
+			s.webhooks.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Sprintf("waiting for webhook deliveries: %s", ctx.Err().Error()))
+		}
+	}
+	if err := s.closeStores(); err != nil {
+		errs = append(errs, fmt.Sprintf("closing stores: %s", err.Error()))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// newServer configures logging & assembles a server from a validated config
+func newServer(cfg *Config) (*server, error) {
+	lvl, err := logrus.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	log.SetLevel(lvl)
+	if err := handlers.SetLogLevel(cfg.Log.Level); err != nil {
+		return nil, err
+	}
+	if cfg.Log.Format == "json" {
+		log.SetFormatter(&logrus.JSONFormatter{})
+	}
+	if err := handlers.SetLogFormat(cfg.Log.Format); err != nil {
+		return nil, err
+	}
+
+	registry.AcceptLegacySignatures = cfg.AcceptLegacySignatures
+
+	adminKey, err := cfg.AdminKey()
+	if err != nil {
+		return nil, err
+	}
+	if adminKey == "" {
+		adminKey = handlers.NewAdminKey()
+		log.Infof("admin key: %s", adminKey)
+	}
+
+	reg, pset, checks, closeStores, err := newRegistry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &server{
+		tls:         cfg.TLS.Enabled(),
+		certFile:    cfg.TLS.CertFile,
+		keyFile:     cfg.TLS.KeyFile,
+		closeStores: closeStores,
+	}
+
+	opts := append(checks, handlers.AddProtector(handlers.NewBAProtector(cfg.Auth.Username, adminKey)))
+	if pset != nil {
+		s.pins = pinset.NewTracker(pset)
+		opts = append(opts, handlers.AddPinset(s.pins))
+	}
+	if cfg.Subsystems.Webhooks {
+		s.webhooks = webhook.NewDispatcher(webhook.NewMemSubscriptions())
+		opts = append(opts, handlers.AddWebhooks(s.webhooks))
+	}
+	if cfg.Subsystems.Dsync {
+		d, err := newDsync(cfg.Dsync, reg.Datasets)
+		if err != nil {
+			closeStores()
+			return nil, fmt.Errorf("creating dsync remote: %s", err.Error())
+		}
+		opts = append(opts, handlers.AddDsync(d))
+	}
+
+	if cfg.Subsystems.Metrics {
+		opts = append(opts, handlers.AddMetrics(handlers.NewMetrics()))
+	}
+	opts = append(opts, handlers.AddLimits(cfg.Limits.HandlerLimits()))
+
+	s.Server = &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handlers.NewRoutes(reg, opts...),
+		MaxHeaderBytes:    cfg.Limits.MaxHeaderBytes,
+		ReadHeaderTimeout: time.Duration(cfg.Timeouts.ReadHeader),
+		ReadTimeout:       time.Duration(cfg.Timeouts.Read),
+		WriteTimeout:      time.Duration(cfg.Timeouts.Write),
+		IdleTimeout:       time.Duration(cfg.Timeouts.Idle),
+		ErrorLog:          stdlog.New(log.WriterLevel(logrus.WarnLevel), "", 0),
+	}
+	if s.tls {
+		s.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.TLS.SelfSigned {
+			cert, err := selfSignedCert()
+			if err != nil {
+				closeStores()
+				return nil, fmt.Errorf("generating self-signed certificate: %s", err.Error())
+			}
+			s.TLSConfig.Certificates = []tls.Certificate{cert}
+			log.Warn("serving with a self-signed certificate, don't use this in production")
+		}
+	}
+	return s, nil
+}
+
+// newRegistry creates registry stores & a pinset for the configured storage
+// backend & subsystems, with readiness checks for any storage connections.
+// pinset is nil when pinning is disabled
+func newRegistry(cfg *Config) (reg registry.Registry, pset pinset.Pinset, checks []func(o *handlers.RouteOptions), closeStores func() error, err error) {
+	closeStores = func() error { return nil }
+
+	switch cfg.Storage.Backend {
+	case StorageMemory:
+		reg.Profiles = registry.NewMemProfiles()
+		reg.Datasets = registry.NewMemDatasets()
+		reg.Organizations = registry.NewMemOrganizations()
+		reg.Redirects = registry.NewMemRedirects()
+		if cfg.Subsystems.Reputations {
+			reg.Reputations = registry.NewMemReputations()
+		}
+	case StorageSQLite, StoragePostgres:
+		db, err := sqlstore.Open(context.Background(), sqlstore.Dialect(cfg.Storage.Backend), cfg.Storage.DSN)
+		if err != nil {
+			return reg, nil, nil, nil, fmt.Errorf("opening %s storage: %s", cfg.Storage.Backend, err.Error())
+		}
+		closeStores = db.Close
+		checks = append(checks, handlers.AddHealthCheck("database", db.PingContext))
+		onErr := func(err error) {
+			log.Errorf("storage: %s", err.Error())
+		}
+		reg.Profiles = registry.NewLegacyProfiles(sqlstore.NewProfiles(db), onErr)
+		reg.Datasets = registry.NewLegacyDatasets(sqlstore.NewDatasets(db), onErr)
+		reg.Organizations = registry.NewLegacyOrganizations(sqlstore.NewOrganizations(db), onErr)
+		reg.Redirects = registry.NewLegacyRedirects(sqlstore.NewRedirects(db), onErr)
+		if cfg.Subsystems.Reputations {
+			reg.Reputations = registry.NewLegacyReputations(sqlstore.NewReputations(db), onErr)
+		}
+		if cfg.Subsystems.Pinset {
+			pset = sqlstore.NewPins(db)
+		}
+	default:
+		return reg, nil, nil, nil, fmt.Errorf("unsupported storage backend: '%s'", cfg.Storage.Backend)
 	}
 
-	pro := handlers.NewBAProtector("username", adminKey)
-	ps := registry.NewMemProfiles()
-	reg := registry.Registry{
-		Profiles: ps,
-		Datasets: registry.NewMemDatasets(),
+	if cfg.Subsystems.Stats {
+		reg.Stats = registry.NewMemStats()
 	}
-	pset := &pinset.MemPinset{Profiles: ps}
 
-	s := http.Server{
-		Addr:    ":" + port,
-		Handler: handlers.NewRoutes(reg, handlers.AddPinset(pset), handlers.AddProtector(pro)),
+	if cfg.Subsystems.Search {
+		idx := search.NewIndex()
+		idx.Stats = reg.Stats
+		if _, err := registry.Reindex(reg.Datasets, idx); err != nil {
+			closeStores()
+			return reg, nil, nil, nil, fmt.Errorf("building search index: %s", err.Error())
+		}
+		var pros []*registry.Profile
+		reg.Profiles.SortedRange(func(key string, p *registry.Profile) bool {
+			pros = append(pros, p)
+			return false
+		})
+		if err := idx.IndexProfiles(pros); err != nil {
+			closeStores()
+			return reg, nil, nil, nil, fmt.Errorf("building search index: %s", err.Error())
+		}
+		reg.Profiles = search.Profiles{Profiles: reg.Profiles, Index: idx}
+		reg.Search = idx
+		reg.Suggester = idx
+		reg.Indexer = idx
 	}
 
-	log.Infof("serving on: %s", s.Addr)
-	if err := s.ListenAndServe(); err != nil {
-		log.Info(err.Error())
+	if cfg.Subsystems.Pinset && pset == nil {
+		pset = &pinset.MemPinset{Profiles: reg.Profiles}
+	}
+	return reg, pset, checks, closeStores, nil
+}
+
+// selfSignedCert generates a certificate for localhost valid for one year
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"qri registry development"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
 	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry"
+)
+
+func TestServerShutdown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Key = "key"
+	cfg.Log.Level = "error"
+	s, err := newServer(cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s.Addr = "127.0.0.1:0"
+	if s.ReadTimeout != time.Minute || s.pins == nil || s.webhooks == nil {
+		t.Errorf("server not configured from defaults")
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- s.Serve() }()
+	time.Sleep(time.Millisecond * 20)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("expected Serve to return nil after shutdown, got: %s", err.Error())
+	}
+}
+
+func TestServerDsync(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Key = "key"
+	cfg.Log.Level = "error"
+	cfg.Subsystems.Dsync = true
+	s, err := newServer(cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer s.closeStores()
+
+	w := httptest.NewRecorder()
+	s.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if !strings.Contains(w.Body.String(), `"name":"dsync"`) {
+		t.Errorf("expected readiness to report dsync, got: %s", w.Body.String())
+	}
+}
+
+func TestNewRegistrySQLStorage(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.Backend = StorageSQLite
+	cfg.Storage.DSN = "file:regserver_test?mode=memory&cache=shared"
+	cfg.Subsystems.Search = false
+	reg, _, _, closeStores, err := newRegistry(cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer closeStores()
+
+	reg.Organizations.Store("acme", &registry.Organization{Handle: "acme", Owners: []string{"QmA"}})
+	reg.Redirects.Store("a/foo", &registry.Redirect{From: "a/foo", To: "a/bar"})
+	if _, ok := reg.Organizations.(*registry.MemOrganizations); ok {
+		t.Errorf("expected organizations to use sql storage")
+	}
+	if _, ok := reg.Redirects.(*registry.MemRedirects); ok {
+		t.Errorf("expected redirects to use sql storage")
+	}
+	if o, ok := reg.Organizations.Load("acme"); !ok || !o.IsOwner("QmA") {
+		t.Errorf("expected organization to be stored")
+	}
+	if r, ok := reg.Redirects.Load("a/foo"); !ok || r.To != "a/bar" {
+		t.Errorf("expected redirect to be stored")
+	}
+}
+
+func TestSelfSignedCert(t *testing.T) {
+	cert, err := selfSignedCert()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := c.VerifyHostname("localhost"); err != nil {
+		t.Error(err.Error())
+	}
+}
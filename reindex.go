@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// IndexReader is an opt-in interface for search indexes that can list their
+// contents, required for consistency checks
+type IndexReader interface {
+	// IndexedDatasets returns every indexed dataset, keyed by dataset key
+	IndexedDatasets() (map[string]*Dataset, error)
+}
+
+// IndexReport describes differences between a Datasets store & an index.
+// Each field lists dataset keys in lexographical order
+type IndexReport struct {
+	// Checked is the number of datasets in the store
+	Checked int `json:"checked"`
+	// Missing datasets are in the store but not the index
+	Missing []string `json:"missing"`
+	// Stale datasets are indexed with a different value than the store
+	Stale []string `json:"stale"`
+	// Orphaned datasets are in the index but not the store
+	Orphaned []string `json:"orphaned"`
+}
+
+// Consistent is true when the store & index agree
+func (r *IndexReport) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Stale) == 0 && len(r.Orphaned) == 0
+}
+
+// CheckIndex compares the contents of an index with a Datasets store. If
+// opts configure a Lock it's held for the duration of the check
+func CheckIndex(datasets Datasets, idx IndexReader, opts ...func(o *RegisterOptions)) (*IndexReport, error) {
+	if lock := indexLock(opts); lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	return checkIndex(datasets, idx)
+}
+
+func checkIndex(datasets Datasets, idx IndexReader) (*IndexReport, error) {
+	indexed, err := idx.IndexedDatasets()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &IndexReport{Missing: []string{}, Stale: []string{}, Orphaned: []string{}}
+	var cerr error
+	datasets.SortedRange(func(key string, d *Dataset) bool {
+		r.Checked++
+		i, ok := indexed[key]
+		if !ok {
+			r.Missing = append(r.Missing, key)
+			return false
+		}
+		delete(indexed, key)
+		same, err := sameDataset(d, i)
+		if err != nil {
+			cerr = err
+			return true
+		}
+		if !same {
+			r.Stale = append(r.Stale, key)
+		}
+		return false
+	})
+	if cerr != nil {
+		return nil, cerr
+	}
+
+	for key := range indexed {
+		r.Orphaned = append(r.Orphaned, key)
+	}
+	sort.Strings(r.Orphaned)
+	return r, nil
+}
+
+// Reindex rebuilds an index from a Datasets store, indexing every stored
+// dataset. If idx is also an IndexReader, orphaned datasets are removed &
+// Reindex returns a report of the inconsistencies found before rebuilding,
+// otherwise the returned report is nil. If opts configure a Lock it's held
+// until the index is rebuilt, so dataset changes made with the same lock
+// can't interleave
+func Reindex(datasets Datasets, idx Indexer, opts ...func(o *RegisterOptions)) (*IndexReport, error) {
+	if lock := indexLock(opts); lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	var report *IndexReport
+	if rdr, ok := idx.(IndexReader); ok {
+		var err error
+		if report, err = checkIndex(datasets, rdr); err != nil {
+			return nil, err
+		}
+		if len(report.Orphaned) > 0 {
+			orphans := make([]*Dataset, len(report.Orphaned))
+			for i, key := range report.Orphaned {
+				handle, name, err := SplitDatasetKey(key)
+				if err != nil {
+					return report, err
+				}
+				orphans[i] = &Dataset{Handle: handle, Name: name}
+			}
+			if err := idx.UnindexDatasets(orphans); err != nil {
+				return report, fmt.Errorf("removing orphaned datasets: %s", err.Error())
+			}
+		}
+	}
+
+	var dss []*Dataset
+	datasets.SortedRange(func(key string, d *Dataset) bool {
+		dss = append(dss, d)
+		return false
+	})
+	if err := idx.IndexDatasets(dss); err != nil {
+		return report, fmt.Errorf("indexing datasets: %s", err.Error())
+	}
+	return report, nil
+}
+
+// indexLock gives the lock configured by opts, if any
+func indexLock(opts []func(o *RegisterOptions)) sync.Locker {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o.Lock
+}
+
+// sameDataset compares datasets by their JSON encoding, ignoring stats
+func sameDataset(a, b *Dataset) (bool, error) {
+	if a == b {
+		return true, nil
+	}
+	ac, bc := *a, *b
+	ac.Stats, bc.Stats = nil, nil
+	adata, err := json.Marshal(ac)
+	if err != nil {
+		return false, err
+	}
+	bdata, err := json.Marshal(bc)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(adata, bdata), nil
+}
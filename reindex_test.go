@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memIndex is a minimal IndexReader & Indexer for tests
+type memIndex map[string]*Dataset
+
+func (idx memIndex) IndexedDatasets() (map[string]*Dataset, error) {
+	dss := make(map[string]*Dataset, len(idx))
+	for key, ds := range idx {
+		dss[key] = ds
+	}
+	return dss, nil
+}
+
+func (idx memIndex) IndexDatasets(dss []*Dataset) error {
+	for _, ds := range dss {
+		idx[ds.Key()] = ds
+	}
+	return nil
+}
+
+func (idx memIndex) UnindexDatasets(dss []*Dataset) error {
+	for _, ds := range dss {
+		delete(idx, ds.Key())
+	}
+	return nil
+}
+
+func TestCheckIndex(t *testing.T) {
+	datasets := NewMemDatasets()
+	cities := &Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities"}
+	airports := &Dataset{Handle: "b5", Name: "airports", Path: "/ipfs/QmAirports"}
+	movies := &Dataset{Handle: "ramfox", Name: "movies", Path: "/ipfs/QmMovies"}
+	for _, ds := range []*Dataset{cities, airports, movies} {
+		datasets.Store(ds.Key(), ds)
+	}
+
+	idx := memIndex{
+		"b5/cities":   cities,
+		"b5/airports": &Dataset{Handle: "b5", Name: "airports", Path: "/ipfs/QmOldAirports"},
+		"b5/deleted":  &Dataset{Handle: "b5", Name: "deleted"},
+	}
+
+	got, err := CheckIndex(datasets, idx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := &IndexReport{
+		Checked:  3,
+		Missing:  []string{"ramfox/movies"},
+		Stale:    []string{"b5/airports"},
+		Orphaned: []string{"b5/deleted"},
+	}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("report mismatch. expected: %#v, got: %#v", expect, got)
+	}
+	if got.Consistent() {
+		t.Error("expected inconsistent report")
+	}
+
+	if _, err := Reindex(datasets, idx); err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err = CheckIndex(datasets, idx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !got.Consistent() {
+		t.Errorf("expected consistent index after reindex, got: %#v", got)
+	}
+}
+
+func TestReindexLock(t *testing.T) {
+	datasets := NewMemDatasets()
+	idx := memIndex{}
+	lock := &sync.Mutex{}
+
+	// a change in progress holds the lock, reindexing must wait for it
+	lock.Lock()
+	done := make(chan error)
+	go func() {
+		_, err := Reindex(datasets, idx, WithLock(lock))
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected reindex to wait for the lock")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	cities := &Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities"}
+	datasets.Store(cities.Key(), cities)
+	lock.Unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := idx["b5/cities"]; !ok {
+		t.Errorf("expected reindex to include changes made while it waited")
+	}
+}
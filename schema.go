@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"sort"
+
+	"github.com/qri-io/dataset"
+)
+
+// Column describes a single column of a dataset, as declared in the
+// dataset's structure schema
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Columns extracts column names & types from a dataset structure's schema.
+// Array-of-arrays schemas use the title of each item for a column name,
+// array-of-objects schemas use property names, sorted. Columns returns nil
+// for schemas that don't describe tabular data
+func Columns(st *dataset.Structure) []Column {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	if schemaType(st.Schema) != "array" {
+		return nil
+	}
+	rows, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var cols []Column
+	switch schemaType(rows) {
+	case "array":
+		items, ok := rows["items"].([]interface{})
+		if !ok {
+			return nil
+		}
+		for _, item := range items {
+			sch, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := sch["title"].(string)
+			if name == "" {
+				continue
+			}
+			cols = append(cols, Column{Name: name, Type: schemaType(sch)})
+		}
+	case "object":
+		props, ok := rows["properties"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sch, _ := props[name].(map[string]interface{})
+			cols = append(cols, Column{Name: name, Type: schemaType(sch)})
+		}
+	}
+	return cols
+}
+
+// schemaType gives the type of a json schema, for schemas that list multiple
+// types the first non-null type is used
+func schemaType(sch map[string]interface{}) string {
+	switch t := sch["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
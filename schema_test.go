@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestColumns(t *testing.T) {
+	cases := []struct {
+		description string
+		schema      map[string]interface{}
+		expect      []Column
+	}{
+		{"nil schema", nil, nil},
+		{"object body", dataset.BaseSchemaObject, nil},
+		{"array of arrays", map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "city", "type": "string"},
+					map[string]interface{}{"title": "fips_code", "type": []interface{}{"null", "integer"}},
+					map[string]interface{}{"type": "number"},
+				},
+			},
+		}, []Column{{"city", "string"}, {"fips_code", "integer"}}},
+		{"array of objects", map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pop":  map[string]interface{}{"type": "integer"},
+					"city": map[string]interface{}{"type": "string"},
+				},
+			},
+		}, []Column{{"city", "string"}, {"pop", "integer"}}},
+	}
+
+	for _, c := range cases {
+		got := Columns(&dataset.Structure{Schema: c.schema})
+		if len(got) != len(c.expect) {
+			t.Errorf("case %s: expected %d columns, got: %d", c.description, len(c.expect), len(got))
+			continue
+		}
+		for i, col := range c.expect {
+			if got[i] != col {
+				t.Errorf("case %s column %d mismatch. expected: %v, got: %v", c.description, i, col, got[i])
+			}
+		}
+	}
+}
@@ -23,6 +23,51 @@ type Indexer interface {
 type SearchParams struct {
 	Q             string
 	Limit, Offset int
+	// Column restricts results to datasets with a column of this name,
+	// matched case-insensitively
+	Column string
+	// ColumnType restricts results to datasets with a column of this json
+	// schema type. When Column is also set, both must match the same column
+	ColumnType string
+	// Sort is a comma-separated list of fields to order results by, see
+	// ParseSort for details. The default is "relevance,key" when Q is set
+	// and "key" otherwise
+	Sort string
+	// Boosts weights matches in each dataset field when scoring relevance,
+	// keyed by SearchFields. Fields without a boost use DefaultBoosts
+	Boosts map[string]float64
+}
+
+// SortFields gives the parsed sort order for a search, applying defaults
+func (p SearchParams) SortFields() ([]SortField, error) {
+	if p.Sort != "" {
+		return ParseSort(p.Sort)
+	}
+	if p.Q != "" {
+		return []SortField{{Field: SortRelevance, Desc: true}, {Field: SortKey}}, nil
+	}
+	return []SortField{{Field: SortKey}}, nil
+}
+
+// Boost gives the relevance weight of a match in field
+func (p SearchParams) Boost(field string) float64 {
+	if b, ok := p.Boosts[field]; ok {
+		return b
+	}
+	return DefaultBoosts[field]
+}
+
+// ValidateBoosts checks boosts are for known fields & aren't negative
+func (p SearchParams) ValidateBoosts() error {
+	for field, b := range p.Boosts {
+		if _, ok := DefaultBoosts[field]; !ok {
+			return NewError(ErrInvalid, "invalid boost field: '%s'", field)
+		}
+		if b < 0 {
+			return NewError(ErrInvalid, "boost for '%s' cannot be negative", field)
+		}
+	}
+	return nil
 }
 
 // Result is the interface that a search result implements
@@ -30,6 +75,24 @@ type Result struct {
 	Type  string      // one of ["dataset", "profile"] for now
 	ID    string      // identifier to lookup
 	Value interface{} // Value returned
+	Score float64     // relevance of the result, higher is more relevant
+}
+
+// Suggester is an opt-in interface for registries that support
+// search-as-you-type completions
+type Suggester interface {
+	// Suggest returns up to limit completions for a partial query, tolerating
+	// typos. Best completions come first
+	Suggest(prefix string, limit int) ([]Suggestion, error)
+}
+
+// Suggestion is a single autocomplete result
+type Suggestion struct {
+	Type string // one of ["dataset", "profile"]
+	ID   string // dataset key or profile handle
+	Text string // the text that matched, suitable for display
+	// Distance is the number of edits between the query and a prefix of Text
+	Distance int
 }
 
 // ErrSearchNotSupported is the canonical error to indicate search
@@ -55,16 +118,21 @@ type MockSearch struct {
 
 // Search is a trivial search implementation used for testing
 func (ms MockSearch) Search(p SearchParams) (results []Result, err error) {
+	sortFields, err := p.SortFields()
+	if err != nil {
+		return nil, err
+	}
 	ms.Datasets.Range(func(key string, ds *Dataset) bool {
 		dsname := ""
 		if ds.Meta != nil {
 			dsname = strings.ToLower(ds.Meta.Title)
 		}
 		if strings.Contains(dsname, strings.ToLower(p.Q)) {
-			result := &Result{Value: ds}
+			result := &Result{ID: key, Value: ds, Score: p.Boost("title")}
 			results = append(results, *result)
 		}
 		return false
 	})
+	SortResults(results, sortFields, nil)
 	return results, nil
 }
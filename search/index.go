@@ -0,0 +1,206 @@
+// Package search implements an in-memory search index for registry datasets
+// and profiles
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qri-io/registry"
+)
+
+// Index is an in-memory search index of registry datasets safe for
+// concurrent use. Index implements registry.Indexer, registry.Searchable and
+// registry.Suggester. Profile handles are indexed for suggestions only
+type Index struct {
+	// Stats, if set, enables sorting results by popularity
+	Stats registry.Stats
+
+	sync.RWMutex
+	docs map[string]*doc
+	// byColumn maps lowercased column names to dataset keys
+	byColumn map[string]map[string]struct{}
+	// profiles is the set of indexed profile handles
+	profiles map[string]struct{}
+	// completions is a trie of suggestion terms
+	completions *trie
+}
+
+// doc is an indexed dataset
+type doc struct {
+	ds      *registry.Dataset
+	fields  map[string]string
+	columns []registry.Column
+}
+
+var (
+	// assert at compile time that Index is an Indexer & Searchable
+	_ registry.Indexer     = (*Index)(nil)
+	_ registry.Searchable  = (*Index)(nil)
+	_ registry.Suggester   = (*Index)(nil)
+	_ registry.IndexReader = (*Index)(nil)
+)
+
+// NewIndex allocates a new, empty *Index
+func NewIndex() *Index {
+	return &Index{
+		docs:        make(map[string]*doc),
+		byColumn:    make(map[string]map[string]struct{}),
+		profiles:    make(map[string]struct{}),
+		completions: newTrie(),
+	}
+}
+
+// Len returns the number of datasets in the index
+func (idx *Index) Len() int {
+	idx.RLock()
+	defer idx.RUnlock()
+	return len(idx.docs)
+}
+
+// IndexDatasets adds one or more datasets to the index, replacing any
+// previously indexed dataset with the same key
+func (idx *Index) IndexDatasets(dss []*registry.Dataset) error {
+	idx.Lock()
+	defer idx.Unlock()
+	for _, ds := range dss {
+		key := ds.Key()
+		idx.unindex(key)
+		d := &doc{
+			ds:      ds,
+			fields:  registry.DatasetFields(ds),
+			columns: registry.Columns(ds.Structure),
+		}
+		idx.docs[key] = d
+		for _, col := range d.columns {
+			name := strings.ToLower(col.Name)
+			if idx.byColumn[name] == nil {
+				idx.byColumn[name] = map[string]struct{}{}
+			}
+			idx.byColumn[name][key] = struct{}{}
+		}
+		for term, s := range datasetSuggestions(ds) {
+			idx.completions.insert(term, s)
+		}
+	}
+	return nil
+}
+
+// UnindexDatasets removes one or more datasets from the index
+func (idx *Index) UnindexDatasets(dss []*registry.Dataset) error {
+	idx.Lock()
+	defer idx.Unlock()
+	for _, ds := range dss {
+		idx.unindex(ds.Key())
+	}
+	return nil
+}
+
+// unindex drops the document at key. callers must hold the write lock
+func (idx *Index) unindex(key string) {
+	d, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for _, col := range d.columns {
+		name := strings.ToLower(col.Name)
+		if set, ok := idx.byColumn[name]; ok {
+			delete(set, key)
+			if len(set) == 0 {
+				delete(idx.byColumn, name)
+			}
+		}
+	}
+	for term, s := range datasetSuggestions(d.ds) {
+		idx.completions.remove(term, s)
+	}
+	delete(idx.docs, key)
+}
+
+// Search finds datasets that match the query p.Q, see registry.ParseQuery
+// for query syntax, and that match any column criteria. Results are scored
+// by summing the boost of each field a term matches, and ordered by p.Sort
+func (idx *Index) Search(p registry.SearchParams) ([]registry.Result, error) {
+	sortFields, err := p.SortFields()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ValidateBoosts(); err != nil {
+		return nil, err
+	}
+	query, err := registry.ParseQuery(p.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.RLock()
+	defer idx.RUnlock()
+
+	var keys []string
+	if p.Column != "" {
+		for key := range idx.byColumn[strings.ToLower(p.Column)] {
+			keys = append(keys, key)
+		}
+	} else {
+		keys = make([]string, 0, len(idx.docs))
+		for key := range idx.docs {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	results := []registry.Result{}
+	for _, key := range keys {
+		d := idx.docs[key]
+		score, ok := d.match(query, p)
+		if !ok || !d.matchesColumn(p.Column, p.ColumnType) {
+			continue
+		}
+		results = append(results, registry.Result{Type: "dataset", ID: key, Value: d.ds, Score: score})
+	}
+
+	registry.SortResults(results, sortFields, idx.Stats)
+	return page(results, p.Offset, p.Limit), nil
+}
+
+// matchesColumn checks for a column matching name and type, empty values
+// match anything
+func (d *doc) matchesColumn(name, typ string) bool {
+	if name == "" && typ == "" {
+		return true
+	}
+	for _, col := range d.columns {
+		if (name == "" || strings.EqualFold(col.Name, name)) && (typ == "" || strings.EqualFold(col.Type, typ)) {
+			return true
+		}
+	}
+	return false
+}
+
+// page applies offset & limit to a result list. a limit <= 0 returns all
+// results after offset
+func page(results []registry.Result, offset, limit int) []registry.Result {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(results) {
+		return []registry.Result{}
+	}
+	results = results[offset:]
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results
+}
+
+// IndexedDatasets returns every indexed dataset, keyed by dataset key
+func (idx *Index) IndexedDatasets() (map[string]*registry.Dataset, error) {
+	idx.RLock()
+	defer idx.RUnlock()
+	dss := make(map[string]*registry.Dataset, len(idx.docs))
+	for key, d := range idx.docs {
+		dss[key] = d.ds
+	}
+	return dss, nil
+}
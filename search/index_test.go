@@ -0,0 +1,151 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+)
+
+func tabular(cols ...registry.Column) *dataset.Structure {
+	items := make([]interface{}, len(cols))
+	for i, col := range cols {
+		items[i] = map[string]interface{}{"title": col.Name, "type": col.Type}
+	}
+	return &dataset.Structure{Schema: map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "array", "items": items},
+	}}
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex()
+	dss := []*registry.Dataset{
+		{Handle: "b5", Name: "counties", Meta: &dataset.Meta{Title: "US county populations"},
+			Structure: tabular(registry.Column{Name: "fips_code", Type: "integer"}, registry.Column{Name: "pop", Type: "integer"})},
+		{Handle: "b5", Name: "zips", Meta: &dataset.Meta{Title: "zip codes", Keywords: []string{"postal"}},
+			Structure: tabular(registry.Column{Name: "FIPS_CODE", Type: "string"})},
+		{Handle: "ramfox", Name: "weather", Meta: &dataset.Meta{Title: "daily weather"},
+			Structure: tabular(registry.Column{Name: "temp", Type: "number"})},
+	}
+	if err := idx.IndexDatasets(dss); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		p      registry.SearchParams
+		expect []string
+	}{
+		{registry.SearchParams{}, []string{"b5/counties", "b5/zips", "ramfox/weather"}},
+		{registry.SearchParams{Q: "county"}, []string{"b5/counties"}},
+		{registry.SearchParams{Q: "b5 postal"}, []string{"b5/zips"}},
+		{registry.SearchParams{Column: "fips_code"}, []string{"b5/counties", "b5/zips"}},
+		{registry.SearchParams{Column: "fips_code", ColumnType: "integer"}, []string{"b5/counties"}},
+		{registry.SearchParams{ColumnType: "number"}, []string{"ramfox/weather"}},
+		{registry.SearchParams{Column: "pop", ColumnType: "string"}, []string{}},
+		{registry.SearchParams{Limit: 1, Offset: 1}, []string{"b5/zips"}},
+		{registry.SearchParams{Offset: 10}, []string{}},
+	}
+
+	for i, c := range cases {
+		res, err := idx.Search(c.p)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(res) != len(c.expect) {
+			t.Errorf("case %d expected %d results, got: %d", i, len(c.expect), len(res))
+			continue
+		}
+		for j, key := range c.expect {
+			if res[j].ID != key {
+				t.Errorf("case %d result %d mismatch. expected: %s, got: %s", i, j, key, res[j].ID)
+			}
+		}
+	}
+
+	if err := idx.UnindexDatasets(dss[:1]); err != nil {
+		t.Fatal(err.Error())
+	}
+	res, err := idx.Search(registry.SearchParams{Column: "pop"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 0 {
+		t.Errorf("expected unindexed dataset columns to be removed, got %d results", len(res))
+	}
+	if idx.Len() != 2 {
+		t.Errorf("expected 2 indexed datasets, got: %d", idx.Len())
+	}
+}
+
+func TestIndexSortPopularity(t *testing.T) {
+	stats := registry.NewMemStats()
+	idx := NewIndex()
+	idx.Stats = stats
+	if err := idx.IndexDatasets([]*registry.Dataset{
+		{Handle: "b5", Name: "a"},
+		{Handle: "b5", Name: "b"},
+		{Handle: "b5", Name: "c"},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+	stats.Record("b5/c", registry.StatPin, time.Now())
+	stats.Record("b5/b", registry.StatLookup, time.Now())
+
+	res, err := idx.Search(registry.SearchParams{Sort: registry.SortPopularity})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []string{"b5/c", "b5/b", "b5/a"}
+	for i, key := range expect {
+		if res[i].ID != key {
+			t.Errorf("result %d mismatch. expected: %s, got: %s", i, key, res[i].ID)
+		}
+	}
+
+	if _, err := idx.Search(registry.SearchParams{Sort: "nope"}); err == nil {
+		t.Errorf("expected invalid sort to error")
+	}
+}
+
+func TestIndexRelevance(t *testing.T) {
+	idx := NewIndex()
+	if err := idx.IndexDatasets([]*registry.Dataset{
+		{Handle: "b5", Name: "a", Meta: &dataset.Meta{Description: "census tracts"}},
+		{Handle: "b5", Name: "b", Meta: &dataset.Meta{Title: "census tracts"}},
+		{Handle: "b5", Name: "census", Meta: &dataset.Meta{Title: "tracts"}},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		p      registry.SearchParams
+		expect []string
+	}{
+		// title (3) beats name (2) beats description (1)
+		{registry.SearchParams{Q: "census"}, []string{"b5/b", "b5/census", "b5/a"}},
+		{registry.SearchParams{Q: "census", Boosts: map[string]float64{"description": 5}}, []string{"b5/a", "b5/b", "b5/census"}},
+		{registry.SearchParams{Q: "census", Sort: "-key"}, []string{"b5/census", "b5/b", "b5/a"}},
+	}
+	for i, c := range cases {
+		res, err := idx.Search(c.p)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(res) != len(c.expect) {
+			t.Errorf("case %d expected %d results, got: %d", i, len(c.expect), len(res))
+			continue
+		}
+		for j, key := range c.expect {
+			if res[j].ID != key {
+				t.Errorf("case %d result %d mismatch. expected: %s, got: %s", i, j, key, res[j].ID)
+			}
+		}
+	}
+
+	if _, err := idx.Search(registry.SearchParams{Boosts: map[string]float64{"body": 1}}); err == nil {
+		t.Errorf("expected invalid boost field to error")
+	}
+}
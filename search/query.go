@@ -0,0 +1,73 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/qri-io/registry"
+)
+
+// match evaluates a parsed query against a document, returning the
+// relevance score of matching terms. a nil node matches everything
+func (d *doc) match(n registry.QueryNode, p registry.SearchParams) (score float64, ok bool) {
+	switch q := n.(type) {
+	case nil:
+		return 0, true
+	case registry.QueryAnd:
+		for _, child := range q {
+			s, ok := d.match(child, p)
+			if !ok {
+				return 0, false
+			}
+			score += s
+		}
+		return score, true
+	case registry.QueryOr:
+		for _, child := range q {
+			if s, matched := d.match(child, p); matched {
+				score += s
+				ok = true
+			}
+		}
+		return score, ok
+	case registry.QueryNot:
+		_, matched := d.match(q.Node, p)
+		return 0, !matched
+	case registry.QueryTerm:
+		return d.matchTerm(q, p)
+	}
+	return 0, false
+}
+
+// matchTerm checks a single term. unqualified terms match any search field,
+// handle, name, keyword, format & column qualifiers must match exactly
+func (d *doc) matchTerm(t registry.QueryTerm, p registry.SearchParams) (score float64, ok bool) {
+	switch t.Field {
+	case "":
+		for _, field := range registry.SearchFields {
+			if strings.Contains(d.fields[field], t.Value) {
+				score += p.Boost(field)
+				ok = true
+			}
+		}
+		return score, ok
+	case "handle", "name":
+		return p.Boost(t.Field), d.fields[t.Field] == t.Value
+	case "title", "description":
+		return p.Boost(t.Field), strings.Contains(d.fields[t.Field], t.Value)
+	case "keyword":
+		if d.ds.Meta != nil {
+			for _, kw := range d.ds.Meta.Keywords {
+				if strings.EqualFold(kw, t.Value) {
+					return p.Boost("keywords"), true
+				}
+			}
+		}
+	case "format":
+		return 0, d.ds.Structure != nil && strings.EqualFold(d.ds.Structure.Format, t.Value)
+	case "column":
+		return 0, d.matchesColumn(t.Value, "")
+	case "is":
+		return 0, t.Value == "deprecated" && d.ds.Deprecation != nil
+	}
+	return 0, false
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+)
+
+func TestIndexQuery(t *testing.T) {
+	idx := NewIndex()
+	if err := idx.IndexDatasets([]*registry.Dataset{
+		{Handle: "acme", Name: "census", Meta: &dataset.Meta{Title: "population density by tract", Keywords: []string{"census"}},
+			Structure: &dataset.Structure{Format: "csv"}},
+		{Handle: "acme", Name: "census_2000", Meta: &dataset.Meta{Title: "population density, 2000", Keywords: []string{"census"}},
+			Structure: &dataset.Structure{Format: "csv"}, Deprecation: &registry.Deprecation{Successor: "acme/census"}},
+		{Handle: "acme", Name: "weather", Meta: &dataset.Meta{Title: "daily weather"},
+			Structure: tabular(registry.Column{Name: "temp", Type: "number"})},
+		{Handle: "b5", Name: "census", Meta: &dataset.Meta{Title: "census population"},
+			Structure: &dataset.Structure{Format: "json"}},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		q      string
+		expect []string
+	}{
+		{`handle:acme format:csv keyword:census "population density" -deprecated`, []string{"acme/census"}},
+		{`"population density"`, []string{"acme/census", "acme/census_2000"}},
+		{"is:deprecated", []string{"acme/census_2000"}},
+		{"name:census", []string{"acme/census", "b5/census"}},
+		{"format:csv OR format:json -deprecated", []string{"acme/census", "b5/census"}},
+		{"column:temp", []string{"acme/weather"}},
+		{"-handle:acme", []string{"b5/census"}},
+		{"(weather OR population) -keyword:census", []string{"acme/weather", "b5/census"}},
+	}
+
+	for i, c := range cases {
+		res, err := idx.Search(registry.SearchParams{Q: c.q, Sort: "key"})
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(res) != len(c.expect) {
+			t.Errorf("case %d expected %d results, got: %d", i, len(c.expect), len(res))
+			continue
+		}
+		for j, key := range c.expect {
+			if res[j].ID != key {
+				t.Errorf("case %d result %d mismatch. expected: %s, got: %s", i, j, key, res[j].ID)
+			}
+		}
+	}
+
+	if _, err := idx.Search(registry.SearchParams{Q: `"unterminated`}); err == nil {
+		t.Errorf("expected syntax error")
+	}
+}
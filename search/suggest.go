@@ -0,0 +1,195 @@
+package search
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/registry"
+)
+
+// DefaultSuggestLimit is the number of suggestions returned when no limit
+// is provided
+const DefaultSuggestLimit = 10
+
+// MaxEdits gives the number of typos tolerated for a query of length n.
+// short queries must match exactly
+func MaxEdits(n int) int {
+	switch {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// IndexProfiles adds profile handles to the suggestion index
+func (idx *Index) IndexProfiles(ps []*registry.Profile) error {
+	idx.Lock()
+	defer idx.Unlock()
+	for _, p := range ps {
+		idx.profiles[p.Handle] = struct{}{}
+		idx.completions.insert(strings.ToLower(p.Handle), profileSuggestion(p.Handle))
+	}
+	return nil
+}
+
+// UnindexProfiles removes profile handles from the suggestion index
+func (idx *Index) UnindexProfiles(ps []*registry.Profile) error {
+	idx.Lock()
+	defer idx.Unlock()
+	for _, p := range ps {
+		if _, ok := idx.profiles[p.Handle]; !ok {
+			continue
+		}
+		delete(idx.profiles, p.Handle)
+		idx.completions.remove(strings.ToLower(p.Handle), profileSuggestion(p.Handle))
+	}
+	return nil
+}
+
+// Suggest returns up to limit profiles & datasets with a handle, key, name or
+// title that starts with prefix, tolerating MaxEdits(len(prefix)) typos.
+// Suggestions are ordered by edit distance, then by length of the matched
+// text. a limit <= 0 uses DefaultSuggestLimit
+func (idx *Index) Suggest(prefix string, limit int) ([]registry.Suggestion, error) {
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+
+	top := newTopSuggestions(limit)
+	idx.RLock()
+	idx.completions.search(prefix, MaxEdits(len([]rune(prefix))), top)
+	idx.RUnlock()
+	return top.sorted(), nil
+}
+
+// topSuggestions keeps the best limit suggestions seen, one per profile or
+// dataset, so collecting matches for short prefixes doesn't grow with the
+// size of the index. topSuggestions is a heap with the worst suggestion first
+type topSuggestions struct {
+	limit int
+	items []registry.Suggestion
+	// index maps suggestion ids to their position in items
+	index map[string]int
+}
+
+func newTopSuggestions(limit int) *topSuggestions {
+	return &topSuggestions{limit: limit, index: map[string]int{}}
+}
+
+// add keeps s if it's the closest match for it's profile or dataset and is
+// among the best limit suggestions seen
+func (t *topSuggestions) add(s registry.Suggestion) {
+	id := suggestionID(s)
+	if i, ok := t.index[id]; ok {
+		if suggestionLess(s, t.items[i]) {
+			t.items[i] = s
+			heap.Fix(t, i)
+		}
+		return
+	}
+	if len(t.items) < t.limit {
+		heap.Push(t, s)
+		return
+	}
+	if suggestionLess(s, t.items[0]) {
+		delete(t.index, suggestionID(t.items[0]))
+		t.items[0] = s
+		t.index[id] = 0
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted gives kept suggestions, best first
+func (t *topSuggestions) sorted() []registry.Suggestion {
+	res := make([]registry.Suggestion, len(t.items))
+	copy(res, t.items)
+	sort.Slice(res, func(i, j int) bool { return suggestionLess(res[i], res[j]) })
+	return res
+}
+
+func (t *topSuggestions) Len() int           { return len(t.items) }
+func (t *topSuggestions) Less(i, j int) bool { return suggestionLess(t.items[j], t.items[i]) }
+func (t *topSuggestions) Swap(i, j int) {
+	t.items[i], t.items[j] = t.items[j], t.items[i]
+	t.index[suggestionID(t.items[i])] = i
+	t.index[suggestionID(t.items[j])] = j
+}
+
+func (t *topSuggestions) Push(x interface{}) {
+	s := x.(registry.Suggestion)
+	t.index[suggestionID(s)] = len(t.items)
+	t.items = append(t.items, s)
+}
+
+func (t *topSuggestions) Pop() interface{} {
+	s := t.items[len(t.items)-1]
+	t.items = t.items[:len(t.items)-1]
+	delete(t.index, suggestionID(s))
+	return s
+}
+
+// suggestionID identifies the profile or dataset a suggestion is for
+func suggestionID(s registry.Suggestion) string {
+	return s.Type + ":" + s.ID
+}
+
+// suggestionLess orders suggestions by distance, text length, type then id
+func suggestionLess(a, b registry.Suggestion) bool {
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	if len(a.Text) != len(b.Text) {
+		return len(a.Text) < len(b.Text)
+	}
+	if a.Type != b.Type {
+		return a.Type > b.Type
+	}
+	return a.ID < b.ID
+}
+
+func profileSuggestion(handle string) registry.Suggestion {
+	return registry.Suggestion{Type: "profile", ID: handle, Text: handle}
+}
+
+// datasetSuggestions gives the suggestion terms for a dataset: it's key, name
+// and title
+func datasetSuggestions(ds *registry.Dataset) map[string]registry.Suggestion {
+	key := ds.Key()
+	terms := map[string]registry.Suggestion{
+		strings.ToLower(key):     {Type: "dataset", ID: key, Text: key},
+		strings.ToLower(ds.Name): {Type: "dataset", ID: key, Text: ds.Name},
+	}
+	if ds.Meta != nil && ds.Meta.Title != "" {
+		terms[strings.ToLower(ds.Meta.Title)] = registry.Suggestion{Type: "dataset", ID: key, Text: ds.Meta.Title}
+	}
+	return terms
+}
+
+// Profiles wraps a registry.Profiles, keeping profile handles in an index
+// up to date for suggestions
+type Profiles struct {
+	registry.Profiles
+	Index *Index
+}
+
+// Store adds a profile to the underlying store & the index
+func (ps Profiles) Store(key string, p *registry.Profile) {
+	if prev, ok := ps.Profiles.Load(key); ok {
+		ps.Index.UnindexProfiles([]*registry.Profile{prev})
+	}
+	ps.Profiles.Store(key, p)
+	ps.Index.IndexProfiles([]*registry.Profile{p})
+}
+
+// Delete removes a profile from the underlying store & the index
+func (ps Profiles) Delete(key string) {
+	if prev, ok := ps.Profiles.Load(key); ok {
+		ps.Index.UnindexProfiles([]*registry.Profile{prev})
+	}
+	ps.Profiles.Delete(key)
+}
@@ -0,0 +1,118 @@
+package search
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+)
+
+func TestSuggest(t *testing.T) {
+	idx := NewIndex()
+	if err := idx.IndexProfiles([]*registry.Profile{{Handle: "b5"}, {Handle: "ramfox"}, {Handle: "census_bureau"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := idx.IndexDatasets([]*registry.Dataset{
+		{Handle: "b5", Name: "cities", Meta: &dataset.Meta{Title: "Cities of the World"}},
+		{Handle: "b5", Name: "census"},
+		{Handle: "ramfox", Name: "rainfall"},
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		prefix string
+		limit  int
+		expect []string
+	}{
+		{"", 0, []string{}},
+		{"b5", 0, []string{"profile:b5", "dataset:b5/census", "dataset:b5/cities"}},
+		{"b", 2, []string{"profile:b5", "dataset:b5/census"}},
+		{"cit", 0, []string{"dataset:b5/cities"}},
+		{"Cities of", 0, []string{"dataset:b5/cities"}},
+		// typos
+		{"ciites", 0, []string{"dataset:b5/cities"}},
+		{"ramfx", 0, []string{"profile:ramfox", "dataset:ramfox/rainfall"}},
+		{"cens", 0, []string{"dataset:b5/census", "profile:census_bureau"}},
+		{"xx", 0, []string{}},
+	}
+
+	for i, c := range cases {
+		res, err := idx.Suggest(c.prefix, c.limit)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(res) != len(c.expect) {
+			t.Errorf("case %d expected %d suggestions, got: %v", i, len(c.expect), res)
+			continue
+		}
+		for j, id := range c.expect {
+			if got := res[j].Type + ":" + res[j].ID; got != id {
+				t.Errorf("case %d suggestion %d mismatch. expected: %s, got: %s", i, j, id, got)
+			}
+		}
+	}
+
+	if err := idx.UnindexProfiles([]*registry.Profile{{Handle: "ramfox"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := idx.UnindexDatasets([]*registry.Dataset{{Handle: "ramfox", Name: "rainfall"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	res, err := idx.Suggest("ramfox", 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != 0 {
+		t.Errorf("expected unindexed entries to be removed, got: %v", res)
+	}
+}
+
+func TestTopSuggestions(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	best := map[string]registry.Suggestion{}
+	top := newTopSuggestions(5)
+	for i := 0; i < 500; i++ {
+		s := registry.Suggestion{
+			Type:     "dataset",
+			ID:       fmt.Sprintf("ds_%d", src.Intn(50)),
+			Text:     fmt.Sprintf("%*s", 1+src.Intn(20), "x"),
+			Distance: src.Intn(3),
+		}
+		top.add(s)
+		if prev, ok := best[s.ID]; !ok || suggestionLess(s, prev) {
+			best[s.ID] = s
+		}
+	}
+
+	expect := make([]registry.Suggestion, 0, len(best))
+	for _, s := range best {
+		expect = append(expect, s)
+	}
+	sort.Slice(expect, func(i, j int) bool { return suggestionLess(expect[i], expect[j]) })
+	got := top.sorted()
+	if len(got) != 5 {
+		t.Fatalf("expected 5 suggestions, got: %d", len(got))
+	}
+	for i, s := range got {
+		if s != expect[i] {
+			t.Errorf("suggestion %d mismatch. expected: %v, got: %v", i, expect[i], s)
+		}
+	}
+}
+
+func TestProfilesDecorator(t *testing.T) {
+	idx := NewIndex()
+	ps := Profiles{Profiles: registry.NewMemProfiles(), Index: idx}
+	ps.Store("b5", &registry.Profile{Handle: "b5"})
+	if res, _ := idx.Suggest("b5", 0); len(res) != 1 {
+		t.Errorf("expected stored profile to be suggested")
+	}
+	ps.Delete("b5")
+	if res, _ := idx.Suggest("b5", 0); len(res) != 0 {
+		t.Errorf("expected deleted profile to be removed from suggestions")
+	}
+}
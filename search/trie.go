@@ -0,0 +1,123 @@
+package search
+
+import (
+	"github.com/qri-io/registry"
+)
+
+// trie is a prefix tree of lowercased terms to suggestions, supporting
+// typo-tolerant prefix lookups
+type trie struct {
+	root *trieNode
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  map[registry.Suggestion]struct{}
+}
+
+func newTrie() *trie {
+	return &trie{root: &trieNode{children: map[rune]*trieNode{}}}
+}
+
+// insert adds s to the node for term
+func (t *trie) insert(term string, s registry.Suggestion) {
+	n := t.root
+	for _, r := range term {
+		child, ok := n.children[r]
+		if !ok {
+			child = &trieNode{children: map[rune]*trieNode{}}
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.entries == nil {
+		n.entries = map[registry.Suggestion]struct{}{}
+	}
+	n.entries[s] = struct{}{}
+}
+
+// remove drops s from the node for term, pruning empty nodes
+func (t *trie) remove(term string, s registry.Suggestion) {
+	t.root.remove([]rune(term), s)
+}
+
+// remove reports whether n is empty after removal
+func (n *trieNode) remove(term []rune, s registry.Suggestion) bool {
+	if len(term) == 0 {
+		delete(n.entries, s)
+	} else if child, ok := n.children[term[0]]; ok {
+		if child.remove(term[1:], s) {
+			delete(n.children, term[0])
+		}
+	}
+	return len(n.entries) == 0 && len(n.children) == 0
+}
+
+// search adds entries for terms that have a prefix within maxEdits of query
+// to top, with Distance set to the smallest edit distance. search walks the
+// trie computing one row of the levenshtein matrix per node, pruning
+// branches that can't come within maxEdits
+func (t *trie) search(query string, maxEdits int, top *topSuggestions) {
+	q := []rune(query)
+	if len(q) == 0 {
+		return
+	}
+
+	row := make([]int, len(q)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	var walk func(n *trieNode, r rune, prev []int, best int)
+	walk = func(n *trieNode, r rune, prev []int, best int) {
+		cur := make([]int, len(q)+1)
+		cur[0] = prev[0] + 1
+		min := cur[0]
+		for i := 1; i <= len(q); i++ {
+			cost := 1
+			if q[i-1] == r {
+				cost = 0
+			}
+			cur[i] = minInt(cur[i-1]+1, prev[i]+1, prev[i-1]+cost)
+			if cur[i] < min {
+				min = cur[i]
+			}
+		}
+
+		dist := cur[len(q)]
+		if dist <= maxEdits && dist < best {
+			n.collect(dist, top)
+			best = dist
+		}
+		// only descend if a deeper prefix could match more closely
+		if min <= maxEdits && min < best {
+			for cr, child := range n.children {
+				walk(child, cr, cur, best)
+			}
+		}
+	}
+
+	for r, child := range t.root.children {
+		walk(child, r, row, maxEdits+1)
+	}
+}
+
+// collect adds all entries at or below n to top with distance dist
+func (n *trieNode) collect(dist int, top *topSuggestions) {
+	for s := range n.entries {
+		s.Distance = dist
+		top.add(s)
+	}
+	for _, child := range n.children {
+		child.collect(dist, top)
+	}
+}
+
+func minInt(a int, rest ...int) int {
+	for _, b := range rest {
+		if b < a {
+			a = b
+		}
+	}
+	return a
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// SortKey orders search results by dataset key
+	SortKey = "key"
+	// SortHandle orders search results by dataset handle
+	SortHandle = "handle"
+	// SortName orders search results by dataset name
+	SortName = "name"
+	// SortTimestamp orders search results by commit timestamp
+	SortTimestamp = "timestamp"
+	// SortPopularity orders search results by dataset popularity
+	SortPopularity = "popularity"
+	// SortRelevance orders search results by relevance score
+	SortRelevance = "relevance"
+)
+
+// SearchFields lists the dataset fields searches match against
+var SearchFields = []string{"handle", "name", "title", "description", "keywords"}
+
+// DefaultBoosts are the relevance weights of matches in each dataset field
+var DefaultBoosts = map[string]float64{
+	"handle":      1,
+	"name":        2,
+	"title":       3,
+	"description": 1,
+	"keywords":    2,
+}
+
+// SortField is a single component of a sort order
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma-separated sort spec like "-timestamp,name".
+// Fields are one of key, handle, name, timestamp, popularity or relevance,
+// prefixed with "-" for descending or "+" for ascending order. Without a
+// prefix timestamp, popularity & relevance sort descending, others ascending
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		f := SortField{}
+		prefixed := false
+		switch {
+		case strings.HasPrefix(s, "-"):
+			f.Desc, prefixed = true, true
+		case strings.HasPrefix(s, "+"):
+			prefixed = true
+		}
+		if prefixed {
+			s = s[1:]
+		}
+
+		switch s {
+		case SortKey, SortHandle, SortName:
+		case SortTimestamp, SortPopularity, SortRelevance:
+			if !prefixed {
+				f.Desc = true
+			}
+		default:
+			return nil, NewError(ErrInvalid, "invalid sort: '%s'", s)
+		}
+		f.Field = s
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// DatasetFields gives the lowercased text of each of a dataset's SearchFields
+func DatasetFields(ds *Dataset) map[string]string {
+	fields := map[string]string{
+		"handle": strings.ToLower(ds.Handle),
+		"name":   strings.ToLower(ds.Name),
+	}
+	if ds.Meta != nil {
+		fields["title"] = strings.ToLower(ds.Meta.Title)
+		fields["description"] = strings.ToLower(ds.Meta.Description)
+		fields["keywords"] = strings.ToLower(strings.Join(ds.Meta.Keywords, "\n"))
+	}
+	return fields
+}
+
+// SortResults orders dataset search results by fields, using stats to
+// score popularity. stats may be nil, in which case popularity is ignored.
+// Results with equal values keep their existing order
+func SortResults(results []Result, fields []SortField, stats Stats) {
+	pop := map[string]int{}
+	if stats != nil {
+		for _, r := range results {
+			s, _ := stats.Load(r.ID)
+			pop[r.ID] = s.Popularity()
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		for _, f := range fields {
+			c := compareResults(a, b, f.Field, pop)
+			if c == 0 {
+				continue
+			}
+			if f.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compareResults returns -1, 0 or 1 comparing a & b by field
+func compareResults(a, b Result, field string, pop map[string]int) int {
+	da, _ := a.Value.(*Dataset)
+	db, _ := b.Value.(*Dataset)
+	switch field {
+	case SortKey:
+		return strings.Compare(a.ID, b.ID)
+	case SortHandle:
+		if da != nil && db != nil {
+			return strings.Compare(da.Handle, db.Handle)
+		}
+	case SortName:
+		if da != nil && db != nil {
+			return strings.Compare(da.Name, db.Name)
+		}
+	case SortTimestamp:
+		ta, tb := commitTime(da), commitTime(db)
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		}
+	case SortPopularity:
+		return compareInts(pop[a.ID], pop[b.ID])
+	case SortRelevance:
+		switch {
+		case a.Score < b.Score:
+			return -1
+		case a.Score > b.Score:
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// commitTime gives the commit timestamp of a dataset, or the zero time
+func commitTime(d *Dataset) time.Time {
+	if d == nil || d.Commit == nil {
+		return time.Time{}
+	}
+	return d.Commit.Timestamp
+}
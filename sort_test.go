@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestParseSort(t *testing.T) {
+	cases := []struct {
+		spec   string
+		expect []SortField
+		err    string
+	}{
+		{"name", []SortField{{SortName, false}}, ""},
+		{"-name", []SortField{{SortName, true}}, ""},
+		{"timestamp", []SortField{{SortTimestamp, true}}, ""},
+		{"+timestamp, key", []SortField{{SortTimestamp, false}, {SortKey, false}}, ""},
+		{"popularity,relevance", []SortField{{SortPopularity, true}, {SortRelevance, true}}, ""},
+		{"size", nil, "invalid sort: 'size'"},
+		{"name,", nil, "invalid sort: ''"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseSort(c.spec)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d expected %d fields, got: %d", i, len(c.expect), len(got))
+			continue
+		}
+		for j := range c.expect {
+			if got[j] != c.expect[j] {
+				t.Errorf("case %d field %d mismatch. expected: %v, got: %v", i, j, c.expect[j], got[j])
+			}
+		}
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	at := func(day int) *dataset.Commit {
+		return &dataset.Commit{Timestamp: time.Date(2001, 1, day, 0, 0, 0, 0, time.UTC)}
+	}
+	results := []Result{
+		{ID: "b5/a", Value: &Dataset{Handle: "b5", Name: "a", Commit: at(2)}, Score: 1},
+		{ID: "b5/b", Value: &Dataset{Handle: "b5", Name: "b", Commit: at(3)}, Score: 3},
+		{ID: "ramfox/a", Value: &Dataset{Handle: "ramfox", Name: "a", Commit: at(1)}, Score: 3},
+	}
+	stats := NewMemStats()
+	stats.Record("b5/a", StatPin, time.Now())
+
+	cases := []struct {
+		spec   string
+		expect []string
+	}{
+		{"-timestamp", []string{"b5/b", "b5/a", "ramfox/a"}},
+		{"name,-key", []string{"ramfox/a", "b5/a", "b5/b"}},
+		{"relevance,-handle", []string{"ramfox/a", "b5/b", "b5/a"}},
+		{"popularity,key", []string{"b5/a", "b5/b", "ramfox/a"}},
+	}
+	for _, c := range cases {
+		fields, err := ParseSort(c.spec)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		SortResults(results, fields, stats)
+		for i, key := range c.expect {
+			if results[i].ID != key {
+				t.Errorf("sort '%s' result %d mismatch. expected: %s, got: %s", c.spec, i, key, results[i].ID)
+			}
+		}
+	}
+}
+
+func TestSearchParamsBoosts(t *testing.T) {
+	p := SearchParams{Boosts: map[string]float64{"title": 10}}
+	if p.Boost("title") != 10 {
+		t.Errorf("expected boost override to apply")
+	}
+	if p.Boost("name") != DefaultBoosts["name"] {
+		t.Errorf("expected default boost for unset field")
+	}
+	if err := (SearchParams{Boosts: map[string]float64{"body": 1}}).ValidateBoosts(); err == nil {
+		t.Errorf("expected unknown boost field to error")
+	}
+	if err := (SearchParams{Boosts: map[string]float64{"title": -1}}).ValidateBoosts(); err == nil {
+		t.Errorf("expected negative boost to error")
+	}
+}
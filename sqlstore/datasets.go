@@ -0,0 +1,187 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/qri-io/registry"
+)
+
+// Datasets is a registry.DatasetStore backed by a SQL database. Datasets are
+// stored as JSON documents with indexed handle, path & profileID columns.
+// Each distinct path stored for a dataset is kept as a version
+type Datasets struct {
+	db *DB
+}
+
+// assert at compile time that Datasets is a registry.DatasetStore
+var _ registry.DatasetStore = (*Datasets)(nil)
+
+// NewDatasets creates a dataset store from a database
+func NewDatasets(db *DB) *Datasets {
+	return &Datasets{db: db}
+}
+
+// Version is a single recorded path of a dataset
+type Version struct {
+	Path    string
+	Created time.Time
+}
+
+// Len returns the number of datasets in the store
+func (s *Datasets) Len(ctx context.Context) (int, error) {
+	return s.db.count(ctx, "datasets")
+}
+
+// Get fetches a dataset by key
+func (s *Datasets) Get(ctx context.Context, key string) (*registry.Dataset, error) {
+	return s.getWhere(ctx, "id", key)
+}
+
+// GetByPath fetches a dataset by it's content-addressed path
+func (s *Datasets) GetByPath(ctx context.Context, path string) (*registry.Dataset, error) {
+	return s.getWhere(ctx, "path", path)
+}
+
+func (s *Datasets) getWhere(ctx context.Context, col, val string) (*registry.Dataset, error) {
+	if val == "" {
+		return nil, registry.ErrNotFound
+	}
+	var data string
+	q := s.db.rebind(`SELECT data FROM datasets WHERE ` + col + ` = ? ORDER BY id LIMIT 1`)
+	if err := s.db.QueryRowContext(ctx, q, val).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, registry.ErrNotFound
+		}
+		return nil, err
+	}
+	return decodeDataset(data)
+}
+
+// ListByHandle returns all datasets for a given handle, sorted by key
+func (s *Datasets) ListByHandle(ctx context.Context, handle string) ([]*registry.Dataset, error) {
+	return s.list(ctx, `SELECT data FROM datasets WHERE handle = ? ORDER BY id`, handle)
+}
+
+// ListByProfileID returns all datasets for a given profileID, sorted by key
+func (s *Datasets) ListByProfileID(ctx context.Context, profileID string) ([]*registry.Dataset, error) {
+	return s.list(ctx, `SELECT data FROM datasets WHERE profile_id = ? ORDER BY id`, profileID)
+}
+
+// List returns datasets sorted by key within the range defined by
+// limit & offset
+func (s *Datasets) List(ctx context.Context, limit, offset int) ([]*registry.Dataset, error) {
+	return s.list(ctx, limitOffset(`SELECT data FROM datasets ORDER BY id`, limit, offset))
+}
+
+func (s *Datasets) list(ctx context.Context, query string, args ...interface{}) ([]*registry.Dataset, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []*registry.Dataset{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		d, err := decodeDataset(data)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, d)
+	}
+	return ds, rows.Err()
+}
+
+// Put adds or replaces a dataset
+func (s *Datasets) Put(ctx context.Context, d *registry.Dataset) error {
+	return s.PutMany(ctx, []*registry.Dataset{d})
+}
+
+// PutMany adds or replaces a batch of datasets in a single transaction
+func (s *Datasets) PutMany(ctx context.Context, ds []*registry.Dataset) error {
+	upsert := s.db.rebind(`INSERT INTO datasets (id, handle, name, path, profile_id, data) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			handle = excluded.handle,
+			name = excluded.name,
+			path = excluded.path,
+			profile_id = excluded.profile_id,
+			data = excluded.data`)
+	version := s.db.rebind(`INSERT INTO dataset_versions (dataset_id, path, created) VALUES (?, ?, ?)
+		ON CONFLICT (dataset_id, path) DO NOTHING`)
+
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, d := range ds {
+			data, err := json.Marshal(d)
+			if err != nil {
+				return err
+			}
+			key := d.Key()
+			if _, err := tx.ExecContext(ctx, upsert, key, d.Handle, d.Name, d.Path, d.ProfileID, string(data)); err != nil {
+				return err
+			}
+			if d.Path != "" {
+				if _, err := tx.ExecContext(ctx, version, key, d.Path, time.Now().UTC()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a dataset & it's version history by key
+func (s *Datasets) Delete(ctx context.Context, key string) error {
+	return s.DeleteMany(ctx, []string{key})
+}
+
+// DeleteMany removes a batch of datasets by key in a single transaction
+func (s *Datasets) DeleteMany(ctx context.Context, keys []string) error {
+	delDs := s.db.rebind(`DELETE FROM datasets WHERE id = ?`)
+	delVersions := s.db.rebind(`DELETE FROM dataset_versions WHERE dataset_id = ?`)
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, key := range keys {
+			if _, err := tx.ExecContext(ctx, delDs, key); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, delVersions, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Versions lists the paths that have been stored for a dataset key,
+// oldest first
+func (s *Datasets) Versions(ctx context.Context, key string) ([]Version, error) {
+	q := s.db.rebind(`SELECT path, created FROM dataset_versions WHERE dataset_id = ? ORDER BY created, path`)
+	rows, err := s.db.QueryContext(ctx, q, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vs []Version
+	for rows.Next() {
+		v := Version{}
+		if err := rows.Scan(&v.Path, &v.Created); err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, rows.Err()
+}
+
+func decodeDataset(data string) (*registry.Dataset, error) {
+	d := &registry.Dataset{}
+	if err := json.Unmarshal([]byte(data), d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,116 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+)
+
+// migration is a single schema change. Migrations are applied in order &
+// must never be edited once released, add a new migration instead
+type migration struct {
+	Version    int
+	Statements []string
+}
+
+// migrations is the ordered list of schema changes
+var migrations = []migration{
+	{1, []string{
+		`CREATE TABLE profiles (
+			handle     TEXT PRIMARY KEY,
+			profile_id TEXT NOT NULL,
+			public_key TEXT NOT NULL,
+			signature  TEXT NOT NULL,
+			created    TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX profiles_profile_id_idx ON profiles (profile_id)`,
+		`CREATE INDEX profiles_public_key_idx ON profiles (public_key)`,
+
+		`CREATE TABLE datasets (
+			id         TEXT PRIMARY KEY,
+			handle     TEXT NOT NULL,
+			name       TEXT NOT NULL,
+			path       TEXT NOT NULL,
+			profile_id TEXT NOT NULL,
+			data       TEXT NOT NULL
+		)`,
+		`CREATE INDEX datasets_handle_idx ON datasets (handle)`,
+		`CREATE INDEX datasets_path_idx ON datasets (path)`,
+		`CREATE INDEX datasets_profile_id_idx ON datasets (profile_id)`,
+
+		`CREATE TABLE dataset_versions (
+			dataset_id TEXT NOT NULL,
+			path       TEXT NOT NULL,
+			created    TIMESTAMP NOT NULL,
+			PRIMARY KEY (dataset_id, path)
+		)`,
+
+		`CREATE TABLE reputations (
+			profile_id TEXT PRIMARY KEY,
+			rep        INTEGER NOT NULL
+		)`,
+
+		`CREATE TABLE pins (
+			path         TEXT PRIMARY KEY,
+			profile_id   TEXT NOT NULL,
+			pinned       BOOLEAN NOT NULL,
+			pct_complete REAL NOT NULL,
+			status       TEXT NOT NULL,
+			error        TEXT NOT NULL,
+			ttl          TIMESTAMP NOT NULL
+		)`,
+	}},
+	{2, []string{
+		`CREATE TABLE organizations (
+			handle TEXT PRIMARY KEY,
+			data   TEXT NOT NULL
+		)`,
+
+		`CREATE TABLE redirects (
+			from_key TEXT PRIMARY KEY,
+			to_key   TEXT NOT NULL,
+			created  TIMESTAMP NOT NULL
+		)`,
+	}},
+}
+
+// Migrate applies any pending migrations, recording applied versions in a
+// schema_migrations table. Each migration runs in it's own transaction
+func (db *DB) Migrate(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	current, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		err := db.inTx(ctx, func(tx *sql.Tx) error {
+			for _, stmt := range m.Statements {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, db.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), m.Version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the most recently applied migration version, zero if
+// no migrations have been applied
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var v sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, err
+	}
+	return int(v.Int64), nil
+}
@@ -0,0 +1,95 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/registry"
+)
+
+// Organizations is a registry.OrganizationStore backed by a SQL database.
+// Organizations are stored as JSON documents keyed by handle
+type Organizations struct {
+	db *DB
+}
+
+// assert at compile time that Organizations is a registry.OrganizationStore
+var _ registry.OrganizationStore = (*Organizations)(nil)
+
+// NewOrganizations creates an organization store from a database
+func NewOrganizations(db *DB) *Organizations {
+	return &Organizations{db: db}
+}
+
+// Len returns the number of organizations in the store
+func (s *Organizations) Len(ctx context.Context) (int, error) {
+	return s.db.count(ctx, "organizations")
+}
+
+// Get fetches an organization by handle
+func (s *Organizations) Get(ctx context.Context, handle string) (*registry.Organization, error) {
+	var data string
+	q := s.db.rebind(`SELECT data FROM organizations WHERE handle = ?`)
+	if err := s.db.QueryRowContext(ctx, q, handle).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, registry.ErrNotFound
+		}
+		return nil, err
+	}
+	return decodeOrganization(data)
+}
+
+// List returns organizations sorted by handle within the range defined by
+// limit & offset
+func (s *Organizations) List(ctx context.Context, limit, offset int) ([]*registry.Organization, error) {
+	rows, err := s.db.QueryContext(ctx, limitOffset(`SELECT data FROM organizations ORDER BY handle`, limit, offset))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []*registry.Organization
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		o, err := decodeOrganization(data)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+// Put adds or replaces an organization
+func (s *Organizations) Put(ctx context.Context, o *registry.Organization) error {
+	if o.Handle == "" {
+		return fmt.Errorf("handle is required")
+	}
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	q := s.db.rebind(`INSERT INTO organizations (handle, data) VALUES (?, ?)
+		ON CONFLICT (handle) DO UPDATE SET data = excluded.data`)
+	_, err = s.db.ExecContext(ctx, q, o.Handle, string(data))
+	return err
+}
+
+// Delete removes an organization by handle
+func (s *Organizations) Delete(ctx context.Context, handle string) error {
+	_, err := s.db.ExecContext(ctx, s.db.rebind(`DELETE FROM organizations WHERE handle = ?`), handle)
+	return err
+}
+
+func decodeOrganization(data string) (*registry.Organization, error) {
+	o := &registry.Organization{}
+	if err := json.Unmarshal([]byte(data), o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+// Pins is a pinset.Pinset that keeps a record of pins in a SQL database.
+// Like pinset.MemPinset it doesn't replicate any data itself, it's intended
+// for registries that pin content through an external process & need a
+// durable record of pin state
+type Pins struct {
+	db *DB
+}
+
+// assert at compile time that Pins is a pinset.Pinset
+var _ pinset.Pinset = (*Pins)(nil)
+
+// NewPins creates a pinset from a database
+func NewPins(db *DB) *Pins {
+	return &Pins{db: db}
+}
+
+// Pin records a path as pinned
+func (s *Pins) Pin(req *pinset.PinRequest) (chan pinset.PinStatus, error) {
+	status := pinset.PinStatus{
+		Path:        req.Path,
+		Pinned:      true,
+		PctComplete: 1.0,
+	}
+	if err := s.SetStatus(context.Background(), req.ProfileID, status); err != nil {
+		return nil, err
+	}
+
+	pc := make(chan pinset.PinStatus, 1)
+	pc <- status
+	close(pc)
+	return pc, nil
+}
+
+// SetStatus writes the status of a pin, external pinning processes use this
+// to report progress
+func (s *Pins) SetStatus(ctx context.Context, profileID string, ps pinset.PinStatus) error {
+	q := s.db.rebind(`INSERT INTO pins (path, profile_id, pinned, pct_complete, status, error, ttl) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (path) DO UPDATE SET
+			profile_id = excluded.profile_id,
+			pinned = excluded.pinned,
+			pct_complete = excluded.pct_complete,
+			status = excluded.status,
+			error = excluded.error,
+			ttl = excluded.ttl`)
+	_, err := s.db.ExecContext(ctx, q, ps.Path, profileID, ps.Pinned, ps.PctComplete, ps.Status, ps.Error, ps.TTL.UTC())
+	return err
+}
+
+// Unpin removes a pin record
+func (s *Pins) Unpin(req *pinset.PinRequest) error {
+	_, err := s.db.ExecContext(context.Background(), s.db.rebind(`DELETE FROM pins WHERE path = ?`), req.Path)
+	return err
+}
+
+// Status gets the current pin state for a given request
+func (s *Pins) Status(req *pinset.PinRequest) (pinset.PinStatus, error) {
+	ps := pinset.PinStatus{}
+	var ttl time.Time
+	q := s.db.rebind(`SELECT path, pinned, pct_complete, status, error, ttl FROM pins WHERE path = ?`)
+	err := s.db.QueryRowContext(context.Background(), q, req.Path).Scan(&ps.Path, &ps.Pinned, &ps.PctComplete, &ps.Status, &ps.Error, &ttl)
+	if err == sql.ErrNoRows {
+		return ps, registry.ErrNotFound
+	} else if err != nil {
+		return ps, err
+	}
+	ps.TTL = ttl
+	return ps, nil
+}
+
+// Pins lists pinned paths within the range defined by limit & offset in
+// lexographical order
+func (s *Pins) Pins(limit, offset int) ([]string, error) {
+	rows, err := s.db.QueryContext(context.Background(), limitOffset(`SELECT path FROM pins WHERE pinned = TRUE ORDER BY path`, limit, offset))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
+
+// PinLen returns the number of pinned paths
+func (s *Pins) PinLen() (n int, err error) {
+	err = s.db.QueryRowContext(context.Background(), `SELECT COUNT(*) FROM pins WHERE pinned = TRUE`).Scan(&n)
+	return n, err
+}
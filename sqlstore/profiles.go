@@ -0,0 +1,135 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/qri-io/registry"
+)
+
+// Profiles is a registry.ProfileStore backed by a SQL database
+type Profiles struct {
+	db *DB
+}
+
+// assert at compile time that Profiles is a registry.ProfileStore
+var _ registry.ProfileStore = (*Profiles)(nil)
+
+// NewProfiles creates a profile store from a database
+func NewProfiles(db *DB) *Profiles {
+	return &Profiles{db: db}
+}
+
+const profileCols = `handle, profile_id, public_key, signature, created`
+
+// Len returns the number of profiles in the store
+func (s *Profiles) Len(ctx context.Context) (int, error) {
+	return s.db.count(ctx, "profiles")
+}
+
+// Get fetches a profile by handle
+func (s *Profiles) Get(ctx context.Context, handle string) (*registry.Profile, error) {
+	return s.getWhere(ctx, "handle", handle)
+}
+
+// GetByProfileID fetches a profile by it's profileID
+func (s *Profiles) GetByProfileID(ctx context.Context, profileID string) (*registry.Profile, error) {
+	return s.getWhere(ctx, "profile_id", profileID)
+}
+
+// GetByPublicKey fetches a profile by it's base64-encoded public key
+func (s *Profiles) GetByPublicKey(ctx context.Context, pubKey string) (*registry.Profile, error) {
+	return s.getWhere(ctx, "public_key", pubKey)
+}
+
+func (s *Profiles) getWhere(ctx context.Context, col, val string) (*registry.Profile, error) {
+	if val == "" {
+		return nil, registry.ErrNotFound
+	}
+	q := s.db.rebind(fmt.Sprintf(`SELECT %s FROM profiles WHERE %s = ? ORDER BY handle LIMIT 1`, profileCols, col))
+	p, err := scanProfile(s.db.QueryRowContext(ctx, q, val))
+	if err == sql.ErrNoRows {
+		return nil, registry.ErrNotFound
+	}
+	return p, err
+}
+
+// List returns profiles sorted by handle within the range defined by
+// limit & offset
+func (s *Profiles) List(ctx context.Context, limit, offset int) ([]*registry.Profile, error) {
+	q := limitOffset(`SELECT `+profileCols+` FROM profiles ORDER BY handle`, limit, offset)
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ps []*registry.Profile
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, rows.Err()
+}
+
+// Put adds or replaces a profile
+func (s *Profiles) Put(ctx context.Context, p *registry.Profile) error {
+	return s.PutMany(ctx, []*registry.Profile{p})
+}
+
+// PutMany adds or replaces a batch of profiles in a single transaction
+func (s *Profiles) PutMany(ctx context.Context, ps []*registry.Profile) error {
+	q := s.db.rebind(`INSERT INTO profiles (` + profileCols + `) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (handle) DO UPDATE SET
+			profile_id = excluded.profile_id,
+			public_key = excluded.public_key,
+			signature = excluded.signature,
+			created = excluded.created`)
+
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, p := range ps {
+			if p.Handle == "" {
+				return fmt.Errorf("handle is required")
+			}
+			if _, err := tx.ExecContext(ctx, q, p.Handle, p.ProfileID, p.PublicKey, p.Signature, p.Created.UTC()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a profile by handle
+func (s *Profiles) Delete(ctx context.Context, handle string) error {
+	return s.DeleteMany(ctx, []string{handle})
+}
+
+// DeleteMany removes a batch of profiles by handle in a single transaction
+func (s *Profiles) DeleteMany(ctx context.Context, handles []string) error {
+	q := s.db.rebind(`DELETE FROM profiles WHERE handle = ?`)
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, handle := range handles {
+			if _, err := tx.ExecContext(ctx, q, handle); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProfile(row scanner) (*registry.Profile, error) {
+	p := &registry.Profile{}
+	if err := row.Scan(&p.Handle, &p.ProfileID, &p.PublicKey, &p.Signature, &p.Created); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,77 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/qri-io/registry"
+)
+
+// Redirects is a registry.RedirectStore backed by a SQL database
+type Redirects struct {
+	db *DB
+}
+
+// assert at compile time that Redirects is a registry.RedirectStore
+var _ registry.RedirectStore = (*Redirects)(nil)
+
+// NewRedirects creates a redirect store from a database
+func NewRedirects(db *DB) *Redirects {
+	return &Redirects{db: db}
+}
+
+// Len returns the number of redirects in the store
+func (s *Redirects) Len(ctx context.Context) (int, error) {
+	return s.db.count(ctx, "redirects")
+}
+
+// Get fetches a redirect by previous dataset key
+func (s *Redirects) Get(ctx context.Context, from string) (*registry.Redirect, error) {
+	r := &registry.Redirect{}
+	q := s.db.rebind(`SELECT from_key, to_key, created FROM redirects WHERE from_key = ?`)
+	if err := s.db.QueryRowContext(ctx, q, from).Scan(&r.From, &r.To, &r.Created); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, registry.ErrNotFound
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// List returns redirects sorted by previous dataset key within the range
+// defined by limit & offset
+func (s *Redirects) List(ctx context.Context, limit, offset int) ([]*registry.Redirect, error) {
+	rows, err := s.db.QueryContext(ctx, limitOffset(`SELECT from_key, to_key, created FROM redirects ORDER BY from_key`, limit, offset))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rs []*registry.Redirect
+	for rows.Next() {
+		r := &registry.Redirect{}
+		if err := rows.Scan(&r.From, &r.To, &r.Created); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+// Put adds or replaces a redirect
+func (s *Redirects) Put(ctx context.Context, r *registry.Redirect) error {
+	if r.From == "" || r.To == "" {
+		return fmt.Errorf("from and to are required")
+	}
+	q := s.db.rebind(`INSERT INTO redirects (from_key, to_key, created) VALUES (?, ?, ?)
+		ON CONFLICT (from_key) DO UPDATE SET to_key = excluded.to_key, created = excluded.created`)
+	_, err := s.db.ExecContext(ctx, q, r.From, r.To, r.Created.UTC())
+	return err
+}
+
+// Delete removes a redirect by previous dataset key
+func (s *Redirects) Delete(ctx context.Context, from string) error {
+	_, err := s.db.ExecContext(ctx, s.db.rebind(`DELETE FROM redirects WHERE from_key = ?`), from)
+	return err
+}
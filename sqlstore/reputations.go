@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/qri-io/registry"
+)
+
+// Reputations is a registry.ReputationStore backed by a SQL database
+type Reputations struct {
+	db *DB
+}
+
+// assert at compile time that Reputations is a registry.ReputationStore
+var _ registry.ReputationStore = (*Reputations)(nil)
+
+// NewReputations creates a reputation store from a database
+func NewReputations(db *DB) *Reputations {
+	return &Reputations{db: db}
+}
+
+// Len returns the number of reputations in the store
+func (s *Reputations) Len(ctx context.Context) (int, error) {
+	return s.db.count(ctx, "reputations")
+}
+
+// Get fetches a reputation by profileID
+func (s *Reputations) Get(ctx context.Context, profileID string) (*registry.Reputation, error) {
+	r := &registry.Reputation{}
+	q := s.db.rebind(`SELECT profile_id, rep FROM reputations WHERE profile_id = ?`)
+	if err := s.db.QueryRowContext(ctx, q, profileID).Scan(&r.ProfileID, &r.Rep); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, registry.ErrNotFound
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// List returns reputations sorted by profileID within the range defined by
+// limit & offset
+func (s *Reputations) List(ctx context.Context, limit, offset int) ([]*registry.Reputation, error) {
+	rows, err := s.db.QueryContext(ctx, limitOffset(`SELECT profile_id, rep FROM reputations ORDER BY profile_id`, limit, offset))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rs []*registry.Reputation
+	for rows.Next() {
+		r := &registry.Reputation{}
+		if err := rows.Scan(&r.ProfileID, &r.Rep); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+// Put validates & adds or replaces a reputation
+func (s *Reputations) Put(ctx context.Context, r *registry.Reputation) error {
+	return s.PutMany(ctx, []*registry.Reputation{r})
+}
+
+// PutMany validates & adds or replaces a batch of reputations in a single
+// transaction
+func (s *Reputations) PutMany(ctx context.Context, rs []*registry.Reputation) error {
+	q := s.db.rebind(`INSERT INTO reputations (profile_id, rep) VALUES (?, ?)
+		ON CONFLICT (profile_id) DO UPDATE SET rep = excluded.rep`)
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, r := range rs {
+			if err := r.Validate(); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, q, r.ProfileID, r.Rep); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a reputation by profileID
+func (s *Reputations) Delete(ctx context.Context, profileID string) error {
+	return s.DeleteMany(ctx, []string{profileID})
+}
+
+// DeleteMany removes a batch of reputations by profileID in a single
+// transaction
+func (s *Reputations) DeleteMany(ctx context.Context, profileIDs []string) error {
+	q := s.db.rebind(`DELETE FROM reputations WHERE profile_id = ?`)
+	return s.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range profileIDs {
+			if _, err := tx.ExecContext(ctx, q, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+// Package sqlstore implements registry stores over database/sql. Queries are
+// written to run on both Postgres (the production target) and SQLite
+// (used for local development & tests). Callers are responsible for
+// importing a database driver, eg: github.com/lib/pq or
+// github.com/mattn/go-sqlite3
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect names a supported SQL database flavour. Dialect strings match the
+// name their driver registers with database/sql
+type Dialect string
+
+const (
+	// Postgres is the dialect for PostgreSQL 9.5+
+	Postgres Dialect = "postgres"
+	// SQLite is the dialect for SQLite 3.24+
+	SQLite Dialect = "sqlite3"
+)
+
+// DB wraps a *sql.DB, adapting queries to a dialect
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open connects to a database with the driver registered for dialect &
+// applies any pending migrations
+func Open(ctx context.Context, dialect Dialect, dsn string) (*DB, error) {
+	switch dialect {
+	case Postgres, SQLite:
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect '%s'", dialect)
+	}
+
+	sqldb, err := sql.Open(string(dialect), dsn)
+	if err != nil {
+		return nil, err
+	}
+	db := NewDB(sqldb, dialect)
+	if err := db.Migrate(ctx); err != nil {
+		sqldb.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewDB wraps an existing database connection. NewDB doesn't run migrations
+func NewDB(db *sql.DB, dialect Dialect) *DB {
+	return &DB{DB: db, Dialect: dialect}
+}
+
+// rebind converts "?" placeholders in a query to the dialect's bind style
+func (db *DB) rebind(query string) string {
+	if db.Dialect != Postgres {
+		return query
+	}
+	var (
+		b strings.Builder
+		n int
+	)
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// inTx runs fn inside a transaction, committing if fn returns nil
+func (db *DB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// limitOffset appends a limit & offset clause to a query. negative limits
+// return all rows from offset onward
+func limitOffset(query string, limit, offset int) string {
+	if limit < 0 {
+		// both postgres & sqlite accept -1 / ALL semantics differently,
+		// so use a very large limit instead
+		limit = 1<<31 - 1
+	}
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// count returns the number of rows in a table
+func (db *DB) count(ctx context.Context, table string) (n int, err error) {
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n)
+	return n, err
+}
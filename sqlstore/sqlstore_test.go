@@ -0,0 +1,340 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+var dbCount int32
+
+// newTestDB opens a fresh, migrated in-memory sqlite database
+func newTestDB(t *testing.T) *DB {
+	// each test gets it's own named in-memory database. shared cache keeps
+	// the database alive across pooled connections
+	n := atomic.AddInt32(&dbCount, 1)
+	dsn := fmt.Sprintf("file:sqlstore_test_%d?mode=memory&cache=shared", n)
+	db, err := Open(context.Background(), SQLite, dsn)
+	if err != nil {
+		t.Fatalf("opening test db: %s", err.Error())
+	}
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestOpenUnsupportedDialect(t *testing.T) {
+	if _, err := Open(context.Background(), Dialect("oracle"), ""); err == nil {
+		t.Errorf("expected unsupported dialect to error")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	db := &DB{Dialect: Postgres}
+	got := db.rebind("SELECT a FROM b WHERE c = ? AND d = ?")
+	expect := "SELECT a FROM b WHERE c = $1 AND d = $2"
+	if got != expect {
+		t.Errorf("rebind mismatch. expected: %s, got: %s", expect, got)
+	}
+	db.Dialect = SQLite
+	if got := db.rebind("c = ?"); got != "c = ?" {
+		t.Errorf("expected sqlite query to be unchanged, got: %s", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+
+	v, err := db.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expect := migrations[len(migrations)-1].Version; v != expect {
+		t.Errorf("schema version mismatch. expected: %d, got: %d", expect, v)
+	}
+
+	// migrating again should be a no-op
+	if err := db.Migrate(ctx); err != nil {
+		t.Errorf("re-running migrations: %s", err.Error())
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewProfiles(db)
+
+	created := time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)
+	err := s.PutMany(ctx, []*registry.Profile{
+		{Handle: "b", ProfileID: "QmB", PublicKey: "keyB", Created: created},
+		{Handle: "a", ProfileID: "QmA", PublicKey: "keyA", Created: created},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := s.Put(ctx, &registry.Profile{}); err == nil {
+		t.Errorf("expected profile without handle to error")
+	}
+
+	if n, err := s.Len(ctx); err != nil || n != 2 {
+		t.Errorf("expected len 2, got: %d, err: %v", n, err)
+	}
+	p, err := s.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if p.ProfileID != "QmA" || !p.Created.Equal(created) {
+		t.Errorf("profile mismatch: %#v", p)
+	}
+	if p, err := s.GetByProfileID(ctx, "QmB"); err != nil || p.Handle != "b" {
+		t.Errorf("expected profileID QmB to return handle 'b', err: %v", err)
+	}
+	if p, err := s.GetByPublicKey(ctx, "keyA"); err != nil || p.Handle != "a" {
+		t.Errorf("expected public key keyA to return handle 'a', err: %v", err)
+	}
+	if _, err := s.Get(ctx, "missing"); err != registry.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+
+	// upsert replaces existing records
+	if err := s.Put(ctx, &registry.Profile{Handle: "a", ProfileID: "QmA2", PublicKey: "keyA2"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	ps, err := s.List(ctx, -1, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ps) != 2 || ps[0].Handle != "a" || ps[0].ProfileID != "QmA2" {
+		t.Errorf("list mismatch after upsert")
+	}
+	if ps, _ := s.List(ctx, 1, 1); len(ps) != 1 || ps[0].Handle != "b" {
+		t.Errorf("expected limit 1 offset 1 to list 'b'")
+	}
+
+	if err := s.DeleteMany(ctx, []string{"a", "b"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n, _ := s.Len(ctx); n != 0 {
+		t.Errorf("expected empty store after delete, got: %d", n)
+	}
+}
+
+func TestDatasets(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewDatasets(db)
+
+	ds := &registry.Dataset{
+		Handle:    "a",
+		Name:      "foo",
+		Path:      "/ipfs/QmFoo",
+		ProfileID: "QmA",
+		Meta:      &dataset.Meta{Title: "foo title"},
+		Structure: &dataset.Structure{Checksum: "QmSum"},
+	}
+	if err := s.PutMany(ctx, []*registry.Dataset{ds, {Handle: "a", Name: "bar", Path: "/ipfs/QmBar", ProfileID: "QmA"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	got, err := s.Get(ctx, "a/foo")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Meta == nil || got.Meta.Title != "foo title" || got.Structure.Checksum != "QmSum" {
+		t.Errorf("expected dataset document to round trip")
+	}
+	if got, err := s.GetByPath(ctx, "/ipfs/QmBar"); err != nil || got.Name != "bar" {
+		t.Errorf("expected path /ipfs/QmBar to load 'bar', err: %v", err)
+	}
+	if list, err := s.ListByHandle(ctx, "a"); err != nil || len(list) != 2 || list[0].Name != "bar" {
+		t.Errorf("expected handle 'a' to list [bar foo], err: %v", err)
+	}
+	if list, err := s.ListByProfileID(ctx, "QmA"); err != nil || len(list) != 2 {
+		t.Errorf("expected 2 datasets for profileID QmA, err: %v", err)
+	}
+
+	// storing a new path records a version
+	ds.Path = "/ipfs/QmFoo2"
+	if err := s.Put(ctx, ds); err != nil {
+		t.Fatal(err.Error())
+	}
+	vs, err := s.Versions(ctx, "a/foo")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(vs) != 2 {
+		t.Errorf("expected 2 versions, got: %d", len(vs))
+	}
+	if _, err := s.GetByPath(ctx, "/ipfs/QmFoo"); err != registry.ErrNotFound {
+		t.Errorf("expected stale path to return ErrNotFound, got: %v", err)
+	}
+
+	if err := s.Delete(ctx, "a/foo"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if vs, _ := s.Versions(ctx, "a/foo"); len(vs) != 0 {
+		t.Errorf("expected versions to be removed with dataset")
+	}
+	if n, _ := s.Len(ctx); n != 1 {
+		t.Errorf("expected len 1, got: %d", n)
+	}
+}
+
+func TestReputations(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewReputations(db)
+
+	if err := s.Put(ctx, &registry.Reputation{}); err == nil {
+		t.Errorf("expected invalid reputation to error")
+	}
+	if err := s.PutMany(ctx, []*registry.Reputation{registry.NewReputation("QmA"), {ProfileID: "QmB", Rep: 5}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if r, err := s.Get(ctx, "QmB"); err != nil || r.Rep != 5 {
+		t.Errorf("expected QmB to have rep 5, err: %v", err)
+	}
+	if rs, err := s.List(ctx, -1, 0); err != nil || len(rs) != 2 || rs[0].ProfileID != "QmA" {
+		t.Errorf("list mismatch, err: %v", err)
+	}
+	if err := s.Delete(ctx, "QmA"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := s.Get(ctx, "QmA"); err != registry.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestPins(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewPins(db)
+
+	req := &pinset.PinRequest{Path: "/ipfs/QmFoo", ProfileID: "QmA"}
+	if _, err := s.Status(req); err != registry.ErrNotFound {
+		t.Errorf("expected status of unknown pin to be ErrNotFound, got: %v", err)
+	}
+
+	statuses, err := s.Pin(req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if st := <-statuses; !st.Pinned {
+		t.Errorf("expected pin status to be pinned")
+	}
+	if st, err := s.Status(req); err != nil || !st.Pinned {
+		t.Errorf("expected stored status to be pinned, err: %v", err)
+	}
+	if n, err := s.PinLen(); err != nil || n != 1 {
+		t.Errorf("expected 1 pin, got: %d, err: %v", n, err)
+	}
+	if pins, err := s.Pins(10, 0); err != nil || len(pins) != 1 || pins[0] != req.Path {
+		t.Errorf("pins mismatch: %v, err: %v", pins, err)
+	}
+
+	if err := s.Unpin(req); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n, _ := s.PinLen(); n != 0 {
+		t.Errorf("expected 0 pins after unpin, got: %d", n)
+	}
+}
+
+func TestLegacyAdapter(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	// sql stores should work with the existing registration functions
+	// through the legacy adapters
+	ps := registry.NewLegacyProfiles(NewProfiles(db), func(err error) { t.Error(err.Error()) })
+	ps.Store("a", &registry.Profile{Handle: "a", ProfileID: "QmA", PublicKey: "keyA"})
+	if p, ok := ps.LoadByPublicKey("keyA"); !ok || p.Handle != "a" {
+		t.Errorf("expected legacy adapter to load by public key")
+	}
+}
+
+func TestOrganizations(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewOrganizations(db)
+
+	if err := s.Put(ctx, &registry.Organization{}); err == nil {
+		t.Errorf("expected organization without handle to error")
+	}
+	created := time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)
+	for _, o := range []*registry.Organization{
+		{Handle: "b", Owners: []string{"QmB"}, Created: created, Updated: created},
+		{Handle: "a", Owners: []string{"QmA"}, Members: []string{"QmB"}, Created: created, Updated: created},
+	} {
+		if err := s.Put(ctx, o); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	o, err := s.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !o.IsOwner("QmA") || !o.IsMember("QmB") || !o.Updated.Equal(created) {
+		t.Errorf("organization mismatch: %#v", o)
+	}
+	if orgs, err := s.List(ctx, -1, 0); err != nil || len(orgs) != 2 || orgs[0].Handle != "a" {
+		t.Errorf("list mismatch, err: %v", err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := s.Get(ctx, "a"); err != registry.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	defer db.Close()
+	s := NewRedirects(db)
+
+	if err := s.Put(ctx, &registry.Redirect{From: "a/foo"}); err == nil {
+		t.Errorf("expected redirect without to to error")
+	}
+	created := time.Date(2001, 1, 1, 1, 1, 1, 0, time.UTC)
+	if err := s.Put(ctx, &registry.Redirect{From: "a/foo", To: "a/bar", Created: created}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := s.Put(ctx, &registry.Redirect{From: "a/foo", To: "a/baz", Created: created}); err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := s.Get(ctx, "a/foo")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if r.To != "a/baz" || !r.Created.Equal(created) {
+		t.Errorf("expected upserted redirect, got: %#v", r)
+	}
+	if n, err := s.Len(ctx); err != nil || n != 1 {
+		t.Errorf("expected len 1, got: %d, err: %v", n, err)
+	}
+
+	// moves should resolve through the legacy adapter
+	rs := registry.NewLegacyRedirects(s, func(err error) { t.Error(err.Error()) })
+	if to, ok := registry.ResolveRedirect(rs, "a/foo"); !ok || to != "a/baz" {
+		t.Errorf("expected a/foo to resolve to a/baz, got: %s", to)
+	}
+	rs.Delete("a/foo")
+	if rs.Len() != 0 {
+		t.Errorf("expected redirect to be removed")
+	}
+}
@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// StatLookup counts dataset lookups from GET /dataset/<ref>
+	StatLookup = "lookup"
+	// StatFetch counts dsync manifest fetches of a dataset
+	StatFetch = "fetch"
+	// StatPin counts pins of a dataset
+	StatPin = "pin"
+)
+
+// StatsRetentionDays is the number of days MemStats keeps daily counters
+// for. Datasets with no activity in that time are dropped, totals included
+var StatsRetentionDays = 90
+
+// dayFormat is the layout of DailyStats.Day
+const dayFormat = "2006-01-02"
+
+// DailyStats counts dataset events for a single UTC day
+type DailyStats struct {
+	Day     string `json:"day"`
+	Lookups int    `json:"lookups"`
+	Fetches int    `json:"fetches"`
+	Pins    int    `json:"pins"`
+}
+
+// add counts an event of kind
+func (s *DailyStats) add(kind string) {
+	switch kind {
+	case StatLookup:
+		s.Lookups++
+	case StatFetch:
+		s.Fetches++
+	case StatPin:
+		s.Pins++
+	}
+}
+
+// DatasetStats summarizes use of a dataset. Daily is ordered oldest to
+// newest and omitted when stats are aggregated over a window
+type DatasetStats struct {
+	Key     string       `json:"key,omitempty"`
+	Lookups int          `json:"lookups"`
+	Fetches int          `json:"fetches"`
+	Pins    int          `json:"pins"`
+	Daily   []DailyStats `json:"daily,omitempty"`
+}
+
+// Popularity scores a dataset by use. Fetches & pins are stronger signals of
+// use than lookups, and are weighted accordingly
+func (s *DatasetStats) Popularity() int {
+	if s == nil {
+		return 0
+	}
+	return s.Lookups + s.Fetches*5 + s.Pins*10
+}
+
+// Stats is the interface for tracking dataset use, keyed by dataset key
+type Stats interface {
+	// Record counts an event of kind for the dataset at key
+	Record(key, kind string, t time.Time)
+	// Load returns totals & daily counters for the dataset at key
+	Load(key string) (stats *DatasetStats, ok bool)
+	// Trending lists datasets with activity since a given time, ordered
+	// by descending popularity within that window
+	Trending(since time.Time, limit int) []*DatasetStats
+	// Move transfers counters recorded for the dataset at from to the
+	// dataset at to, merging with any counters already recorded for to
+	Move(from, to string)
+}
+
+// MemStats is an in-memory implementation of Stats safe for concurrent use
+type MemStats struct {
+	sync.RWMutex
+	totals map[string]*DatasetStats
+	days   map[string]map[string]*DailyStats
+	// swept is the latest day expired counters were dropped for
+	swept string
+}
+
+// NewMemStats allocates a new *MemStats
+func NewMemStats() *MemStats {
+	return &MemStats{
+		totals: make(map[string]*DatasetStats),
+		days:   make(map[string]map[string]*DailyStats),
+	}
+}
+
+// Record counts an event of kind for the dataset at key. The first event
+// recorded on each day drops daily counters older than StatsRetentionDays,
+// along with datasets that have no remaining counters
+func (ms *MemStats) Record(key, kind string, t time.Time) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	total, ok := ms.totals[key]
+	if !ok {
+		total = &DatasetStats{Key: key}
+		ms.totals[key] = total
+		ms.days[key] = map[string]*DailyStats{}
+	}
+	switch kind {
+	case StatLookup:
+		total.Lookups++
+	case StatFetch:
+		total.Fetches++
+	case StatPin:
+		total.Pins++
+	}
+
+	day := t.UTC().Format(dayFormat)
+	days := ms.days[key]
+	if days[day] == nil {
+		days[day] = &DailyStats{Day: day}
+	}
+	days[day].add(kind)
+
+	if day > ms.swept {
+		ms.swept = day
+		ms.expire(t.UTC().AddDate(0, 0, -StatsRetentionDays).Format(dayFormat))
+	}
+}
+
+// expire drops daily counters before cutoff & datasets left without any.
+// callers must hold the write lock
+func (ms *MemStats) expire(cutoff string) {
+	for key, days := range ms.days {
+		for d := range days {
+			if d < cutoff {
+				delete(days, d)
+			}
+		}
+		if len(days) == 0 {
+			delete(ms.days, key)
+			delete(ms.totals, key)
+		}
+	}
+}
+
+// Move transfers counters recorded for the dataset at from to the dataset at
+// to, merging with any counters already recorded for to
+func (ms *MemStats) Move(from, to string) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	total, ok := ms.totals[from]
+	if !ok || from == to {
+		return
+	}
+	days := ms.days[from]
+	delete(ms.totals, from)
+	delete(ms.days, from)
+
+	if prev, ok := ms.totals[to]; ok {
+		prev.Lookups += total.Lookups
+		prev.Fetches += total.Fetches
+		prev.Pins += total.Pins
+		for day, d := range days {
+			if merged := ms.days[to][day]; merged != nil {
+				merged.Lookups += d.Lookups
+				merged.Fetches += d.Fetches
+				merged.Pins += d.Pins
+			} else {
+				ms.days[to][day] = d
+			}
+		}
+		return
+	}
+	total.Key = to
+	ms.totals[to] = total
+	ms.days[to] = days
+}
+
+// Load returns totals & daily counters for the dataset at key
+func (ms *MemStats) Load(key string) (*DatasetStats, bool) {
+	ms.RLock()
+	defer ms.RUnlock()
+
+	total, ok := ms.totals[key]
+	if !ok {
+		return nil, false
+	}
+	stats := *total
+	stats.Daily = make([]DailyStats, 0, len(ms.days[key]))
+	for _, d := range ms.days[key] {
+		stats.Daily = append(stats.Daily, *d)
+	}
+	sort.Slice(stats.Daily, func(i, j int) bool { return stats.Daily[i].Day < stats.Daily[j].Day })
+	return &stats, true
+}
+
+// Trending lists datasets with activity since a given time, ordered by
+// descending popularity within that window. a limit <= 0 returns all datasets
+func (ms *MemStats) Trending(since time.Time, limit int) []*DatasetStats {
+	ms.RLock()
+	defer ms.RUnlock()
+
+	start := since.UTC().Format(dayFormat)
+	var trending []*DatasetStats
+	for key, days := range ms.days {
+		s := &DatasetStats{Key: key}
+		for day, d := range days {
+			if day >= start {
+				s.Lookups += d.Lookups
+				s.Fetches += d.Fetches
+				s.Pins += d.Pins
+			}
+		}
+		if s.Popularity() > 0 {
+			trending = append(trending, s)
+		}
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if pi, pj := trending[i].Popularity(), trending[j].Popularity(); pi != pj {
+			return pi > pj
+		}
+		return trending[i].Key < trending[j].Key
+	})
+	if limit > 0 && len(trending) > limit {
+		trending = trending[:limit]
+	}
+	return trending
+}
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemStats(t *testing.T) {
+	now := time.Date(2001, 1, 10, 12, 0, 0, 0, time.UTC)
+	ms := NewMemStats()
+
+	if _, ok := ms.Load("b5/cities"); ok {
+		t.Errorf("expected missing stats to not load")
+	}
+
+	ms.Record("b5/cities", StatLookup, now.AddDate(0, 0, -3))
+	ms.Record("b5/cities", StatLookup, now)
+	ms.Record("b5/cities", StatFetch, now)
+	ms.Record("b5/towns", StatPin, now.AddDate(0, 0, -3))
+	ms.Record("b5/villages", StatLookup, now)
+
+	s, ok := ms.Load("b5/cities")
+	if !ok {
+		t.Fatal("expected stats to load")
+	}
+	if s.Lookups != 2 || s.Fetches != 1 || s.Pins != 0 {
+		t.Errorf("totals mismatch. got: %#v", s)
+	}
+	if len(s.Daily) != 2 || s.Daily[0].Day != "2001-01-07" || s.Daily[1].Day != "2001-01-10" {
+		t.Errorf("expected 2 ordered daily counters, got: %v", s.Daily)
+	}
+	if s.Popularity() != 7 {
+		t.Errorf("expected popularity of 7, got: %d", s.Popularity())
+	}
+
+	trending := ms.Trending(now.AddDate(0, 0, -7), 0)
+	expect := []string{"b5/towns", "b5/cities", "b5/villages"}
+	if len(trending) != len(expect) {
+		t.Fatalf("expected %d trending datasets, got: %d", len(expect), len(trending))
+	}
+	for i, key := range expect {
+		if trending[i].Key != key {
+			t.Errorf("trending %d mismatch. expected: %s, got: %s", i, key, trending[i].Key)
+		}
+	}
+
+	trending = ms.Trending(now.AddDate(0, 0, -1), 1)
+	if len(trending) != 1 || trending[0].Key != "b5/cities" || trending[0].Lookups != 1 {
+		t.Errorf("expected window to only count recent events, got: %v", trending)
+	}
+
+	// recording far in the future drops expired daily counters but keeps totals
+	ms.Record("b5/cities", StatLookup, now.AddDate(0, 0, StatsRetentionDays+1))
+	s, _ = ms.Load("b5/cities")
+	if len(s.Daily) != 1 {
+		t.Errorf("expected expired daily counters to be dropped, got: %d", len(s.Daily))
+	}
+	if s.Lookups != 3 {
+		t.Errorf("expected totals to be kept, got: %d lookups", s.Lookups)
+	}
+	if _, ok := ms.Load("b5/towns"); ok {
+		t.Errorf("expected datasets without recent activity to be dropped")
+	}
+}
+
+func TestMemStatsMove(t *testing.T) {
+	now := time.Date(2001, 1, 10, 12, 0, 0, 0, time.UTC)
+	ms := NewMemStats()
+	ms.Record("b5/cities", StatLookup, now.AddDate(0, 0, -1))
+	ms.Record("b5/cities", StatPin, now)
+	ms.Record("b5/towns", StatLookup, now)
+
+	ms.Move("b5/cities", "b5/towns")
+	if _, ok := ms.Load("b5/cities"); ok {
+		t.Errorf("expected stats to be moved from the previous key")
+	}
+	s, ok := ms.Load("b5/towns")
+	if !ok {
+		t.Fatal("expected stats to load at the new key")
+	}
+	if s.Key != "b5/towns" || s.Lookups != 2 || s.Pins != 1 {
+		t.Errorf("expected moved totals to merge, got: %#v", s)
+	}
+	if len(s.Daily) != 2 || s.Daily[1].Lookups != 1 || s.Daily[1].Pins != 1 {
+		t.Errorf("expected moved daily counters to merge, got: %v", s.Daily)
+	}
+
+	ms.Move("b5/towns", "b5/villages")
+	if s, ok := ms.Load("b5/villages"); !ok || s.Key != "b5/villages" || s.Popularity() != 12 {
+		t.Errorf("expected stats to move to an unused key, got: %#v", s)
+	}
+}
@@ -0,0 +1,383 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProfileStore is the context-aware, error-returning successor to Profiles.
+// Profiles are keyed by handle. List methods return records sorted by key,
+// a negative limit returns all records from offset onward
+type ProfileStore interface {
+	// Len returns the number of records in the store
+	Len(ctx context.Context) (int, error)
+	// Get fetches a profile by handle, returning ErrNotFound if none exists
+	Get(ctx context.Context, handle string) (*Profile, error)
+	// GetByProfileID fetches a profile by it's profileID
+	GetByProfileID(ctx context.Context, profileID string) (*Profile, error)
+	// GetByPublicKey fetches a profile by it's base64-encoded public key
+	GetByPublicKey(ctx context.Context, pubKey string) (*Profile, error)
+	// List returns profiles within the range defined by limit & offset
+	List(ctx context.Context, limit, offset int) ([]*Profile, error)
+	// Put adds or replaces a profile, bypassing the register process
+	Put(ctx context.Context, p *Profile) error
+	// PutMany adds or replaces a batch of profiles
+	PutMany(ctx context.Context, ps []*Profile) error
+	// Delete removes a profile by handle
+	Delete(ctx context.Context, handle string) error
+	// DeleteMany removes a batch of profiles by handle
+	DeleteMany(ctx context.Context, handles []string) error
+}
+
+// DatasetStore is the context-aware, error-returning successor to Datasets.
+// Datasets are keyed by Dataset.Key(). List methods return records sorted by
+// key, a negative limit returns all records from offset onward
+type DatasetStore interface {
+	// Len returns the number of records in the store
+	Len(ctx context.Context) (int, error)
+	// Get fetches a dataset by key, returning ErrNotFound if none exists
+	Get(ctx context.Context, key string) (*Dataset, error)
+	// GetByPath fetches a dataset by it's content-addressed path
+	GetByPath(ctx context.Context, path string) (*Dataset, error)
+	// ListByHandle returns all datasets for a given handle
+	ListByHandle(ctx context.Context, handle string) ([]*Dataset, error)
+	// ListByProfileID returns all datasets for a given profileID
+	ListByProfileID(ctx context.Context, profileID string) ([]*Dataset, error)
+	// List returns datasets within the range defined by limit & offset
+	List(ctx context.Context, limit, offset int) ([]*Dataset, error)
+	// Put adds or replaces a dataset, bypassing the register process
+	Put(ctx context.Context, d *Dataset) error
+	// PutMany adds or replaces a batch of datasets
+	PutMany(ctx context.Context, ds []*Dataset) error
+	// Delete removes a dataset by key
+	Delete(ctx context.Context, key string) error
+	// DeleteMany removes a batch of datasets by key
+	DeleteMany(ctx context.Context, keys []string) error
+}
+
+// ReputationStore is the context-aware, error-returning successor to
+// Reputations. Reputations are keyed by profileID. List returns records
+// sorted by key, a negative limit returns all records from offset onward
+type ReputationStore interface {
+	// Len returns the number of records in the store
+	Len(ctx context.Context) (int, error)
+	// Get fetches a reputation by profileID, returning ErrNotFound if none
+	// exists
+	Get(ctx context.Context, profileID string) (*Reputation, error)
+	// List returns reputations within the range defined by limit & offset
+	List(ctx context.Context, limit, offset int) ([]*Reputation, error)
+	// Put validates & adds or replaces a reputation
+	Put(ctx context.Context, r *Reputation) error
+	// PutMany validates & adds or replaces a batch of reputations
+	PutMany(ctx context.Context, rs []*Reputation) error
+	// Delete removes a reputation by profileID
+	Delete(ctx context.Context, profileID string) error
+	// DeleteMany removes a batch of reputations by profileID
+	DeleteMany(ctx context.Context, profileIDs []string) error
+}
+
+// OrganizationStore is the context-aware, error-returning successor to
+// Organizations. Organizations are keyed by handle. List returns records
+// sorted by key, a negative limit returns all records from offset onward
+type OrganizationStore interface {
+	// Len returns the number of records in the store
+	Len(ctx context.Context) (int, error)
+	// Get fetches an organization by handle, returning ErrNotFound if none
+	// exists
+	Get(ctx context.Context, handle string) (*Organization, error)
+	// List returns organizations within the range defined by limit & offset
+	List(ctx context.Context, limit, offset int) ([]*Organization, error)
+	// Put adds or replaces an organization, bypassing the register process
+	Put(ctx context.Context, o *Organization) error
+	// Delete removes an organization by handle
+	Delete(ctx context.Context, handle string) error
+}
+
+// RedirectStore is the context-aware, error-returning successor to
+// Redirects. Redirects are keyed by the dataset key they're from. List
+// returns records sorted by key, a negative limit returns all records from
+// offset onward
+type RedirectStore interface {
+	// Len returns the number of records in the store
+	Len(ctx context.Context) (int, error)
+	// Get fetches a redirect by previous dataset key, returning ErrNotFound
+	// if none exists
+	Get(ctx context.Context, from string) (*Redirect, error)
+	// List returns redirects within the range defined by limit & offset
+	List(ctx context.Context, limit, offset int) ([]*Redirect, error)
+	// Put adds or replaces a redirect
+	Put(ctx context.Context, r *Redirect) error
+	// Delete removes a redirect by previous dataset key
+	Delete(ctx context.Context, from string) error
+}
+
+// NewProfileStore adapts a Profiles implementation to the ProfileStore
+// interface
+func NewProfileStore(ps Profiles) ProfileStore {
+	return profileStore{ps}
+}
+
+// profileStore wraps a Profiles, satisfying ProfileStore
+type profileStore struct {
+	ps Profiles
+}
+
+func (s profileStore) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.ps.Len(), nil
+}
+
+func (s profileStore) Get(ctx context.Context, handle string) (*Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return profileOrNotFound(s.ps.Load(handle))
+}
+
+func (s profileStore) GetByProfileID(ctx context.Context, profileID string) (*Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return profileOrNotFound(s.ps.LoadByProfileID(profileID))
+}
+
+func (s profileStore) GetByPublicKey(ctx context.Context, pubKey string) (*Profile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return profileOrNotFound(s.ps.LoadByPublicKey(pubKey))
+}
+
+func (s profileStore) List(ctx context.Context, limit, offset int) (res []*Profile, err error) {
+	s.ps.SortedRange(func(key string, p *Profile) bool {
+		if err = ctx.Err(); err != nil {
+			return true
+		}
+		if offset > 0 {
+			offset--
+			return false
+		}
+		if limit >= 0 && len(res) == limit {
+			return true
+		}
+		res = append(res, p)
+		return false
+	})
+	return res, err
+}
+
+func (s profileStore) Put(ctx context.Context, p *Profile) error {
+	return s.PutMany(ctx, []*Profile{p})
+}
+
+func (s profileStore) PutMany(ctx context.Context, ps []*Profile) error {
+	for _, p := range ps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p.Handle == "" {
+			return fmt.Errorf("handle is required")
+		}
+		s.ps.Store(p.Handle, p)
+	}
+	return nil
+}
+
+func (s profileStore) Delete(ctx context.Context, handle string) error {
+	return s.DeleteMany(ctx, []string{handle})
+}
+
+func (s profileStore) DeleteMany(ctx context.Context, handles []string) error {
+	for _, handle := range handles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.ps.Delete(handle)
+	}
+	return nil
+}
+
+func profileOrNotFound(p *Profile, ok bool) (*Profile, error) {
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// NewDatasetStore adapts a Datasets implementation to the DatasetStore
+// interface
+func NewDatasetStore(ds Datasets) DatasetStore {
+	return datasetStore{ds}
+}
+
+// datasetStore wraps a Datasets, satisfying DatasetStore
+type datasetStore struct {
+	ds Datasets
+}
+
+func (s datasetStore) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.ds.Len(), nil
+}
+
+func (s datasetStore) Get(ctx context.Context, key string) (*Dataset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return datasetOrNotFound(s.ds.Load(key))
+}
+
+func (s datasetStore) GetByPath(ctx context.Context, path string) (*Dataset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return datasetOrNotFound(s.ds.LoadByPath(path))
+}
+
+func (s datasetStore) ListByHandle(ctx context.Context, handle string) ([]*Dataset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListByHandle(handle), nil
+}
+
+func (s datasetStore) ListByProfileID(ctx context.Context, profileID string) ([]*Dataset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListByProfileID(profileID), nil
+}
+
+func (s datasetStore) List(ctx context.Context, limit, offset int) (res []*Dataset, err error) {
+	s.ds.SortedRange(func(key string, d *Dataset) bool {
+		if err = ctx.Err(); err != nil {
+			return true
+		}
+		if offset > 0 {
+			offset--
+			return false
+		}
+		if limit >= 0 && len(res) == limit {
+			return true
+		}
+		res = append(res, d)
+		return false
+	})
+	return res, err
+}
+
+func (s datasetStore) Put(ctx context.Context, d *Dataset) error {
+	return s.PutMany(ctx, []*Dataset{d})
+}
+
+func (s datasetStore) PutMany(ctx context.Context, ds []*Dataset) error {
+	for _, d := range ds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.ds.Store(d.Key(), d)
+	}
+	return nil
+}
+
+func (s datasetStore) Delete(ctx context.Context, key string) error {
+	return s.DeleteMany(ctx, []string{key})
+}
+
+func (s datasetStore) DeleteMany(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.ds.Delete(key)
+	}
+	return nil
+}
+
+func datasetOrNotFound(d *Dataset, ok bool) (*Dataset, error) {
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return d, nil
+}
+
+// NewReputationStore adapts a Reputations implementation to the
+// ReputationStore interface
+func NewReputationStore(rs Reputations) ReputationStore {
+	return reputationStore{rs}
+}
+
+// reputationStore wraps a Reputations, satisfying ReputationStore
+type reputationStore struct {
+	rs Reputations
+}
+
+func (s reputationStore) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.rs.Len(), nil
+}
+
+func (s reputationStore) Get(ctx context.Context, profileID string) (*Reputation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r, ok := s.rs.Load(profileID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s reputationStore) List(ctx context.Context, limit, offset int) (res []*Reputation, err error) {
+	s.rs.SortedRange(func(key string, r *Reputation) bool {
+		if err = ctx.Err(); err != nil {
+			return true
+		}
+		if offset > 0 {
+			offset--
+			return false
+		}
+		if limit >= 0 && len(res) == limit {
+			return true
+		}
+		res = append(res, r)
+		return false
+	})
+	return res, err
+}
+
+func (s reputationStore) Put(ctx context.Context, r *Reputation) error {
+	return s.PutMany(ctx, []*Reputation{r})
+}
+
+func (s reputationStore) PutMany(ctx context.Context, rs []*Reputation) error {
+	for _, r := range rs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.rs.Add(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s reputationStore) Delete(ctx context.Context, profileID string) error {
+	return s.DeleteMany(ctx, []string{profileID})
+}
+
+func (s reputationStore) DeleteMany(ctx context.Context, profileIDs []string) error {
+	for _, id := range profileIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.rs.Delete(id)
+	}
+	return nil
+}
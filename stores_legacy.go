@@ -0,0 +1,353 @@
+package registry
+
+import (
+	"context"
+)
+
+// The legacy adapters in this file wrap context-aware stores in the original
+// Profiles, Datasets, Reputations, Organizations and Redirects interfaces so new store implementations
+// can be used by existing callers & http handlers during migration.
+// Methods use context.Background(), and errors that can't be returned
+// through the legacy interfaces are passed to an optional error handler.
+// Store methods report an error & skip storing when the provided key doesn't
+// match the record's own key. Ranging pages through List in chunks of
+// legacyPageSize
+
+// legacyPageSize is the number of records fetched per List call when ranging
+const legacyPageSize = 100
+
+// NewLegacyProfiles adapts a ProfileStore to the Profiles interface
+func NewLegacyProfiles(s ProfileStore, onErr func(error)) Profiles {
+	return legacyProfiles{s: s, onErr: onErr}
+}
+
+type legacyProfiles struct {
+	s     ProfileStore
+	onErr func(error)
+}
+
+func (l legacyProfiles) Len() int {
+	n, err := l.s.Len(context.Background())
+	handleLegacyErr(l.onErr, err)
+	return n
+}
+
+func (l legacyProfiles) Load(key string) (*Profile, bool) {
+	return l.load(l.s.Get(context.Background(), key))
+}
+
+func (l legacyProfiles) LoadByProfileID(profileID string) (*Profile, bool) {
+	return l.load(l.s.GetByProfileID(context.Background(), profileID))
+}
+
+func (l legacyProfiles) LoadByPublicKey(pubKey string) (*Profile, bool) {
+	return l.load(l.s.GetByPublicKey(context.Background(), pubKey))
+}
+
+func (l legacyProfiles) load(p *Profile, err error) (*Profile, bool) {
+	if err != nil {
+		if err != ErrNotFound {
+			handleLegacyErr(l.onErr, err)
+		}
+		return nil, false
+	}
+	return p, true
+}
+
+func (l legacyProfiles) Range(iter func(key string, p *Profile) bool) {
+	l.SortedRange(iter)
+}
+
+func (l legacyProfiles) SortedRange(iter func(key string, p *Profile) bool) {
+	rangePages(l.onErr, func(limit, offset int) (int, bool, error) {
+		ps, err := l.s.List(context.Background(), limit, offset)
+		for _, p := range ps {
+			if iter(p.Handle, p) {
+				return len(ps), true, err
+			}
+		}
+		return len(ps), false, err
+	})
+}
+
+func (l legacyProfiles) Store(key string, value *Profile) {
+	if err := checkLegacyKey(key, value.Handle); err != nil {
+		handleLegacyErr(l.onErr, err)
+		return
+	}
+	handleLegacyErr(l.onErr, l.s.Put(context.Background(), value))
+}
+
+func (l legacyProfiles) Delete(key string) {
+	handleLegacyErr(l.onErr, l.s.Delete(context.Background(), key))
+}
+
+// NewLegacyDatasets adapts a DatasetStore to the Datasets interface
+func NewLegacyDatasets(s DatasetStore, onErr func(error)) Datasets {
+	return legacyDatasets{s: s, onErr: onErr}
+}
+
+type legacyDatasets struct {
+	s     DatasetStore
+	onErr func(error)
+}
+
+func (l legacyDatasets) Len() int {
+	n, err := l.s.Len(context.Background())
+	handleLegacyErr(l.onErr, err)
+	return n
+}
+
+func (l legacyDatasets) Load(key string) (*Dataset, bool) {
+	return l.load(l.s.Get(context.Background(), key))
+}
+
+func (l legacyDatasets) LoadByPath(path string) (*Dataset, bool) {
+	return l.load(l.s.GetByPath(context.Background(), path))
+}
+
+func (l legacyDatasets) load(d *Dataset, err error) (*Dataset, bool) {
+	if err != nil {
+		if err != ErrNotFound {
+			handleLegacyErr(l.onErr, err)
+		}
+		return nil, false
+	}
+	return d, true
+}
+
+func (l legacyDatasets) ListByHandle(handle string) []*Dataset {
+	ds, err := l.s.ListByHandle(context.Background(), handle)
+	handleLegacyErr(l.onErr, err)
+	return ds
+}
+
+func (l legacyDatasets) ListByProfileID(profileID string) []*Dataset {
+	ds, err := l.s.ListByProfileID(context.Background(), profileID)
+	handleLegacyErr(l.onErr, err)
+	return ds
+}
+
+func (l legacyDatasets) Range(iter func(key string, d *Dataset) bool) {
+	l.SortedRange(iter)
+}
+
+func (l legacyDatasets) SortedRange(iter func(key string, d *Dataset) bool) {
+	rangePages(l.onErr, func(limit, offset int) (int, bool, error) {
+		ds, err := l.s.List(context.Background(), limit, offset)
+		for _, d := range ds {
+			if iter(d.Key(), d) {
+				return len(ds), true, err
+			}
+		}
+		return len(ds), false, err
+	})
+}
+
+func (l legacyDatasets) Store(key string, value *Dataset) {
+	if err := checkLegacyKey(key, value.Key()); err != nil {
+		handleLegacyErr(l.onErr, err)
+		return
+	}
+	handleLegacyErr(l.onErr, l.s.Put(context.Background(), value))
+}
+
+func (l legacyDatasets) Delete(key string) {
+	handleLegacyErr(l.onErr, l.s.Delete(context.Background(), key))
+}
+
+// NewLegacyReputations adapts a ReputationStore to the Reputations interface
+func NewLegacyReputations(s ReputationStore, onErr func(error)) Reputations {
+	return legacyReputations{s: s, onErr: onErr}
+}
+
+type legacyReputations struct {
+	s     ReputationStore
+	onErr func(error)
+}
+
+func (l legacyReputations) Len() int {
+	n, err := l.s.Len(context.Background())
+	handleLegacyErr(l.onErr, err)
+	return n
+}
+
+func (l legacyReputations) Load(key string) (*Reputation, bool) {
+	r, err := l.s.Get(context.Background(), key)
+	if err != nil {
+		if err != ErrNotFound {
+			handleLegacyErr(l.onErr, err)
+		}
+		return nil, false
+	}
+	return r, true
+}
+
+func (l legacyReputations) Range(iter func(key string, r *Reputation) bool) {
+	l.SortedRange(iter)
+}
+
+func (l legacyReputations) SortedRange(iter func(key string, r *Reputation) bool) {
+	rangePages(l.onErr, func(limit, offset int) (int, bool, error) {
+		rs, err := l.s.List(context.Background(), limit, offset)
+		for _, r := range rs {
+			if iter(r.ProfileID, r) {
+				return len(rs), true, err
+			}
+		}
+		return len(rs), false, err
+	})
+}
+
+func (l legacyReputations) Add(r *Reputation) error {
+	return l.s.Put(context.Background(), r)
+}
+
+func (l legacyReputations) Store(key string, value *Reputation) {
+	if err := checkLegacyKey(key, value.ProfileID); err != nil {
+		handleLegacyErr(l.onErr, err)
+		return
+	}
+	handleLegacyErr(l.onErr, l.s.Put(context.Background(), value))
+}
+
+func (l legacyReputations) Delete(key string) {
+	handleLegacyErr(l.onErr, l.s.Delete(context.Background(), key))
+}
+
+// NewLegacyOrganizations adapts an OrganizationStore to the Organizations
+// interface
+func NewLegacyOrganizations(s OrganizationStore, onErr func(error)) Organizations {
+	return legacyOrganizations{s: s, onErr: onErr}
+}
+
+type legacyOrganizations struct {
+	s     OrganizationStore
+	onErr func(error)
+}
+
+func (l legacyOrganizations) Len() int {
+	n, err := l.s.Len(context.Background())
+	handleLegacyErr(l.onErr, err)
+	return n
+}
+
+func (l legacyOrganizations) Load(handle string) (*Organization, bool) {
+	o, err := l.s.Get(context.Background(), handle)
+	if err != nil {
+		if err != ErrNotFound {
+			handleLegacyErr(l.onErr, err)
+		}
+		return nil, false
+	}
+	return o, true
+}
+
+func (l legacyOrganizations) Range(iter func(handle string, o *Organization) bool) {
+	l.SortedRange(iter)
+}
+
+func (l legacyOrganizations) SortedRange(iter func(handle string, o *Organization) bool) {
+	rangePages(l.onErr, func(limit, offset int) (int, bool, error) {
+		orgs, err := l.s.List(context.Background(), limit, offset)
+		for _, o := range orgs {
+			if iter(o.Handle, o) {
+				return len(orgs), true, err
+			}
+		}
+		return len(orgs), false, err
+	})
+}
+
+func (l legacyOrganizations) Store(handle string, value *Organization) {
+	if err := checkLegacyKey(handle, value.Handle); err != nil {
+		handleLegacyErr(l.onErr, err)
+		return
+	}
+	handleLegacyErr(l.onErr, l.s.Put(context.Background(), value))
+}
+
+func (l legacyOrganizations) Delete(handle string) {
+	handleLegacyErr(l.onErr, l.s.Delete(context.Background(), handle))
+}
+
+// NewLegacyRedirects adapts a RedirectStore to the Redirects interface
+func NewLegacyRedirects(s RedirectStore, onErr func(error)) Redirects {
+	return legacyRedirects{s: s, onErr: onErr}
+}
+
+type legacyRedirects struct {
+	s     RedirectStore
+	onErr func(error)
+}
+
+func (l legacyRedirects) Len() int {
+	n, err := l.s.Len(context.Background())
+	handleLegacyErr(l.onErr, err)
+	return n
+}
+
+func (l legacyRedirects) Load(from string) (*Redirect, bool) {
+	r, err := l.s.Get(context.Background(), from)
+	if err != nil {
+		if err != ErrNotFound {
+			handleLegacyErr(l.onErr, err)
+		}
+		return nil, false
+	}
+	return r, true
+}
+
+func (l legacyRedirects) SortedRange(iter func(from string, r *Redirect) bool) {
+	rangePages(l.onErr, func(limit, offset int) (int, bool, error) {
+		rs, err := l.s.List(context.Background(), limit, offset)
+		for _, r := range rs {
+			if iter(r.From, r) {
+				return len(rs), true, err
+			}
+		}
+		return len(rs), false, err
+	})
+}
+
+func (l legacyRedirects) Store(from string, value *Redirect) {
+	if err := checkLegacyKey(from, value.From); err != nil {
+		handleLegacyErr(l.onErr, err)
+		return
+	}
+	handleLegacyErr(l.onErr, l.s.Put(context.Background(), value))
+}
+
+func (l legacyRedirects) Delete(from string) {
+	handleLegacyErr(l.onErr, l.s.Delete(context.Background(), from))
+}
+
+// rangePages calls page with increasing offsets until it errors, asks to
+// stop, or returns fewer than legacyPageSize records
+func rangePages(onErr func(error), page func(limit, offset int) (n int, brk bool, err error)) {
+	for offset := 0; ; offset += legacyPageSize {
+		n, brk, err := page(legacyPageSize, offset)
+		if err != nil {
+			handleLegacyErr(onErr, err)
+			return
+		}
+		if brk || n < legacyPageSize {
+			return
+		}
+	}
+}
+
+// checkLegacyKey errors if the key a record is stored under isn't the
+// record's own key
+func checkLegacyKey(key, recordKey string) error {
+	if key != recordKey {
+		return NewError(ErrInvalid, "key '%s' doesn't match record key '%s'", key, recordKey)
+	}
+	return nil
+}
+
+func handleLegacyErr(onErr func(error), err error) {
+	if err != nil && onErr != nil {
+		onErr(err)
+	}
+}
@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestProfileStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewProfileStore(NewMemProfiles())
+
+	if _, err := s.Get(ctx, "a"); err != ErrNotFound {
+		t.Errorf("expected get of missing profile to return ErrNotFound, got: %v", err)
+	}
+	if err := s.Put(ctx, &Profile{}); err == nil {
+		t.Errorf("expected put without handle to error")
+	}
+
+	err := s.PutMany(ctx, []*Profile{
+		{Handle: "b", ProfileID: "QmB", PublicKey: "keyB"},
+		{Handle: "a", ProfileID: "QmA", PublicKey: "keyA"},
+		{Handle: "c", ProfileID: "QmC", PublicKey: "keyC"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if n, err := s.Len(ctx); err != nil || n != 3 {
+		t.Errorf("expected len 3, got: %d, err: %v", n, err)
+	}
+	if p, err := s.GetByProfileID(ctx, "QmB"); err != nil || p.Handle != "b" {
+		t.Errorf("expected profileID QmB to return handle 'b', err: %v", err)
+	}
+	if p, err := s.GetByPublicKey(ctx, "keyC"); err != nil || p.Handle != "c" {
+		t.Errorf("expected public key keyC to return handle 'c', err: %v", err)
+	}
+
+	ps, err := s.List(ctx, 2, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ps) != 2 || ps[0].Handle != "b" || ps[1].Handle != "c" {
+		t.Errorf("list range mismatch, got %d results", len(ps))
+	}
+	if ps, _ = s.List(ctx, -1, 0); len(ps) != 3 {
+		t.Errorf("expected negative limit to list all profiles, got: %d", len(ps))
+	}
+
+	if err := s.DeleteMany(ctx, []string{"a", "b"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n, _ := s.Len(ctx); n != 1 {
+		t.Errorf("expected len 1 after delete, got: %d", n)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.Get(cancelled, "c"); err != context.Canceled {
+		t.Errorf("expected cancelled context to error, got: %v", err)
+	}
+	if err := s.Put(cancelled, &Profile{Handle: "d"}); err != context.Canceled {
+		t.Errorf("expected cancelled context to error, got: %v", err)
+	}
+}
+
+func TestDatasetStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewDatasetStore(NewMemDatasets())
+
+	err := s.PutMany(ctx, []*Dataset{
+		{Handle: "a", Name: "foo", Path: "/ipfs/QmFoo", ProfileID: "QmA"},
+		{Handle: "a", Name: "bar", Path: "/ipfs/QmBar", ProfileID: "QmA"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if d, err := s.Get(ctx, "a/foo"); err != nil || d.Path != "/ipfs/QmFoo" {
+		t.Errorf("expected a/foo to load, err: %v", err)
+	}
+	if d, err := s.GetByPath(ctx, "/ipfs/QmBar"); err != nil || d.Name != "bar" {
+		t.Errorf("expected /ipfs/QmBar to load, err: %v", err)
+	}
+	if ds, err := s.ListByHandle(ctx, "a"); err != nil || len(ds) != 2 {
+		t.Errorf("expected 2 datasets for handle 'a', got: %d, err: %v", len(ds), err)
+	}
+	if ds, err := s.ListByProfileID(ctx, "QmA"); err != nil || len(ds) != 2 {
+		t.Errorf("expected 2 datasets for profileID QmA, got: %d, err: %v", len(ds), err)
+	}
+	if err := s.Delete(ctx, "a/foo"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := s.GetByPath(ctx, "/ipfs/QmFoo"); err != ErrNotFound {
+		t.Errorf("expected deleted dataset to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestReputationStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewReputationStore(NewMemReputations())
+
+	if err := s.Put(ctx, &Reputation{}); err == nil {
+		t.Errorf("expected invalid reputation to error")
+	}
+	if err := s.PutMany(ctx, []*Reputation{NewReputation("QmA"), NewReputation("QmB")}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if r, err := s.Get(ctx, "QmA"); err != nil || r.Rep != 1 {
+		t.Errorf("expected QmA to load, err: %v", err)
+	}
+	if rs, err := s.List(ctx, 1, 0); err != nil || len(rs) != 1 || rs[0].ProfileID != "QmA" {
+		t.Errorf("list mismatch, err: %v", err)
+	}
+	if err := s.Delete(ctx, "QmA"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := s.Get(ctx, "QmA"); err != ErrNotFound {
+		t.Errorf("expected deleted reputation to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestLegacyAdapters(t *testing.T) {
+	var errs []error
+	onErr := func(err error) { errs = append(errs, err) }
+
+	ps := NewLegacyProfiles(NewProfileStore(NewMemProfiles()), onErr)
+	ps.Store("a", &Profile{Handle: "a", ProfileID: "QmA", PublicKey: "keyA"})
+	if p, ok := ps.LoadByProfileID("QmA"); !ok || p.Handle != "a" {
+		t.Errorf("expected legacy profiles to load by profileID")
+	}
+	if _, ok := ps.Load("missing"); ok {
+		t.Errorf("expected missing profile to not load")
+	}
+	keys := []string{}
+	ps.Range(func(key string, p *Profile) bool {
+		keys = append(keys, key)
+		return false
+	})
+	if len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("legacy profiles range mismatch: %v", keys)
+	}
+	ps.Store("", &Profile{})
+	ps.Store("b", &Profile{Handle: "c", ProfileID: "QmC", PublicKey: "keyC"})
+	if _, ok := ps.Load("c"); ok {
+		t.Errorf("expected storing under a mismatched key to be rejected")
+	}
+	for i := 0; i < legacyPageSize+1; i++ {
+		h := fmt.Sprintf("p%03d", i)
+		ps.Store(h, &Profile{Handle: h, ProfileID: "Qm" + h, PublicKey: "key" + h})
+	}
+	n := 0
+	ps.SortedRange(func(key string, p *Profile) bool {
+		n++
+		return false
+	})
+	if n != legacyPageSize+2 {
+		t.Errorf("expected range to page through all %d profiles, got: %d", legacyPageSize+2, n)
+	}
+
+	ds := NewLegacyDatasets(NewDatasetStore(NewMemDatasets()), onErr)
+	ds.Store("a/foo", &Dataset{Handle: "a", Name: "foo", Path: "/ipfs/QmFoo"})
+	if d, ok := ds.LoadByPath("/ipfs/QmFoo"); !ok || d.Name != "foo" {
+		t.Errorf("expected legacy datasets to load by path")
+	}
+	ds.Delete("a/foo")
+	if ds.Len() != 0 {
+		t.Errorf("expected legacy datasets to be empty after delete, got: %d", ds.Len())
+	}
+
+	rs := NewLegacyReputations(NewReputationStore(NewMemReputations()), onErr)
+	if err := rs.Add(&Reputation{}); err == nil {
+		t.Errorf("expected invalid reputation to error")
+	}
+	if err := rs.Add(NewReputation("QmA")); err != nil {
+		t.Error(err.Error())
+	}
+	if _, ok := rs.Load("QmA"); !ok {
+		t.Errorf("expected legacy reputations to load")
+	}
+
+	if len(errs) != 2 || errs[0].Error() != "handle is required" || ErrorKind(errs[1]) != ErrInvalid {
+		t.Errorf("expected handled errors for storing an invalid profile & a mismatched key, got: %v", errs)
+	}
+}
@@ -0,0 +1,237 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CommitError is returned by UnitOfWork.Apply when a change was valid but
+// couldn't be committed to the search index or event log. Changes are rolled
+// back before a CommitError is returned
+type CommitError struct {
+	// Step is the part of the commit that failed, one of "index" or "events"
+	Step string
+	Err  error
+	// RollbackErr is set if compensating for the failure also failed,
+	// leaving the store and index out of sync
+	RollbackErr error
+}
+
+// Error implements the error interface
+func (e *CommitError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("%s: %s (rollback failed: %s)", e.Step, e.Err.Error(), e.RollbackErr.Error())
+	}
+	return fmt.Sprintf("%s: %s", e.Step, e.Err.Error())
+}
+
+// UnitOfWork applies dataset changes to a Datasets store, an optional search
+// index and an optional event log as a single operation. Changes made to the
+// store are journaled so they can be undone if the index update or event
+// log append fails. Changes are serialized, so concurrent changes can't
+// interleave with a rollback
+type UnitOfWork struct {
+	lock      sync.Locker
+	datasets  Datasets
+	indexer   Indexer
+	events    EventLog
+	redirects Redirects
+	opts      []func(o *RegisterOptions)
+}
+
+// NewUnitOfWork creates a UnitOfWork. idxr may be nil. opts are passed along
+// to each mutation, and supply the event log, redirects store & lock. without
+// a lock option each UnitOfWork has it's own
+func NewUnitOfWork(datasets Datasets, idxr Indexer, opts ...func(o *RegisterOptions)) *UnitOfWork {
+	o := &RegisterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	lock := o.Lock
+	if lock == nil {
+		lock = &sync.Mutex{}
+	}
+	return &UnitOfWork{
+		lock:      lock,
+		datasets:  datasets,
+		indexer:   idxr,
+		events:    o.Events,
+		redirects: o.Redirects,
+		opts:      opts,
+	}
+}
+
+// Apply calls mutate with a journaling view of the datasets store & options
+// that must be used for all writes. Once mutate succeeds, changed datasets are
+// indexed and an event of type t is appended for each changed key. If mutate
+// fails its error is returned as-is. If indexing or appending fails all
+// changes are undone and a *CommitError is returned. The unit of work's lock
+// is held until Apply returns
+func (u *UnitOfWork) Apply(t EventType, mutate func(datasets Datasets, opts ...func(o *RegisterOptions)) error) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	tx := &txDatasets{Datasets: u.datasets, prev: map[string]*Dataset{}}
+	opts := u.opts
+	var txr *txRedirects
+	if u.redirects != nil {
+		txr = &txRedirects{Redirects: u.redirects, prev: map[string]*Redirect{}}
+		opts = append(opts[:len(opts):len(opts)], WithRedirects(txr))
+	}
+	rollback := func() {
+		tx.rollback()
+		if txr != nil {
+			txr.rollback()
+		}
+	}
+
+	if err := mutate(tx, opts...); err != nil {
+		rollback()
+		return err
+	}
+
+	var stored, removed []*Dataset
+	for _, key := range tx.keys {
+		if d, ok := u.datasets.Load(key); ok {
+			stored = append(stored, d)
+		} else if prev := tx.prev[key]; prev != nil {
+			removed = append(removed, prev)
+		}
+	}
+
+	if u.indexer != nil {
+		if err := u.index(stored, removed); err != nil {
+			rollback()
+			return &CommitError{Step: "index", Err: err, RollbackErr: u.restoreIndex(tx)}
+		}
+	}
+
+	if u.events != nil {
+		now := nowFunc()
+		events := make([]*Event, len(tx.keys))
+		for i, key := range tx.keys {
+			e := &Event{Type: t, Key: key, Timestamp: now}
+			e.Dataset, _ = u.datasets.Load(key)
+			events[i] = e
+		}
+		if err := u.events.Append(events...); err != nil {
+			rollback()
+			var rerr error
+			if u.indexer != nil {
+				rerr = u.restoreIndex(tx)
+			}
+			return &CommitError{Step: "events", Err: err, RollbackErr: rerr}
+		}
+	}
+	return nil
+}
+
+// index removes & adds datasets to the search index
+func (u *UnitOfWork) index(stored, removed []*Dataset) error {
+	if len(removed) > 0 {
+		if err := u.indexer.UnindexDatasets(removed); err != nil {
+			return err
+		}
+	}
+	if len(stored) > 0 {
+		return u.indexer.IndexDatasets(stored)
+	}
+	return nil
+}
+
+// restoreIndex returns the index entries of journaled keys to the values
+// they had before the unit of work began. It must be called after the store
+// has been rolled back
+func (u *UnitOfWork) restoreIndex(tx *txDatasets) error {
+	var restore, drop []*Dataset
+	for _, key := range tx.keys {
+		if prev := tx.prev[key]; prev != nil {
+			restore = append(restore, prev)
+		} else if handle, name, err := SplitDatasetKey(key); err == nil {
+			drop = append(drop, &Dataset{Handle: handle, Name: name})
+		}
+	}
+	return u.index(restore, drop)
+}
+
+// txDatasets wraps a Datasets store, journaling the value of each key before
+// it's first written so writes can be undone
+type txDatasets struct {
+	Datasets
+	// keys lists written keys in the order they were first written
+	keys []string
+	// prev holds the value of each written key before the first write, nil
+	// if the key didn't exist
+	prev map[string]*Dataset
+}
+
+// Store journals key & adds an entry to the underlying store
+func (tx *txDatasets) Store(key string, value *Dataset) {
+	tx.journal(key)
+	tx.Datasets.Store(key, value)
+}
+
+// Delete journals key & removes it from the underlying store
+func (tx *txDatasets) Delete(key string) {
+	tx.journal(key)
+	tx.Datasets.Delete(key)
+}
+
+func (tx *txDatasets) journal(key string) {
+	if _, ok := tx.prev[key]; ok {
+		return
+	}
+	tx.prev[key], _ = tx.Datasets.Load(key)
+	tx.keys = append(tx.keys, key)
+}
+
+// rollback restores journaled keys in reverse order
+func (tx *txDatasets) rollback() {
+	for i := len(tx.keys) - 1; i >= 0; i-- {
+		key := tx.keys[i]
+		if prev := tx.prev[key]; prev != nil {
+			tx.Datasets.Store(key, prev)
+		} else {
+			tx.Datasets.Delete(key)
+		}
+	}
+}
+
+// txRedirects wraps a Redirects store, journaling writes like txDatasets
+type txRedirects struct {
+	Redirects
+	keys []string
+	prev map[string]*Redirect
+}
+
+// Store journals from & adds an entry to the underlying store
+func (tx *txRedirects) Store(from string, value *Redirect) {
+	tx.journal(from)
+	tx.Redirects.Store(from, value)
+}
+
+// Delete journals from & removes it from the underlying store
+func (tx *txRedirects) Delete(from string) {
+	tx.journal(from)
+	tx.Redirects.Delete(from)
+}
+
+func (tx *txRedirects) journal(from string) {
+	if _, ok := tx.prev[from]; ok {
+		return
+	}
+	tx.prev[from], _ = tx.Redirects.Load(from)
+	tx.keys = append(tx.keys, from)
+}
+
+// rollback restores journaled keys in reverse order
+func (tx *txRedirects) rollback() {
+	for i := len(tx.keys) - 1; i >= 0; i-- {
+		from := tx.keys[i]
+		if prev := tx.prev[from]; prev != nil {
+			tx.Redirects.Store(from, prev)
+		} else {
+			tx.Redirects.Delete(from)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failIndex is an Indexer that fails the first time it indexes datasets
+type failIndex struct {
+	memIndex
+	failed bool
+}
+
+func (idx *failIndex) IndexDatasets(dss []*Dataset) error {
+	if !idx.failed {
+		idx.failed = true
+		return fmt.Errorf("index unavailable")
+	}
+	return idx.memIndex.IndexDatasets(dss)
+}
+
+// failLog is an EventLog that fails to append
+type failLog bool
+
+func (failLog) Append(events ...*Event) error {
+	return fmt.Errorf("log unavailable")
+}
+
+func TestUnitOfWorkApply(t *testing.T) {
+	cities := &Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities"}
+	updated := &Dataset{Handle: "b5", Name: "cities", Path: "/ipfs/QmCities2"}
+	movies := &Dataset{Handle: "b5", Name: "movies", Path: "/ipfs/QmMovies"}
+	mutate := func(datasets Datasets, opts ...func(o *RegisterOptions)) error {
+		datasets.Store(updated.Key(), updated)
+		datasets.Store(movies.Key(), movies)
+		o := &RegisterOptions{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		o.Redirects.Store("b5/films", &Redirect{From: "b5/films", To: "b5/movies"})
+		return nil
+	}
+
+	setup := func() (*MemDatasets, memIndex, *MemRedirects) {
+		datasets := NewMemDatasets()
+		datasets.Store(cities.Key(), cities)
+		return datasets, memIndex{cities.Key(): cities}, NewMemRedirects()
+	}
+
+	datasets, idx, rs := setup()
+	log := NewMemEventLog()
+	uow := NewUnitOfWork(datasets, idx, WithRedirects(rs), WithEvents(log))
+	if err := uow.Apply(EventDatasetRegistered, mutate); err != nil {
+		t.Fatal(err.Error())
+	}
+	if idx["b5/cities"] != updated || idx["b5/movies"] != movies {
+		t.Errorf("expected index to be updated, got: %v", idx)
+	}
+	if log.Len() != 2 {
+		t.Errorf("expected 2 events, got: %d", log.Len())
+	}
+
+	cases := []struct {
+		name      string
+		failIndex bool
+		log       EventLog
+		step      string
+	}{
+		{"index failure", true, nil, "index"},
+		{"event log failure", false, failLog(true), "events"},
+	}
+	for _, c := range cases {
+		datasets, idx, rs := setup()
+		var idxr Indexer = idx
+		if c.failIndex {
+			idxr = &failIndex{memIndex: idx}
+		}
+		uow := NewUnitOfWork(datasets, idxr, WithRedirects(rs), WithEvents(c.log))
+		err := uow.Apply(EventDatasetRegistered, mutate)
+		cerr, ok := err.(*CommitError)
+		if !ok {
+			t.Errorf("%s: expected *CommitError, got: %v", c.name, err)
+			continue
+		}
+		if cerr.Step != c.step || cerr.RollbackErr != nil {
+			t.Errorf("%s: unexpected commit error: %s", c.name, cerr.Error())
+		}
+		if d, _ := datasets.Load("b5/cities"); d != cities {
+			t.Errorf("%s: expected b5/cities to be restored", c.name)
+		}
+		if _, ok := datasets.Load("b5/movies"); ok {
+			t.Errorf("%s: expected b5/movies to be removed", c.name)
+		}
+		if rs.Len() != 0 {
+			t.Errorf("%s: expected redirects to be rolled back", c.name)
+		}
+		if len(idx) != 1 || idx["b5/cities"] != cities {
+			t.Errorf("%s: expected index to be restored, got: %v", c.name, idx)
+		}
+	}
+
+	datasets, idx, rs = setup()
+	uow = NewUnitOfWork(datasets, idx, WithRedirects(rs))
+	err := uow.Apply(EventDatasetRegistered, func(datasets Datasets, opts ...func(o *RegisterOptions)) error {
+		datasets.Delete(cities.Key())
+		return fmt.Errorf("invalid dataset")
+	})
+	if err == nil || err.Error() != "invalid dataset" {
+		t.Errorf("expected mutate error to be returned as-is, got: %v", err)
+	}
+	if d, _ := datasets.Load("b5/cities"); d != cities {
+		t.Error("expected rejected change to be rolled back")
+	}
+}
+
+func TestUnitOfWorkLock(t *testing.T) {
+	lock := &sync.Mutex{}
+	datasets := NewMemDatasets()
+	a := NewUnitOfWork(datasets, nil, WithLock(lock))
+	b := NewUnitOfWork(datasets, nil, WithLock(lock))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- a.Apply(EventDatasetRegistered, func(datasets Datasets, opts ...func(o *RegisterOptions)) error {
+			close(entered)
+			<-release
+			return fmt.Errorf("invalid dataset")
+		})
+	}()
+	<-entered
+
+	ran := make(chan struct{})
+	go b.Apply(EventDatasetRegistered, func(datasets Datasets, opts ...func(o *RegisterOptions)) error {
+		close(ran)
+		return nil
+	})
+	select {
+	case <-ran:
+		t.Errorf("expected units of work sharing a lock to wait for each other")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	close(release)
+	<-done
+	<-ran
+}
@@ -3,10 +3,36 @@ package registry
 import (
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p-crypto"
 )
 
+const (
+	// OpRegister is the signed operation for a registration request
+	OpRegister = "register"
+	// OpDeregister is the signed operation for a deregistration request
+	OpDeregister = "deregister"
+	// OpDeprecate is the signed operation for deprecating a dataset
+	OpDeprecate = "deprecate"
+	// OpUndeprecate is the signed operation for removing a deprecation
+	OpUndeprecate = "undeprecate"
+)
+
+// MaxRequestAge is how far a signed request's timestamp may drift from the
+// registry's clock before the request is rejected as stale
+var MaxRequestAge = time.Minute * 10
+
+// checkFresh errors if a signed request timestamp is further than
+// MaxRequestAge from now
+func checkFresh(ts time.Time) error {
+	age := nowFunc().Sub(ts)
+	if age > MaxRequestAge || age < -MaxRequestAge {
+		return NewError(ErrUnauthorized, "request timestamp is stale")
+	}
+	return nil
+}
+
 // verify accepts base64 encoded keys & signatures to validate data
 func verify(b64PubKey, b64Signature string, data []byte) error {
 	pkbytes, err := base64.StdEncoding.DecodeString(b64PubKey)
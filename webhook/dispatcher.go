@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// logger
+	log = logrus.New()
+
+	// nowFunc is an internal function for getting timestamps
+	nowFunc = func() time.Time { return time.Now() }
+)
+
+// SetLogLevel controls how detailed webhook logging is
+func SetLogLevel(level string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(lvl)
+	return nil
+}
+
+const (
+	// DefaultMaxAttempts is the number of times a delivery is tried before
+	// it's written to the dead letter list
+	DefaultMaxAttempts = 5
+	// DefaultTimeout is how long a single delivery attempt may take
+	DefaultTimeout = time.Second * 10
+	// DefaultMaxDeadLetters is the number of dead letters kept before the
+	// oldest are dropped
+	DefaultMaxDeadLetters = 1000
+)
+
+// ExponentialBackoff waits one second before the first retry, doubling
+// each subsequent attempt
+func ExponentialBackoff(attempt int) time.Duration {
+	return time.Second * time.Duration(1<<uint(attempt-1))
+}
+
+// DeadLetter records a delivery that failed after exhausting all attempts
+type DeadLetter struct {
+	SubscriptionID string    `json:"subscriptionID"`
+	URL            string    `json:"url"`
+	Event          *Event    `json:"event"`
+	Attempts       int       `json:"attempts"`
+	Error          string    `json:"error"`
+	Failed         time.Time `json:"failed"`
+}
+
+// DeadLetters is a list of failed deliveries safe for concurrent use
+type DeadLetters struct {
+	sync.Mutex
+	// Max caps the length of the list, dropping the oldest dead letters once
+	// reached. defaults to DefaultMaxDeadLetters
+	Max  int
+	list []*DeadLetter
+}
+
+// Add appends a dead letter to the list
+func (dl *DeadLetters) Add(d *DeadLetter) {
+	dl.Lock()
+	max := dl.Max
+	if max <= 0 {
+		max = DefaultMaxDeadLetters
+	}
+	dl.list = append(dl.list, d)
+	if len(dl.list) > max {
+		dl.list = append(dl.list[:0], dl.list[len(dl.list)-max:]...)
+	}
+	dl.Unlock()
+}
+
+// List returns dead letters within the range defined by limit & offset,
+// oldest first
+func (dl *DeadLetters) List(limit, offset int) []*DeadLetter {
+	dl.Lock()
+	defer dl.Unlock()
+	res := make([]*DeadLetter, 0, limit)
+	for i, d := range dl.list {
+		if i < offset {
+			continue
+		}
+		if len(res) == limit {
+			break
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// Len returns the number of dead letters in the list
+func (dl *DeadLetters) Len() int {
+	dl.Lock()
+	defer dl.Unlock()
+	return len(dl.list)
+}
+
+// Dispatcher publishes events to all interested subscriptions. Deliveries
+// happen in the background & are retried with backoff, failed deliveries are
+// recorded to DeadLetters
+type Dispatcher struct {
+	Subscriptions Subscriptions
+	DeadLetters   *DeadLetters
+	// Client performs deliveries, defaults to a client with DefaultTimeout
+	Client *http.Client
+	// MaxAttempts caps the number of times a delivery is tried
+	MaxAttempts int
+	// Backoff returns the duration to wait before retry attempt n
+	Backoff func(attempt int) time.Duration
+
+	wg sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher for a set of subscriptions with
+// default retry settings
+func NewDispatcher(subs Subscriptions) *Dispatcher {
+	return &Dispatcher{
+		Subscriptions: subs,
+		DeadLetters:   &DeadLetters{},
+		Client:        &http.Client{Timeout: DefaultTimeout},
+		MaxAttempts:   DefaultMaxAttempts,
+		Backoff:       ExponentialBackoff,
+	}
+}
+
+// Publish sends an event to all subscriptions listening for event type t.
+// Publish doesn't block, deliveries happen in the background
+func (d *Dispatcher) Publish(t EventType, data interface{}) {
+	if d == nil || d.Subscriptions == nil {
+		return
+	}
+
+	e := &Event{
+		ID:      NewID(),
+		Type:    t,
+		Created: nowFunc(),
+		Data:    data,
+	}
+
+	d.Subscriptions.Range(func(id string, s *Subscription) bool {
+		if s.Wants(t) {
+			d.wg.Add(1)
+			go func(s *Subscription) {
+				defer d.wg.Done()
+				d.deliver(s, e)
+			}(s)
+		}
+		return false
+	})
+}
+
+// Wait blocks until all in-flight deliveries have completed or been
+// dead-lettered
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver sends an event to a subscription, retrying until MaxAttempts is
+// reached
+func (d *Dispatcher) deliver(s *Subscription, e *Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("encoding event %s: %s", e.ID, err.Error())
+		return
+	}
+
+	max := d.MaxAttempts
+	if max < 1 {
+		max = 1
+	}
+
+	attempt := 0
+	for {
+		attempt++
+		if err = d.Deliver(s, e.Type, body); err == nil {
+			return
+		}
+		log.Infof("delivering event %s to %s, attempt %d: %s", e.ID, s.URL, attempt, err.Error())
+		if attempt >= max {
+			break
+		}
+		if d.Backoff != nil {
+			time.Sleep(d.Backoff(attempt))
+		}
+	}
+
+	if d.DeadLetters != nil {
+		d.DeadLetters.Add(&DeadLetter{
+			SubscriptionID: s.ID,
+			URL:            s.URL,
+			Event:          e,
+			Attempts:       attempt,
+			Error:          err.Error(),
+			Failed:         nowFunc(),
+		})
+	}
+}
+
+// Deliver makes a single signed delivery attempt of an encoded event body to
+// a subscription. Any non-2xx response is considered a failure
+func (d *Dispatcher) Deliver(s *Subscription, t EventType, body []byte) error {
+	req, err := http.NewRequest("POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(t))
+	req.Header.Set(SignatureHeader, Sign(s.Secret, body))
+
+	cli := d.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	res, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+func init() {
+	SetLogLevel("error")
+}
+
+// receiver is a local webhook endpoint that records deliveries, failing the
+// first n requests it receives
+type receiver struct {
+	sync.Mutex
+	secret   string
+	failures int
+	requests int
+	events   []*Event
+	badSigs  int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.Lock()
+	defer rc.Unlock()
+	rc.requests++
+	if rc.requests <= rc.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if !VerifySignature(rc.secret, r.Header.Get(SignatureHeader), body) {
+		rc.badSigs++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	e := &Event{}
+	if err := json.Unmarshal(body, e); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.events = append(rc.events, e)
+}
+
+func newTestDispatcher(rc *receiver, url string, events ...EventType) *Dispatcher {
+	subs := NewMemSubscriptions()
+	subs.Store("sub", &Subscription{ID: "sub", URL: url, Events: events, Secret: rc.secret})
+	d := NewDispatcher(subs)
+	d.Backoff = func(int) time.Duration { return 0 }
+	d.MaxAttempts = 3
+	return d
+}
+
+func TestDispatcherPublish(t *testing.T) {
+	rc := &receiver{secret: "secret"}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	d := newTestDispatcher(rc, s.URL, EventDatasetPublished)
+	d.Publish(EventDatasetPublished, &registry.Dataset{Handle: "b5", Name: "ds"})
+	d.Publish(EventPinComplete, pinset.PinStatus{Path: "foo", Pinned: true})
+	d.Wait()
+
+	if rc.badSigs != 0 {
+		t.Errorf("expected all signatures to verify, got %d failures", rc.badSigs)
+	}
+	if len(rc.events) != 1 {
+		t.Fatalf("expected 1 delivered event, got: %d", len(rc.events))
+	}
+	if rc.events[0].Type != EventDatasetPublished {
+		t.Errorf("event type mismatch. expected: %s, got: %s", EventDatasetPublished, rc.events[0].Type)
+	}
+	if d.DeadLetters.Len() != 0 {
+		t.Errorf("expected no dead letters, got: %d", d.DeadLetters.Len())
+	}
+}
+
+func TestDispatcherRetry(t *testing.T) {
+	rc := &receiver{secret: "secret", failures: 2}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	d := newTestDispatcher(rc, s.URL, EventPinComplete)
+	d.Publish(EventPinComplete, pinset.PinStatus{Path: "foo", Pinned: true})
+	d.Wait()
+
+	if rc.requests != 3 {
+		t.Errorf("expected 3 delivery attempts, got: %d", rc.requests)
+	}
+	if len(rc.events) != 1 {
+		t.Errorf("expected event to be delivered after retrying")
+	}
+	if d.DeadLetters.Len() != 0 {
+		t.Errorf("expected no dead letters, got: %d", d.DeadLetters.Len())
+	}
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	rc := &receiver{secret: "secret", failures: 10}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	d := newTestDispatcher(rc, s.URL, EventProfileChanged)
+	d.Publish(EventProfileChanged, &registry.Profile{Handle: "b5"})
+	d.Wait()
+
+	if rc.requests != d.MaxAttempts {
+		t.Errorf("expected %d delivery attempts, got: %d", d.MaxAttempts, rc.requests)
+	}
+	dls := d.DeadLetters.List(10, 0)
+	if len(dls) != 1 {
+		t.Fatalf("expected 1 dead letter, got: %d", len(dls))
+	}
+	if dls[0].SubscriptionID != "sub" {
+		t.Errorf("dead letter subscription mismatch. expected: %s, got: %s", "sub", dls[0].SubscriptionID)
+	}
+	if dls[0].Attempts != d.MaxAttempts {
+		t.Errorf("dead letter attempts mismatch. expected: %d, got: %d", d.MaxAttempts, dls[0].Attempts)
+	}
+}
+
+func TestDeadLettersMax(t *testing.T) {
+	dl := &DeadLetters{Max: 2}
+	for i := 0; i < 3; i++ {
+		dl.Add(&DeadLetter{Attempts: i})
+	}
+	got := dl.List(10, 0)
+	if len(got) != 2 {
+		t.Fatalf("expected dead letters to be capped at 2, got: %d", len(got))
+	}
+	if got[0].Attempts != 1 || got[1].Attempts != 2 {
+		t.Errorf("expected the oldest dead letter to be dropped")
+	}
+}
+
+func TestStoreWrappers(t *testing.T) {
+	rc := &receiver{secret: "secret"}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	d := newTestDispatcher(rc, s.URL, EventTypes...)
+	mps := registry.NewMemProfiles()
+	ps := Profiles{Profiles: mps, Dispatcher: d}
+	es := Events{Dispatcher: d}
+	pins := Pinset{Pinset: &pinset.MemPinset{Profiles: mps}, Dispatcher: d}
+
+	ps.Store("b5", &registry.Profile{Handle: "b5"})
+	ps.Delete("b5")
+	ps.Delete("not_found")
+	ds := &registry.Dataset{Handle: "b5", Name: "ds"}
+	err := es.Append(
+		&registry.Event{Type: registry.EventDatasetRegistered, Key: "b5/ds", Dataset: ds},
+		&registry.Event{Type: registry.EventDatasetMoved, Key: "b5/old"},
+		&registry.Event{Type: registry.EventDatasetMoved, Key: "b5/ds", Dataset: ds},
+		&registry.Event{Type: registry.EventDatasetDeprecated, Key: "b5/ds", Dataset: ds},
+		&registry.Event{Type: registry.EventDatasetUndeprecated, Key: "b5/ds", Dataset: ds},
+		&registry.Event{Type: registry.EventDatasetDeregistered, Key: "b5/ds"},
+		&registry.Event{Type: registry.EventDatasetsStored, Key: "b5/ds", Dataset: ds},
+	)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	statuses, err := pins.Pin(&pinset.PinRequest{Path: "foo"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for range statuses {
+	}
+	d.Wait()
+
+	got := map[EventType]int{}
+	for _, e := range rc.events {
+		got[e.Type]++
+	}
+	for _, et := range EventTypes {
+		if got[et] != 1 {
+			t.Errorf("expected 1 %s event, got: %d", et, got[et])
+		}
+	}
+}
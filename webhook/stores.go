@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"github.com/qri-io/registry"
+	"github.com/qri-io/registry/pinset"
+)
+
+// datasetEvents maps registry event types to the webhook event published
+// for them. registry events without a webhook event aren't published
+var datasetEvents = map[registry.EventType]EventType{
+	registry.EventDatasetRegistered:   EventDatasetPublished,
+	registry.EventDatasetMoved:        EventDatasetMoved,
+	registry.EventDatasetDeprecated:   EventDatasetDeprecated,
+	registry.EventDatasetUndeprecated: EventDatasetUndeprecated,
+}
+
+// Events wraps a registry.EventLog, publishing a webhook event for each
+// appended event that stores a dataset. EventLog may be nil, in which case
+// events are only published
+type Events struct {
+	registry.EventLog
+	Dispatcher *Dispatcher
+}
+
+// Append adds events to the underlying log & publishes them
+func (es Events) Append(events ...*registry.Event) error {
+	if es.EventLog != nil {
+		if err := es.EventLog.Append(events...); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if t, ok := datasetEvents[e.Type]; ok && e.Dataset != nil {
+			es.Dispatcher.Publish(t, e.Dataset)
+		}
+	}
+	return nil
+}
+
+// Profiles wraps a registry.Profiles, publishing events when profiles
+// are stored or deleted
+type Profiles struct {
+	registry.Profiles
+	Dispatcher *Dispatcher
+}
+
+// Store adds an entry to the underlying store & publishes an
+// EventProfileChanged event
+func (ps Profiles) Store(key string, value *registry.Profile) {
+	ps.Profiles.Store(key, value)
+	ps.Dispatcher.Publish(EventProfileChanged, value)
+}
+
+// Delete removes an entry from the underlying store, publishing an
+// EventProfileRemoved event if the entry existed
+func (ps Profiles) Delete(key string) {
+	pro, ok := ps.Profiles.Load(key)
+	ps.Profiles.Delete(key)
+	if ok {
+		ps.Dispatcher.Publish(EventProfileRemoved, pro)
+	}
+}
+
+// Pinset wraps a pinset.Pinset, publishing an EventPinComplete event when
+// a pin request reports it's pinned
+type Pinset struct {
+	pinset.Pinset
+	Dispatcher *Dispatcher
+}
+
+// Pin forwards status updates from the underlying pinset, publishing an
+// event when pinning completes. Forwarding never blocks on the caller: if
+// the returned channel isn't read, older statuses are replaced by the latest
+func (ps Pinset) Pin(req *pinset.PinRequest) (chan pinset.PinStatus, error) {
+	statuses, err := ps.Pinset.Pin(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fwd := make(chan pinset.PinStatus, 1)
+	go func() {
+		defer close(fwd)
+		for status := range statuses {
+			if status.Pinned {
+				ps.Dispatcher.Publish(EventPinComplete, status)
+			}
+			select {
+			case fwd <- status:
+			default:
+				// this goroutine is the only sender, so once the stale status is
+				// drained (or read by the caller) the send can't block
+				select {
+				case <-fwd:
+				default:
+				}
+				fwd <- status
+			}
+		}
+	}()
+	return fwd, nil
+}
@@ -0,0 +1,236 @@
+// Package webhook delivers registry events to subscribed HTTP endpoints.
+// Subscribers register a URL, a list of event types and a shared secret.
+// Each delivery is signed with an HMAC of the request body so receivers can
+// confirm the payload came from the registry
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventType names a kind of registry event subscribers can listen for
+type EventType string
+
+const (
+	// EventDatasetPublished fires when a dataset is registered or updated
+	EventDatasetPublished EventType = "dataset:published"
+	// EventDatasetMoved fires when a dataset changes handle or name
+	EventDatasetMoved EventType = "dataset:moved"
+	// EventDatasetDeprecated fires when a dataset is marked deprecated
+	EventDatasetDeprecated EventType = "dataset:deprecated"
+	// EventDatasetUndeprecated fires when a dataset deprecation is lifted
+	EventDatasetUndeprecated EventType = "dataset:undeprecated"
+	// EventPinComplete fires when a pin request finishes pinning
+	EventPinComplete EventType = "pin:complete"
+	// EventProfileChanged fires when a profile is registered or updated
+	EventProfileChanged EventType = "profile:changed"
+	// EventProfileRemoved fires when a profile is removed from the registry
+	EventProfileRemoved EventType = "profile:removed"
+)
+
+// EventTypes lists all event types the registry will publish
+var EventTypes = []EventType{
+	EventDatasetPublished,
+	EventDatasetMoved,
+	EventDatasetDeprecated,
+	EventDatasetUndeprecated,
+	EventPinComplete,
+	EventProfileChanged,
+	EventProfileRemoved,
+}
+
+// SignatureHeader is the HTTP header a delivery signature is written to
+const SignatureHeader = "X-Registry-Signature"
+
+// EventHeader is the HTTP header the event type of a delivery is written to
+const EventHeader = "X-Registry-Event"
+
+// Event is a single occurrence of a registry event, this is the JSON body
+// that's sent to subscribers
+type Event struct {
+	ID      string      `json:"id"`
+	Type    EventType   `json:"type"`
+	Created time.Time   `json:"created"`
+	Data    interface{} `json:"data"`
+}
+
+// Subscription is a request to have events delivered to a URL
+type Subscription struct {
+	ID      string      `json:"id"`
+	URL     string      `json:"url"`
+	Events  []EventType `json:"events"`
+	Secret  string      `json:"secret,omitempty"`
+	Created time.Time   `json:"created"`
+}
+
+// Validate is a sanity check that all required values are present
+func (s *Subscription) Validate() error {
+	if s.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %s", err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+	if len(s.Events) == 0 {
+		return fmt.Errorf("events are required")
+	}
+	for _, t := range s.Events {
+		if !validEventType(t) {
+			return fmt.Errorf("unknown event type '%s'", t)
+		}
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	return nil
+}
+
+// Redacted returns a copy of the subscription with the secret removed
+func (s *Subscription) Redacted() *Subscription {
+	r := *s
+	r.Secret = ""
+	return &r
+}
+
+// Wants returns true if the subscription is listening for event type t
+func (s *Subscription) Wants(t EventType) bool {
+	for _, et := range s.Events {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+func validEventType(t EventType) bool {
+	for _, et := range EventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign creates a hex-encoded HMAC-SHA256 signature of body using secret.
+// Signatures are written to the SignatureHeader prefixed with "sha256="
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature header value against a body & secret,
+// receivers can use this to confirm a delivery came from the registry
+func VerifySignature(secret, signature string, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// NewID generates a random identifier for subscriptions & events
+func NewID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Subscriptions is the interface for working with a set of *Subscription's
+// Subscriptions carry secrets, and should only be exposed in administrative
+// contexts
+type Subscriptions interface {
+	// Len returns the number of records in the set
+	Len() int
+	// Load fetches a subscription from the set by ID
+	Load(id string) (value *Subscription, ok bool)
+	// Range calls an iteration fuction on each element in the map until
+	// the end of the list is reached or iter returns true
+	Range(iter func(id string, s *Subscription) (brk bool))
+	// SortedRange is like range but with deterministic key ordering
+	SortedRange(iter func(id string, s *Subscription) (brk bool))
+	// Store adds an entry
+	Store(id string, value *Subscription)
+	// Delete removes a record from the set at id
+	Delete(id string)
+}
+
+// MemSubscriptions is a map of subscription data safe for concurrent use
+type MemSubscriptions struct {
+	sync.RWMutex
+	internal map[string]*Subscription
+}
+
+// NewMemSubscriptions allocates a new *MemSubscriptions map
+func NewMemSubscriptions() *MemSubscriptions {
+	return &MemSubscriptions{
+		internal: make(map[string]*Subscription),
+	}
+}
+
+// Len returns the number of records in the map
+func (ss *MemSubscriptions) Len() int {
+	ss.RLock()
+	defer ss.RUnlock()
+	return len(ss.internal)
+}
+
+// Load fetches a subscription from the map by ID
+func (ss *MemSubscriptions) Load(id string) (value *Subscription, ok bool) {
+	ss.RLock()
+	value, ok = ss.internal[id]
+	ss.RUnlock()
+	return
+}
+
+// Range calls an iteration fuction on each element in the map until
+// the end of the list is reached or iter returns true
+func (ss *MemSubscriptions) Range(iter func(id string, s *Subscription) (brk bool)) {
+	ss.RLock()
+	defer ss.RUnlock()
+	for id, s := range ss.internal {
+		if iter(id, s) {
+			break
+		}
+	}
+}
+
+// SortedRange is like range but with deterministic key ordering
+func (ss *MemSubscriptions) SortedRange(iter func(id string, s *Subscription) (brk bool)) {
+	ss.RLock()
+	defer ss.RUnlock()
+	ids := make([]string, 0, len(ss.internal))
+	for id := range ss.internal {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if iter(id, ss.internal[id]) {
+			break
+		}
+	}
+}
+
+// Store adds an entry
+func (ss *MemSubscriptions) Store(id string, value *Subscription) {
+	ss.Lock()
+	ss.internal[id] = value
+	ss.Unlock()
+}
+
+// Delete removes a record from MemSubscriptions at id
+func (ss *MemSubscriptions) Delete(id string) {
+	ss.Lock()
+	delete(ss.internal, id)
+	ss.Unlock()
+}
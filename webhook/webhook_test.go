@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestSubscriptionValidate(t *testing.T) {
+	cases := []struct {
+		s   Subscription
+		err string
+	}{
+		{Subscription{}, "url is required"},
+		{Subscription{URL: "ftp://example.com"}, "url scheme must be http or https"},
+		{Subscription{URL: "http://example.com"}, "events are required"},
+		{Subscription{URL: "http://example.com", Events: []EventType{"foo"}}, "unknown event type 'foo'"},
+		{Subscription{URL: "http://example.com", Events: []EventType{EventPinComplete}}, "secret is required"},
+		{Subscription{URL: "http://example.com", Events: []EventType{EventPinComplete}, Secret: "s"}, ""},
+	}
+
+	for i, c := range cases {
+		err := c.s.Validate()
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestSubscriptionWants(t *testing.T) {
+	s := &Subscription{Events: []EventType{EventDatasetPublished, EventProfileChanged}}
+	if !s.Wants(EventDatasetPublished) {
+		t.Errorf("expected subscription to want %s", EventDatasetPublished)
+	}
+	if s.Wants(EventPinComplete) {
+		t.Errorf("expected subscription to not want %s", EventPinComplete)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"type":"pin:complete"}`)
+	sig := Sign("secret", body)
+	if len(sig) != len("sha256=")+64 {
+		t.Errorf("unexpected signature length: %s", sig)
+	}
+	if !VerifySignature("secret", sig, body) {
+		t.Errorf("expected signature to verify")
+	}
+	if VerifySignature("wrong", sig, body) {
+		t.Errorf("expected signature with wrong secret to fail")
+	}
+	if VerifySignature("secret", sig, []byte(`{}`)) {
+		t.Errorf("expected signature with altered body to fail")
+	}
+}
+
+func TestMemSubscriptions(t *testing.T) {
+	ss := NewMemSubscriptions()
+	ss.Store("b", &Subscription{ID: "b"})
+	ss.Store("a", &Subscription{ID: "a"})
+
+	if ss.Len() != 2 {
+		t.Errorf("expected len to equal 2, got: %d", ss.Len())
+	}
+	if _, ok := ss.Load("a"); !ok {
+		t.Errorf("expected 'a' to load")
+	}
+
+	ids := []string{}
+	ss.SortedRange(func(id string, s *Subscription) bool {
+		ids = append(ids, id)
+		return false
+	})
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("sorted range order mismatch. got: %v", ids)
+	}
+
+	ss.Delete("a")
+	if _, ok := ss.Load("a"); ok {
+		t.Errorf("expected 'a' to be deleted")
+	}
+}